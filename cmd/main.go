@@ -2,6 +2,15 @@ package main
 
 import "github.com/kalom60/cashflow/initiator"
 
+// @securityDefinitions.apikey	ApiKeyAuth
+// @in							header
+// @name						X-API-Key
+// @description				API key issued to a merchant. Every payment, refund and key route is scoped to the key's merchant.
+//
+// @securityDefinitions.apikey	AdminKeyAuth
+// @in							header
+// @name						X-Admin-Key
+// @description				Shared operator key configured as auth.admin_key.
 func main() {
 	initiator.Initiate()
 }
@@ -2,7 +2,9 @@ package initiator
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -10,6 +12,27 @@ import (
 	"go.uber.org/zap"
 )
 
+// reloaders run, in registration order, after a config change has passed
+// validation.
+var (
+	reloadersMu sync.Mutex
+	reloaders   []reloader
+)
+
+type reloader struct {
+	name   string
+	reload func() error
+}
+
+// onConfigReload registers reload to be called whenever the config file
+// changes. An error from reload is logged and leaves the old value in place.
+func onConfigReload(name string, reload func() error) {
+	reloadersMu.Lock()
+	defer reloadersMu.Unlock()
+
+	reloaders = append(reloaders, reloader{name: name, reload: reload})
+}
+
 type Config struct {
 	Name   string
 	Path   string
@@ -77,6 +100,10 @@ func InitConfig(config Config) error {
 	return nil
 }
 
+// placeholderAdminKeys are sample values that must never guard the admin
+// routes.
+var placeholderAdminKeys = []string{"change-me", "changeme", "admin", "secret"}
+
 func validateConfig() error {
 
 	requiredKeys := []string{}
@@ -86,6 +113,10 @@ func validateConfig() error {
 			return fmt.Errorf("missing required configuration key: %s", key)
 		}
 	}
+
+	if adminKey := viper.GetString("auth.admin_key"); slices.Contains(placeholderAdminKeys, strings.ToLower(adminKey)) {
+		return fmt.Errorf("auth.admin_key is set to the placeholder %q, set APPLICATION_AUTH_ADMIN_KEY to a secret instead", adminKey)
+	}
 	return nil
 }
 
@@ -101,7 +132,33 @@ func setupConfigWatcher(log *zap.Logger) error {
 			log.Error("Invalid configuration after change",
 				zap.Error(err),
 			)
+			return
+		}
+
+		reloadersMu.Lock()
+		defer reloadersMu.Unlock()
+
+		for _, r := range reloaders {
+			if err := r.reload(); err != nil {
+				log.Error("Failed to apply configuration change",
+					zap.String("setting", r.name),
+					zap.Error(err),
+				)
+			}
 		}
 	})
 	return nil
 }
+
+func parseDurations(values []string) ([]time.Duration, error) {
+	durations := make([]time.Duration, 0, len(values))
+	for _, value := range values {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+		durations = append(durations, duration)
+	}
+
+	return durations, nil
+}
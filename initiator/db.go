@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/tracing"
 	"github.com/spf13/viper"
 )
 
@@ -34,6 +36,15 @@ func initDB(dbSource string, log logger.Logger) *pgxpool.Pool {
 	}
 	config.MaxConnIdleTime = idleConnTimeout
 
+	// Queries are reported at info level, which the tracing logger turns
+	// into spans. Only entries at db.log_level or above reach the log.
+	logLevel, err := pgx.LogLevelFromString(viper.GetString("db.log_level"))
+	if err != nil {
+		logLevel = pgx.LogLevelNone
+	}
+	config.ConnConfig.Logger = tracing.QueryLogger(log, logLevel)
+	config.ConnConfig.LogLevel = pgx.LogLevelInfo
+
 	conn, err := pgxpool.ConnectConfig(context.Background(), config)
 	if err != nil {
 		log.Fatal(context.Background(), fmt.Sprintf("failed to connect to database (%s): %v", dbSource, err))
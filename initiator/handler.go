@@ -2,16 +2,32 @@ package initiator
 
 import (
 	"github.com/kalom60/cashflow/internal/handler"
+	deadletter "github.com/kalom60/cashflow/internal/handler/dead_letter"
+	"github.com/kalom60/cashflow/internal/handler/health"
+	"github.com/kalom60/cashflow/internal/handler/merchant"
 	"github.com/kalom60/cashflow/internal/handler/payment"
+	"github.com/kalom60/cashflow/internal/handler/refund"
+	"github.com/kalom60/cashflow/internal/handler/webhook"
 	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/messaging"
 )
 
 type Handler struct {
-	Payment handler.Payment
+	Payment    handler.Payment
+	Refund     handler.Refund
+	Health     handler.Health
+	DeadLetter handler.DeadLetter
+	Merchant   handler.Merchant
+	Webhook    handler.Webhook
 }
 
-func initHandler(module *Module, log logger.Logger) *Handler {
+func initHandler(module *Module, msgClient messaging.MessagingClient, log logger.Logger) *Handler {
 	return &Handler{
-		Payment: payment.Init(log, module.Payment),
+		Payment:    payment.Init(log, module.Payment),
+		Refund:     refund.Init(log, module.Refund),
+		Health:     health.Init(log, msgClient),
+		DeadLetter: deadletter.Init(log, module.DeadLetter),
+		Merchant:   merchant.Init(log, module.Merchant),
+		Webhook:    webhook.Init(log, module.Webhook),
 	}
 }
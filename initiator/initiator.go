@@ -2,6 +2,7 @@ package initiator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -11,7 +12,8 @@ import (
 
 	"github.com/kalom60/cashflow/docs"
 	"github.com/kalom60/cashflow/internal/constant/model/persistencedb"
-	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/kalom60/cashflow/internal/glue/middleware"
+	"github.com/kalom60/cashflow/platform/metrics"
 	"github.com/kalom60/cashflow/platform/workerpool"
 	"github.com/labstack/echo/v4"
 
@@ -48,6 +50,10 @@ func Initiate() {
 	logger := InitLogger()
 	log.Info("initializing logger completed")
 
+	logger.Info(ctx, "initializing tracing")
+	shutdownTracing := initTracing(logger)
+	logger.Info(ctx, "done initializing tracing")
+
 	// initailizing database connection
 	log.Info("initializing database connect")
 	pgxPool := initDB("cashflow", logger)
@@ -64,33 +70,40 @@ func Initiate() {
 	logger.Info(ctx, "done initializing persistence layer")
 
 	logger.Info(ctx, "initializing waorker pool")
-	wp := workerpool.New(viper.GetInt("workerpool.max_workers"), viper.GetInt("workerpool.task_buffer"))
+	wp := workerpool.New(viper.GetInt("workerpool.max_workers"), viper.GetInt("workerpool.task_buffer"), workerpool.Options{
+		PanicHandler: func(recovered any, stack []byte) {
+			logger.Named("WorkerPool-Task").Error(ctx, "worker pool task panicked", zap.Any("panic", recovered), zap.ByteString("stack", stack))
+		},
+		TaskTimeout: viper.GetDuration("workerpool.task_timeout"),
+	})
 	wp.Start()
 	logger.Info(ctx, "done initializing worker pool")
 
-	logger.Info(ctx, "initializing rabbitmq client")
-	rabbitMQURL := viper.GetString("rabbitmq.url")
-	msgClient, err := messaging.NewRabbitMQClient(rabbitMQURL)
-	if err != nil {
-		logger.Fatal(ctx, "failed to initialize RabbitMQ client", zap.Error(err))
-	}
-	logger.Info(ctx, "rabbitmq client initialized")
+	logger.Info(ctx, "initializing messaging client")
+	msgClient := initMessaging(logger)
+	logger.Info(ctx, "messaging client initialized")
 
 	logger.Info(ctx, "initializing module layer")
-	module := initModule(persistence, msgClient, logger, wp)
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	module := initModule(workerCtx, persistence, msgClient, logger, wp)
+	registerReloaders(logger, wp, msgClient, module.OutboxEvent)
 	logger.Info(ctx, "done initializing module layer")
 
 	logger.Info(ctx, "initializing handler layer ")
-	handler := initHandler(module, logger)
+	handler := initHandler(module, msgClient, logger)
 	logger.Info(ctx, "done initializing handler layer")
 
 	logger.Info(ctx, "initializing http server")
 	server := echo.New()
+	server.Use(middleware.RequestID(), middleware.Tracing(), middleware.Metrics())
 	echosrv := server.Group("")
 	echosrv.GET("/swagger/*any", echoSwagger.EchoWrapHandler())
+	echosrv.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+	metrics.RegisterWorkerPool(wp)
+	metrics.RegisterDBPool(pgxPool)
 
 	logger.Info(ctx, "initializing route")
-	initRoute(echosrv, handler, logger)
+	initRoute(echosrv, handler, module, logger)
 	logger.Info(ctx, "done initializing route")
 
 	logger.Info(ctx, "done initializing server")
@@ -103,22 +116,24 @@ func Initiate() {
 	}
 
 	go func() {
-		sigint := make(chan os.Signal, 1)
-		signal.Notify(sigint, syscall.SIGINT, syscall.SIGTERM)
-		<-sigint
-
-		log.Info("Shutting down... closing RabbitMQ client")
-		if err := msgClient.Close(); err != nil {
-			log.Error("failed to close RabbitMQ client", zap.Error(err))
+		host := fmt.Sprint(viper.GetString("app.host"), ":", viper.GetInt("app.port"))
+		logger.Info(ctx, "server listening at port ", zap.Any("link", host))
+		err := srv.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal(ctx, fmt.Sprintf("Could not start HTTP server: %s", err))
 		}
-
-		log.Fatal("HTTP server Shutdown")
 	}()
 
-	host := fmt.Sprint(viper.GetString("app.host"), ":", viper.GetInt("app.port"))
-	logger.Info(ctx, "server listening at port ", zap.Any("link", host))
-	err = srv.ListenAndServe()
-	if err != nil {
-		logger.Fatal(ctx, fmt.Sprintf("Could not start HTTP server: %s", err))
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, syscall.SIGINT, syscall.SIGTERM)
+	<-sigint
+
+	shutdownTimeout := viper.GetDuration("app.shutdown_timeout")
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 30 * time.Second
 	}
+	shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancel()
+
+	shutdown(shutdownCtx, logger, srv, stopWorkers, wp, module, msgClient, pgxPool, shutdownTracing)
 }
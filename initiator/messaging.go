@@ -0,0 +1,63 @@
+package initiator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+func initMessaging(log logger.Logger) messaging.MessagingClient {
+	ctx := context.Background()
+
+	retryDelays, err := parseDurations(viper.GetStringSlice("rabbitmq.retry_delays"))
+	if err != nil {
+		log.Fatal(ctx, "invalid rabbitmq.retry_delays", zap.Error(err))
+	}
+
+	opts := messaging.Options{
+		ConfirmTimeout: viper.GetDuration("rabbitmq.confirm_timeout"),
+		MaxAttempts:    viper.GetInt("rabbitmq.max_attempts"),
+		RetryDelays:    retryDelays,
+		Prefetch:       prefetch(),
+	}
+
+	switch driver := viper.GetString("messaging.driver"); driver {
+	case "", "rabbitmq":
+		msgClient, err := messaging.NewRabbitMQClient(viper.GetString("rabbitmq.url"), opts)
+		if err != nil {
+			log.Fatal(ctx, "failed to initialize RabbitMQ client", zap.Error(err))
+		}
+		return msgClient
+	case "memory":
+		log.Warn(ctx, "using in-memory messaging, queued messages are lost on restart")
+		return messaging.NewInMemoryClient(opts)
+	default:
+		log.Fatal(ctx, fmt.Sprintf("unknown messaging driver: %s", driver))
+		return nil
+	}
+}
+
+// consumers is how many consumers share the worker pool, one for payments
+// and one for refunds.
+const consumers = 2
+
+// prefetch is rabbitmq.prefetch, or an even share of the worker pool when it
+// is unset.
+func prefetch() int {
+	if prefetch := viper.GetInt("rabbitmq.prefetch"); prefetch > 0 {
+		return prefetch
+	}
+
+	workers := viper.GetInt("workerpool.max_workers")
+	if workers <= 0 {
+		return 0
+	}
+
+	// The prefetch applies to each consumer, split the pool between them so
+	// together they hold no more messages than it can work on
+	return max(workers/consumers, 1)
+}
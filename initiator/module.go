@@ -5,20 +5,36 @@ import (
 	"time"
 
 	"github.com/kalom60/cashflow/internal/module"
+	deadletter "github.com/kalom60/cashflow/internal/module/dead_letter"
+	"github.com/kalom60/cashflow/internal/module/idempotency"
+	"github.com/kalom60/cashflow/internal/module/merchant"
 	outboxevent "github.com/kalom60/cashflow/internal/module/outbox_event"
 	"github.com/kalom60/cashflow/internal/module/payment"
+	"github.com/kalom60/cashflow/internal/module/refund"
+	"github.com/kalom60/cashflow/internal/module/webhook"
 	"github.com/kalom60/cashflow/platform/logger"
 	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/kalom60/cashflow/platform/processor"
 	"github.com/kalom60/cashflow/platform/workerpool"
 	"github.com/spf13/viper"
 )
 
 type Module struct {
-	Payment     module.Payment
-	OutboxEvent outboxevent.OutboxEventWorker
+	Payment            module.Payment
+	OutboxEvent        *outboxevent.OutboxEventWorker
+	WebhookWorker      *webhook.WebhookWorker
+	Refund             module.Refund
+	Idempotency        module.Idempotency
+	IdempotencyCleaner *idempotency.Cleaner
+	DeadLetter         module.DeadLetter
+	Merchant           module.Merchant
+	Webhook            module.Webhook
 }
 
+// initModule builds the modules and starts the background workers. The
+// workers stop consuming and polling once ctx is cancelled.
 func initModule(
+	ctx context.Context,
 	persistence *Persistance,
 	msgClient messaging.MessagingClient,
 	log logger.Logger,
@@ -26,22 +42,74 @@ func initModule(
 ) *Module {
 	paymentStorage := persistence.Payement
 	outboxEventStorage := persistence.OutboxEvent
+	refundStorage := persistence.Refund
 
 	paymentModule := payment.Init(log, paymentStorage)
+	refundModule := refund.Init(log, refundStorage, paymentStorage)
+	idempotencyModule := idempotency.Init(log, persistence.Idempotency, viper.GetDuration("idempotency.lock_timeout"), viper.GetDuration("idempotency.retention"))
+	paymentProcessor := processor.NewSimulator()
 
-	interval := viper.GetDuration("app.interval")
-	duration := interval * time.Second
-	outboxEventModule := outboxevent.Init(log, outboxEventStorage, msgClient, duration)
+	retryPolicy := outboxevent.NewRetryPolicy(
+		viper.GetInt("outbox.max_attempts"),
+		viper.GetDuration("outbox.base_backoff"),
+		viper.GetDuration("outbox.max_backoff"),
+	)
+	webhookRetryPolicy := webhook.NewRetryPolicy(
+		viper.GetDuration("webhook.base_backoff"),
+		viper.GetDuration("webhook.max_backoff"),
+		viper.GetDuration("webhook.retry_window"),
+	)
+	webhookModule := webhook.Init(log, persistence.Webhook, webhookRetryPolicy)
+
+	outboxEventModule := outboxevent.Init(log, outboxEventStorage, outboxevent.DefaultRegistry(msgClient, webhookModule), retryPolicy, outboxSettings())
 
 	// Start Global Outbox Worker
-	go outboxEventModule.Start(context.Background())
+	go outboxEventModule.Start(ctx)
+
+	// Start Webhook Worker
+	webhookWorker := webhook.NewWebhookWorker(
+		log,
+		persistence.Webhook,
+		webhook.NewSender(viper.GetDuration("webhook.timeout"), viper.GetBool("webhook.allow_private_networks")),
+		webhookRetryPolicy,
+		webhook.Settings{
+			Interval:  viper.GetDuration("webhook.interval"),
+			BatchSize: viper.GetInt("webhook.batch_size"),
+			Timeout:   viper.GetDuration("webhook.timeout"),
+		},
+	)
+	go webhookWorker.Start(ctx)
+
+	// Start Idempotency Key Cleaner
+	idempotencyCleaner := idempotency.NewCleaner(log, persistence.Idempotency, viper.GetDuration("idempotency.retention"), viper.GetDuration("idempotency.cleanup_interval"))
+	go idempotencyCleaner.Start(ctx)
 
 	// Start Payment Status Consumer
-	paymentWorker := payment.NewPaymentWorker(log, pool, paymentStorage, msgClient)
-	paymentWorker.Start(context.Background())
+	paymentWorker := payment.NewPaymentWorker(log, pool, paymentStorage, msgClient, paymentProcessor)
+	paymentWorker.Start(ctx)
+
+	// Start Refund Consumer
+	refundWorker := refund.NewRefundWorker(log, pool, refundStorage, paymentStorage, msgClient, paymentProcessor)
+	refundWorker.Start(ctx)
 
 	return &Module{
-		Payment:     paymentModule,
-		OutboxEvent: *outboxEventModule,
+		Payment:            paymentModule,
+		OutboxEvent:        outboxEventModule,
+		WebhookWorker:      webhookWorker,
+		Refund:             refundModule,
+		Idempotency:        idempotencyModule,
+		IdempotencyCleaner: idempotencyCleaner,
+		DeadLetter:         deadletter.Init(log, msgClient),
+		Merchant:           merchant.Init(log, persistence.Merchant),
+		Webhook:            webhookModule,
+	}
+}
+
+// outboxSettings reads the outbox worker settings. app.interval is in
+// seconds.
+func outboxSettings() outboxevent.Settings {
+	return outboxevent.Settings{
+		Interval:  time.Duration(viper.GetInt("app.interval")) * time.Second,
+		BatchSize: viper.GetInt("app.limit"),
 	}
 }
@@ -3,24 +3,38 @@ package initiator
 import (
 	"github.com/kalom60/cashflow/internal/constant/model/persistencedb"
 	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/internal/storage/idempotency"
+	"github.com/kalom60/cashflow/internal/storage/merchant"
 	outboxevent "github.com/kalom60/cashflow/internal/storage/outbox_event"
 	"github.com/kalom60/cashflow/internal/storage/payment"
+	"github.com/kalom60/cashflow/internal/storage/refund"
+	"github.com/kalom60/cashflow/internal/storage/webhook"
 	"github.com/kalom60/cashflow/platform/logger"
-	"github.com/spf13/viper"
 )
 
 type Persistance struct {
 	Payement    storage.Payment
 	OutboxEvent storage.OutboxEvent
+	Refund      storage.Refund
+	Idempotency storage.Idempotency
+	Merchant    storage.Merchant
+	Webhook     storage.Webhook
 }
 
 func initPersistence(persistencedb *persistencedb.PersistenceDB, log logger.Logger) *Persistance {
-	limit := viper.GetInt("app.limit")
 	paymentStorage := payment.Init(log, persistencedb)
-	outboxEventStorage := outboxevent.Init(log, persistencedb, limit)
+	outboxEventStorage := outboxevent.Init(log, persistencedb)
+	refundStorage := refund.Init(log, persistencedb)
+	idempotencyStorage := idempotency.Init(log, persistencedb)
+	merchantStorage := merchant.Init(log, persistencedb)
+	webhookStorage := webhook.Init(log, persistencedb)
 
 	return &Persistance{
 		Payement:    paymentStorage,
 		OutboxEvent: outboxEventStorage,
+		Refund:      refundStorage,
+		Idempotency: idempotencyStorage,
+		Merchant:    merchantStorage,
+		Webhook:     webhookStorage,
 	}
 }
@@ -0,0 +1,64 @@
+package initiator
+
+import (
+	"context"
+
+	outboxevent "github.com/kalom60/cashflow/internal/module/outbox_event"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/kalom60/cashflow/platform/workerpool"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// registerReloaders lets the worker pool size, the consumer prefetch and the
+// outbox settings follow the config file without a restart.
+func registerReloaders(log logger.Logger, pool *workerpool.WorkerPool, msgClient messaging.MessagingClient, outbox *outboxevent.OutboxEventWorker) {
+	ctx := context.Background()
+
+	onConfigReload("workerpool.max_workers", func() error {
+		size := viper.GetInt("workerpool.max_workers")
+		if size == pool.Size() {
+			return nil
+		}
+
+		if err := pool.Resize(size); err != nil {
+			return err
+		}
+
+		log.Info(ctx, "worker pool resized", zap.Int("max_workers", size))
+		return nil
+	})
+
+	// Registered after the pool so a prefetch that follows max_workers is
+	// only raised once the workers exist
+	applied := prefetch()
+	onConfigReload("rabbitmq.prefetch", func() error {
+		size := prefetch()
+		if size == applied {
+			return nil
+		}
+
+		if err := msgClient.SetPrefetch(size); err != nil {
+			return err
+		}
+		applied = size
+
+		log.Info(ctx, "consumer prefetch changed", zap.Int("prefetch", size))
+		return nil
+	})
+
+	onConfigReload("outbox", func() error {
+		settings := outboxSettings()
+		if settings == outbox.Settings() {
+			return nil
+		}
+
+		if err := outbox.Reconfigure(settings); err != nil {
+			return err
+		}
+
+		log.Info(ctx, "outbox worker reconfigured", zap.Duration("interval", settings.Interval), zap.Int("batch_size", settings.BatchSize))
+		return nil
+	})
+}
@@ -1,11 +1,35 @@
 package initiator
 
 import (
+	"context"
+
+	deadletter "github.com/kalom60/cashflow/internal/glue/dead_letter"
+	"github.com/kalom60/cashflow/internal/glue/health"
+	"github.com/kalom60/cashflow/internal/glue/merchant"
+	"github.com/kalom60/cashflow/internal/glue/middleware"
 	"github.com/kalom60/cashflow/internal/glue/payment"
+	"github.com/kalom60/cashflow/internal/glue/refund"
+	"github.com/kalom60/cashflow/internal/glue/webhook"
 	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/nonce"
 	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
 )
 
-func initRoute(eg *echo.Group, handler *Handler, logger logger.Logger) {
-	payment.RegisterPaymentRoutes(eg, handler.Payment, logger)
+func initRoute(eg *echo.Group, handler *Handler, module *Module, logger logger.Logger) {
+	idempotency := middleware.Idempotency(module.Idempotency, logger)
+	auth := middleware.Auth(module.Merchant, logger)
+	adminKey := viper.GetString("auth.admin_key")
+	if adminKey == "" {
+		logger.Warn(context.Background(), "auth.admin_key is not set, the admin routes reject every request")
+	}
+	admin := middleware.AdminAuth(adminKey, logger)
+	signature := middleware.Signature(module.Merchant, nonce.NewStore(), viper.GetDuration("signing.max_skew"), logger)
+
+	payment.RegisterPaymentRoutes(eg, handler.Payment, auth, signature, idempotency, logger)
+	refund.RegisterRefundRoutes(eg, handler.Refund, auth, logger)
+	merchant.RegisterMerchantRoutes(eg, handler.Merchant, auth, signature, admin, logger)
+	webhook.RegisterWebhookRoutes(eg, handler.Webhook, auth, signature, logger)
+	health.RegisterHealthRoutes(eg, handler.Health, logger)
+	deadletter.RegisterDeadLetterRoutes(eg, handler.DeadLetter, admin, logger)
 }
@@ -0,0 +1,74 @@
+package initiator
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/kalom60/cashflow/platform/workerpool"
+	"go.uber.org/zap"
+)
+
+// shutdown stops the service in dependency order so that no payment is left
+// half-processed: HTTP first, then the consumers and the outbox, then the
+// pool, and the broker and database connections last because running tasks
+// still need them. Every step shares the deadline on ctx.
+func shutdown(
+	ctx context.Context,
+	log logger.Logger,
+	srv *http.Server,
+	stopWorkers context.CancelFunc,
+	pool *workerpool.WorkerPool,
+	module *Module,
+	msgClient messaging.MessagingClient,
+	pgxPool *pgxpool.Pool,
+	shutdownTracing func(context.Context) error,
+) {
+	log.Info(ctx, "shutting down... stopping HTTP server")
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Named("Shutdown-HTTP").Error(ctx, "failed to shut down HTTP server", zap.Error(err))
+	}
+
+	log.Info(ctx, "stopping consumers, outbox and webhook workers")
+	stopWorkers()
+
+	log.Info(ctx, "waiting for worker pool tasks", zap.Int("in_flight", msgClient.InFlight()))
+	if err := pool.StopAndWait(ctx); err != nil {
+		log.Named("Shutdown-WorkerPool").Error(ctx, "worker pool did not drain before the deadline", zap.Int("in_flight", msgClient.InFlight()), zap.Error(err))
+	}
+
+	select {
+	case <-module.OutboxEvent.Done():
+	case <-ctx.Done():
+		log.Named("Shutdown-Outbox").Error(ctx, "outbox worker did not stop before the deadline", zap.Error(ctx.Err()))
+	}
+
+	select {
+	case <-module.WebhookWorker.Done():
+	case <-ctx.Done():
+		log.Named("Shutdown-Webhook").Error(ctx, "webhook worker did not stop before the deadline", zap.Error(ctx.Err()))
+	}
+
+	select {
+	case <-module.IdempotencyCleaner.Done():
+	case <-ctx.Done():
+		log.Named("Shutdown-Idempotency").Error(ctx, "idempotency key cleaner did not stop before the deadline", zap.Error(ctx.Err()))
+	}
+
+	log.Info(ctx, "closing messaging client")
+	if err := msgClient.Close(); err != nil {
+		log.Named("Shutdown-Messaging").Error(ctx, "failed to close messaging client", zap.Error(err))
+	}
+
+	log.Info(ctx, "closing database pool")
+	pgxPool.Close()
+
+	log.Info(ctx, "flushing traces")
+	if err := shutdownTracing(ctx); err != nil {
+		log.Named("Shutdown-Tracing").Error(ctx, "failed to flush traces", zap.Error(err))
+	}
+
+	log.Info(ctx, "shutdown complete")
+}
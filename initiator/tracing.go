@@ -0,0 +1,27 @@
+package initiator
+
+import (
+	"context"
+
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/tracing"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+func initTracing(log logger.Logger) func(context.Context) error {
+	ctx := context.Background()
+
+	shutdown, err := tracing.Init(ctx, tracing.Config{
+		Exporter:    viper.GetString("tracing.exporter"),
+		Endpoint:    viper.GetString("tracing.endpoint"),
+		Insecure:    viper.GetBool("tracing.insecure"),
+		SampleRatio: viper.GetFloat64("tracing.sample_ratio"),
+		ServiceName: "cashflow",
+	})
+	if err != nil {
+		log.Fatal(ctx, "failed to initialize tracing", zap.Error(err))
+	}
+
+	return shutdown
+}
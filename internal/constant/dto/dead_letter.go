@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Dead-letter queues exposed by the admin API.
+const (
+	DeadLetterQueuePayments = "payments"
+	DeadLetterQueueRefunds  = "refunds"
+)
+
+const (
+	DefaultDeadLetterLimit = 10
+	MaxDeadLetterLimit     = 100
+)
+
+// DeadLetter is a message that was rejected by its worker and parked in a
+// dead-letter queue.
+type DeadLetter struct {
+	MessageID      string          `json:"message_id,omitempty"`
+	Body           json.RawMessage `json:"body" swaggertype:"object"`
+	Queue          string          `json:"queue,omitempty" example:"payments.v2"`
+	Reason         string          `json:"reason,omitempty" example:"rejected"`
+	Count          int64           `json:"count" example:"1"`
+	DeadLetteredAt *time.Time      `json:"dead_lettered_at,omitempty"`
+}
+
+type DeadLetterRequest struct {
+	Limit string `query:"limit"`
+}
+
+// ToLimit validates the limit query parameter.
+func (r *DeadLetterRequest) ToLimit() (int, error) {
+	if r.Limit == "" {
+		return DefaultDeadLetterLimit, nil
+	}
+
+	limit, err := strconv.Atoi(r.Limit)
+	if err != nil || limit < 1 || limit > MaxDeadLetterLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", MaxDeadLetterLimit)
+	}
+
+	return limit, nil
+}
+
+type ReplayDeadLettersResponse struct {
+	Replayed int `json:"replayed" example:"3"`
+}
+
+type PurgeDeadLettersResponse struct {
+	Purged int `json:"purged" example:"3"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+type HealthResponse struct {
+	Status   string `json:"status" example:"ok"`
+	RabbitMQ string `json:"rabbitmq" example:"connected"`
+	InFlight int    `json:"in_flight" example:"3"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusInProgress IdempotencyStatus = "IN_PROGRESS"
+	IdempotencyStatusCompleted  IdempotencyStatus = "COMPLETED"
+)
+
+type IdempotencyKey struct {
+	Key          string            `json:"key"`
+	RequestHash  string            `json:"request_hash"`
+	Status       IdempotencyStatus `json:"status"`
+	ResponseCode int               `json:"response_code"`
+	ResponseBody []byte            `json:"response_body"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
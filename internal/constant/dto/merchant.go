@@ -0,0 +1,183 @@
+package dto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// APIKeyHeader carries the API key a merchant authenticates with.
+	APIKeyHeader = "X-API-Key"
+	// AdminKeyHeader carries the shared key that guards the admin routes.
+	AdminKeyHeader = "X-Admin-Key"
+
+	// DefaultRotationOverlap is how long a rotated key keeps working when the
+	// caller does not ask for a different overlap.
+	DefaultRotationOverlap = 24 * time.Hour
+	MaxRotationOverlap     = 30 * 24 * time.Hour
+
+	maxMerchantNameLength = 255
+	maxAPIKeyNameLength   = 100
+)
+
+type Merchant struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// APIKey is the stored part of a merchant's API key. The key itself is only
+// known when it is issued, afterwards only its hash is kept.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	MerchantID uuid.UUID  `json:"merchant_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Active reports whether the key can still be used at now.
+func (k APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// IssuedAPIKey is a freshly created API key together with its plaintext
+// value, which is returned to the caller once and never stored.
+type IssuedAPIKey struct {
+	APIKey
+	Key string
+}
+
+type merchantContextKey struct{}
+
+// WithMerchantID returns ctx carrying the ID of the authenticated merchant.
+func WithMerchantID(ctx context.Context, merchantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, merchantContextKey{}, merchantID)
+}
+
+// MerchantIDFromContext returns the authenticated merchant carried by ctx.
+func MerchantIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	merchantID, ok := ctx.Value(merchantContextKey{}).(uuid.UUID)
+	return merchantID, ok
+}
+
+type CreateMerchantRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *CreateMerchantRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if len(r.Name) > maxMerchantNameLength {
+		return fmt.Errorf("name cannot be longer than %d characters", maxMerchantNameLength)
+	}
+
+	return nil
+}
+
+func (r *CreateMerchantRequest) ToMerchant() Merchant {
+	return Merchant{
+		Name:      r.Name,
+		CreatedAt: time.Now(),
+	}
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *CreateAPIKeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if len(r.Name) > maxAPIKeyNameLength {
+		return fmt.Errorf("name cannot be longer than %d characters", maxAPIKeyNameLength)
+	}
+
+	return nil
+}
+
+// RotateAPIKeyRequest sets how long the old key keeps working next to the
+// new one. The default overlap applies when OverlapSeconds is omitted, zero
+// retires the old key straight away.
+type RotateAPIKeyRequest struct {
+	OverlapSeconds *int `json:"overlap_seconds"`
+}
+
+func (r *RotateAPIKeyRequest) Validate() error {
+	return validateOverlap(r.OverlapSeconds)
+}
+
+func (r *RotateAPIKeyRequest) Overlap() time.Duration {
+	return overlap(r.OverlapSeconds)
+}
+
+func validateOverlap(seconds *int) error {
+	if seconds == nil {
+		return nil
+	}
+
+	if *seconds < 0 {
+		return errors.New("overlap_seconds cannot be negative")
+	}
+
+	if time.Duration(*seconds)*time.Second > MaxRotationOverlap {
+		return fmt.Errorf("overlap_seconds cannot be more than %d", int(MaxRotationOverlap.Seconds()))
+	}
+
+	return nil
+}
+
+func overlap(seconds *int) time.Duration {
+	if seconds == nil {
+		return DefaultRotationOverlap
+	}
+
+	return time.Duration(*seconds) * time.Second
+}
+
+type MerchantResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type APIKeyResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Active    bool       `json:"active"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IssuedAPIKeyResponse is the only response that contains the key itself.
+type IssuedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type CreateMerchantResponse struct {
+	Merchant MerchantResponse     `json:"merchant"`
+	APIKey   IssuedAPIKeyResponse `json:"api_key"`
+}
+
+type RotateAPIKeyResponse struct {
+	Previous APIKeyResponse       `json:"previous"`
+	Current  IssuedAPIKeyResponse `json:"current"`
+}
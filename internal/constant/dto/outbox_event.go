@@ -15,10 +15,58 @@ const (
 	OutboxStatusFailed  OutboxStatus = "FAILED"
 )
 
+// Event types understood by the outbox dispatcher.
+const (
+	EventPaymentCreated   = "payment.created"
+	EventRefundCreated    = "refund.created"
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+)
+
+// HeaderCorrelationID is the outbox header that ties an event to the flow it
+// belongs to. It becomes the correlation_id of the published message.
+const HeaderCorrelationID = "correlation_id"
+
+// HeaderRequestID is the outbox header holding the ID of the HTTP request
+// that created the event. It becomes the request_id of the published message.
+const HeaderRequestID = "request_id"
+
+// Aggregate types an outbox event can belong to.
+const (
+	AggregatePayment = "payment"
+	AggregateRefund  = "refund"
+)
+
 type OutboxEvent struct {
-	ID        uuid.UUID    `json:"id"`
-	Payload   pgtype.JSONB `json:"payload"`
-	Status    OutboxStatus `json:"status"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
+	ID            uuid.UUID         `json:"id"`
+	EventType     string            `json:"event_type"`
+	AggregateType string            `json:"aggregate_type"`
+	AggregateID   uuid.UUID         `json:"aggregate_id"`
+	Payload       pgtype.JSONB      `json:"payload"`
+	Headers       map[string]string `json:"headers"`
+	Status        OutboxStatus      `json:"status"`
+	Attempts      int               `json:"attempts"`
+	LastError     string            `json:"last_error,omitempty"`
+	NextAttemptAt time.Time         `json:"next_attempt_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// OutboxFailure records a failed publish attempt. Status stays PENDING while
+// the event will be retried at NextAttemptAt, and becomes FAILED once the
+// event has used up its attempts.
+type OutboxFailure struct {
+	Status        OutboxStatus
+	LastError     string
+	NextAttemptAt time.Time
+}
+
+// NewOutboxEvent describes an event to be written to the outbox in the same
+// transaction as the change it announces. Payload is marshalled to JSON.
+type NewOutboxEvent struct {
+	EventType     string
+	AggregateType string
+	AggregateID   uuid.UUID
+	Payload       any
+	Headers       map[string]string
 }
@@ -19,19 +19,23 @@ const (
 type PaymentStatus string
 
 const (
-	PENDING PaymentStatus = "PENDING"
-	SUCCESS PaymentStatus = "SUCCESS"
-	FAILED  PaymentStatus = "FAILED"
+	PENDING            PaymentStatus = "PENDING"
+	PROCESSING         PaymentStatus = "PROCESSING"
+	SUCCESS            PaymentStatus = "SUCCESS"
+	FAILED             PaymentStatus = "FAILED"
+	PARTIALLY_REFUNDED PaymentStatus = "PARTIALLY_REFUNDED"
+	REFUNDED           PaymentStatus = "REFUNDED"
 )
 
 type Payment struct {
-	ID        uuid.UUID       `json:"id"`
-	Reference uuid.UUID       `json:"reference"`
-	Amount    decimal.Decimal `json:"amount"`
-	Currency  PaymentCurrency `json:"currency"`
-	Status    PaymentStatus   `json:"status"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+	ID         uuid.UUID       `json:"id"`
+	MerchantID uuid.UUID       `json:"merchant_id"`
+	Reference  uuid.UUID       `json:"reference"`
+	Amount     decimal.Decimal `json:"amount"`
+	Currency   PaymentCurrency `json:"currency"`
+	Status     PaymentStatus   `json:"status"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
 }
 
 type CreatePaymentRequest struct {
@@ -61,13 +65,14 @@ func (r *CreatePaymentRequest) Validate() error {
 	return nil
 }
 
-func (r *CreatePaymentRequest) ToPayment() Payment {
+func (r *CreatePaymentRequest) ToPayment(merchantID uuid.UUID) Payment {
 	return Payment{
-		Reference: r.Reference,
-		Amount:    r.Amount,
-		Currency:  r.Currency,
-		Status:    PENDING,
-		CreatedAt: time.Now(),
+		MerchantID: merchantID,
+		Reference:  r.Reference,
+		Amount:     r.Amount,
+		Currency:   r.Currency,
+		Status:     PENDING,
+		CreatedAt:  time.Now(),
 	}
 }
 
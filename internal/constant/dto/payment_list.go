@@ -0,0 +1,180 @@
+package dto
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DefaultPaymentPageSize = 20
+	MaxPaymentPageSize     = 100
+)
+
+// PaymentCursor is the keyset position of the last payment on a page.
+// Pages are ordered by (created_at, id) descending.
+type PaymentCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (c PaymentCursor) Encode() string {
+	raw := c.CreatedAt.Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodePaymentCursor(s string) (PaymentCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return PaymentCursor{}, errors.New("invalid cursor")
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return PaymentCursor{}, errors.New("invalid cursor")
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return PaymentCursor{}, errors.New("invalid cursor")
+	}
+
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return PaymentCursor{}, errors.New("invalid cursor")
+	}
+
+	return PaymentCursor{CreatedAt: t, ID: parsedID}, nil
+}
+
+// PaymentFilter narrows a payment listing. Nil or invalid fields are ignored,
+// except MerchantID: only that merchant's payments are ever listed.
+type PaymentFilter struct {
+	MerchantID  uuid.UUID
+	Status      *PaymentStatus
+	Currency    *PaymentCurrency
+	MinAmount   decimal.NullDecimal
+	MaxAmount   decimal.NullDecimal
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Reference   *uuid.UUID
+	Cursor      *PaymentCursor
+	Limit       int
+}
+
+type ListPaymentsRequest struct {
+	Status      string `query:"status"`
+	Currency    string `query:"currency"`
+	MinAmount   string `query:"min_amount"`
+	MaxAmount   string `query:"max_amount"`
+	CreatedFrom string `query:"created_from"`
+	CreatedTo   string `query:"created_to"`
+	Reference   string `query:"reference"`
+	Cursor      string `query:"cursor"`
+	Limit       string `query:"limit"`
+}
+
+// ToFilter validates the query parameters and converts them into a PaymentFilter.
+func (r *ListPaymentsRequest) ToFilter() (PaymentFilter, error) {
+	filter := PaymentFilter{Limit: DefaultPaymentPageSize}
+
+	if r.Status != "" {
+		status := PaymentStatus(r.Status)
+		switch status {
+		case PENDING, PROCESSING, SUCCESS, FAILED, PARTIALLY_REFUNDED, REFUNDED:
+		default:
+			return PaymentFilter{}, fmt.Errorf("invalid status: %s", r.Status)
+		}
+		filter.Status = &status
+	}
+
+	if r.Currency != "" {
+		currency := PaymentCurrency(r.Currency)
+		if currency != ETB && currency != USD {
+			return PaymentFilter{}, fmt.Errorf("invalid currency: %s", r.Currency)
+		}
+		filter.Currency = &currency
+	}
+
+	if r.MinAmount != "" {
+		amount, err := decimal.NewFromString(r.MinAmount)
+		if err != nil {
+			return PaymentFilter{}, errors.New("min_amount must be a number")
+		}
+		filter.MinAmount = decimal.NullDecimal{Decimal: amount, Valid: true}
+	}
+
+	if r.MaxAmount != "" {
+		amount, err := decimal.NewFromString(r.MaxAmount)
+		if err != nil {
+			return PaymentFilter{}, errors.New("max_amount must be a number")
+		}
+		filter.MaxAmount = decimal.NullDecimal{Decimal: amount, Valid: true}
+	}
+
+	if filter.MinAmount.Valid && filter.MaxAmount.Valid && filter.MinAmount.Decimal.GreaterThan(filter.MaxAmount.Decimal) {
+		return PaymentFilter{}, errors.New("min_amount cannot be greater than max_amount")
+	}
+
+	if r.CreatedFrom != "" {
+		t, err := time.Parse(time.RFC3339, r.CreatedFrom)
+		if err != nil {
+			return PaymentFilter{}, errors.New("created_from must be an RFC3339 timestamp")
+		}
+		filter.CreatedFrom = &t
+	}
+
+	if r.CreatedTo != "" {
+		t, err := time.Parse(time.RFC3339, r.CreatedTo)
+		if err != nil {
+			return PaymentFilter{}, errors.New("created_to must be an RFC3339 timestamp")
+		}
+		filter.CreatedTo = &t
+	}
+
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return PaymentFilter{}, errors.New("created_from must be before created_to")
+	}
+
+	if r.Reference != "" {
+		reference, err := uuid.Parse(r.Reference)
+		if err != nil {
+			return PaymentFilter{}, errors.New("invalid reference format")
+		}
+		filter.Reference = &reference
+	}
+
+	if r.Cursor != "" {
+		cursor, err := DecodePaymentCursor(r.Cursor)
+		if err != nil {
+			return PaymentFilter{}, err
+		}
+		filter.Cursor = &cursor
+	}
+
+	if r.Limit != "" {
+		limit, err := strconv.Atoi(r.Limit)
+		if err != nil || limit < 1 || limit > MaxPaymentPageSize {
+			return PaymentFilter{}, fmt.Errorf("limit must be between 1 and %d", MaxPaymentPageSize)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+type PaymentPage struct {
+	Payments   []Payment
+	NextCursor string
+}
+
+type ListPaymentsResponse struct {
+	Payments   []GetPaymentDetailsResponse `json:"payments"`
+	NextCursor string                      `json:"next_cursor,omitempty"`
+}
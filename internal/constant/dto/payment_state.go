@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actors recorded in the payment status history.
+const (
+	ActorAPI           = "api"
+	ActorPaymentWorker = "payment-worker"
+	ActorRefundWorker  = "refund-worker"
+)
+
+// paymentTransitions lists, for every status, the statuses a payment may move
+// to next. FAILED and REFUNDED are terminal.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PENDING:            {PROCESSING, FAILED},
+	PROCESSING:         {SUCCESS, FAILED},
+	SUCCESS:            {PARTIALLY_REFUNDED, REFUNDED},
+	PARTIALLY_REFUNDED: {PARTIALLY_REFUNDED, REFUNDED},
+	FAILED:             {},
+	REFUNDED:           {},
+}
+
+// CanTransitionTo reports whether a payment in status s may move to next.
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentTransition is a requested status change together with who asked for
+// it and why, as stored in the status history.
+type PaymentTransition struct {
+	Status PaymentStatus
+	Actor  string
+	Reason string
+}
+
+type PaymentStatusHistory struct {
+	ID         uuid.UUID      `json:"id"`
+	PaymentID  uuid.UUID      `json:"payment_id"`
+	FromStatus *PaymentStatus `json:"from_status"`
+	ToStatus   PaymentStatus  `json:"to_status"`
+	Actor      string         `json:"actor"`
+	Reason     string         `json:"reason,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
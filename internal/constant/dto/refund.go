@@ -0,0 +1,89 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type RefundStatus string
+
+const (
+	RefundStatusPending RefundStatus = "PENDING"
+	RefundStatusSuccess RefundStatus = "SUCCESS"
+	RefundStatusFailed  RefundStatus = "FAILED"
+)
+
+// RefundFailureStillPending is recorded on a refund the processor kept
+// reporting as pending until its message ran out of delivery attempts.
+const RefundFailureStillPending = "still_pending_after_retries"
+
+type Refund struct {
+	ID        uuid.UUID       `json:"id"`
+	PaymentID uuid.UUID       `json:"payment_id"`
+	Amount    decimal.Decimal `json:"amount"`
+	Currency  PaymentCurrency `json:"currency"`
+	Reason    string          `json:"reason"`
+	Status    RefundStatus    `json:"status"`
+	// FailureReason explains why a FAILED refund failed, it is empty otherwise
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CreateRefundRequest refunds the whole remaining amount when Amount is omitted.
+type CreateRefundRequest struct {
+	Amount decimal.NullDecimal `json:"amount" swaggertype:"number"`
+	Reason string              `json:"reason"`
+}
+
+func (r *CreateRefundRequest) Validate() error {
+	if len(r.Reason) > 255 {
+		return errors.New("reason cannot be longer than 255 characters")
+	}
+
+	if !r.Amount.Valid {
+		return nil
+	}
+
+	if r.Amount.Decimal.LessThanOrEqual(decimal.Zero) {
+		return errors.New("amount must be greater than zero")
+	}
+
+	// Max 2 decimal places as per NUMERIC(18,2)
+	if r.Amount.Decimal.Exponent() < -2 {
+		return errors.New("amount cannot have more than 2 decimal places")
+	}
+
+	return nil
+}
+
+// ToRefund leaves Amount at zero for a full refund, the storage layer resolves
+// it to whatever is still refundable while holding the payment lock.
+func (r *CreateRefundRequest) ToRefund(paymentID uuid.UUID) Refund {
+	refund := Refund{
+		PaymentID: paymentID,
+		Reason:    r.Reason,
+		Status:    RefundStatusPending,
+		CreatedAt: time.Now(),
+	}
+	if r.Amount.Valid {
+		refund.Amount = r.Amount.Decimal
+	}
+
+	return refund
+}
+
+type RefundResponse struct {
+	ID        uuid.UUID       `json:"id"`
+	PaymentID uuid.UUID       `json:"payment_id"`
+	Amount    decimal.Decimal `json:"amount"`
+	Currency  PaymentCurrency `json:"currency"`
+	Reason    string          `json:"reason"`
+	Status    RefundStatus    `json:"status"`
+	// FailureReason explains why a FAILED refund failed, it is empty otherwise
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
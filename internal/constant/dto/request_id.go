@@ -0,0 +1,3 @@
+package dto
+
+const RequestIDHeader = "X-Request-ID"
@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SignatureHeader carries the HMAC signature of a signed request.
+	SignatureHeader = "X-Signature"
+	// TimestampHeader carries the unix time, in seconds, a request was signed at.
+	TimestampHeader = "X-Timestamp"
+	// NonceHeader carries a value the client never reuses, so a signed request
+	// cannot be replayed.
+	NonceHeader = "X-Nonce"
+
+	// DefaultSignatureSkew is how far a request's timestamp may be from the
+	// server clock when signing.max_skew is not configured.
+	DefaultSignatureSkew = 5 * time.Minute
+
+	maxSigningSecretNameLength = 100
+)
+
+// SigningSecret is a secret a merchant signs its requests with. Unlike an
+// API key it is stored as is, since verifying a signature needs it.
+type SigningSecret struct {
+	ID         uuid.UUID  `json:"id"`
+	MerchantID uuid.UUID  `json:"merchant_id"`
+	Name       string     `json:"name"`
+	Secret     string     `json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Active reports whether the secret can still be used at now.
+func (s SigningSecret) Active(now time.Time) bool {
+	if s.RevokedAt != nil {
+		return false
+	}
+
+	return s.ExpiresAt == nil || now.Before(*s.ExpiresAt)
+}
+
+type CreateSigningSecretRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *CreateSigningSecretRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if len(r.Name) > maxSigningSecretNameLength {
+		return fmt.Errorf("name cannot be longer than %d characters", maxSigningSecretNameLength)
+	}
+
+	return nil
+}
+
+// RotateSigningSecretRequest sets how long the old secret keeps verifying
+// signatures next to the new one. The default overlap applies when
+// OverlapSeconds is omitted, zero retires the old secret straight away.
+type RotateSigningSecretRequest struct {
+	OverlapSeconds *int `json:"overlap_seconds"`
+}
+
+func (r *RotateSigningSecretRequest) Validate() error {
+	return validateOverlap(r.OverlapSeconds)
+}
+
+func (r *RotateSigningSecretRequest) Overlap() time.Duration {
+	return overlap(r.OverlapSeconds)
+}
+
+type SigningSecretResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Active    bool       `json:"active"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IssuedSigningSecretResponse is the only response that contains the secret
+// itself.
+type IssuedSigningSecretResponse struct {
+	SigningSecretResponse
+	Secret string `json:"secret"`
+}
+
+type RotateSigningSecretResponse struct {
+	Previous SigningSecretResponse       `json:"previous"`
+	Current  IssuedSigningSecretResponse `json:"current"`
+}
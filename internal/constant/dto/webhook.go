@@ -0,0 +1,221 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// WebhookIDHeader carries the ID of the event a webhook delivers. It is the
+	// same on every attempt, so receivers can drop duplicates.
+	WebhookIDHeader = "X-Webhook-ID"
+	// WebhookEventHeader carries the type of the event a webhook delivers.
+	WebhookEventHeader = "X-Webhook-Event"
+
+	DefaultWebhookDeliveryPageSize = 20
+	MaxWebhookDeliveryPageSize     = 100
+
+	maxWebhookURLLength = 2048
+)
+
+// WebhookEventTypes are the events merchants can subscribe an endpoint to.
+var WebhookEventTypes = []string{EventPaymentSucceeded, EventPaymentFailed}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "FAILED"
+)
+
+// WebhookEndpoint is a URL a merchant wants to receive events on. Deliveries
+// to it are signed with Secret.
+type WebhookEndpoint struct {
+	ID         uuid.UUID `json:"id"`
+	MerchantID uuid.UUID `json:"merchant_id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WebhookEvent is the body of every webhook request.
+type WebhookEvent struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+// WebhookDelivery is an event on its way to one endpoint, together with the
+// outcome of its latest attempt.
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id"`
+	EndpointID     uuid.UUID             `json:"endpoint_id"`
+	MerchantID     uuid.UUID             `json:"merchant_id"`
+	EventID        uuid.UUID             `json:"event_id"`
+	EventType      string                `json:"event_type"`
+	Payload        json.RawMessage       `json:"payload" swaggertype:"object"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	LastStatusCode *int                  `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	ExpiresAt      time.Time             `json:"expires_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// WebhookAttempt is the outcome of one delivery attempt. StatusCode is zero
+// when no response was received.
+type WebhookAttempt struct {
+	StatusCode int
+	Error      string
+	At         time.Time
+}
+
+// Succeeded reports whether the endpoint accepted the delivery.
+func (a WebhookAttempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// WebhookDeliveryResult records an attempt on a delivery. NextAttemptAt is
+// only used while the delivery stays PENDING.
+type WebhookDeliveryResult struct {
+	Status        WebhookDeliveryStatus
+	Attempt       WebhookAttempt
+	NextAttemptAt time.Time
+}
+
+type CreateWebhookEndpointRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+}
+
+func (r *CreateWebhookEndpointRequest) Validate() error {
+	r.URL = strings.TrimSpace(r.URL)
+	if r.URL == "" {
+		return errors.New("url is required")
+	}
+
+	if len(r.URL) > maxWebhookURLLength {
+		return fmt.Errorf("url cannot be longer than %d characters", maxWebhookURLLength)
+	}
+
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+
+	if u.User != nil {
+		return errors.New("url cannot contain credentials")
+	}
+
+	if len(r.EventTypes) == 0 {
+		return errors.New("event_types is required")
+	}
+
+	eventTypes := make([]string, 0, len(r.EventTypes))
+	for _, eventType := range r.EventTypes {
+		if !slices.Contains(WebhookEventTypes, eventType) {
+			return fmt.Errorf("unsupported event type: %s", eventType)
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	r.EventTypes = eventTypes
+
+	return nil
+}
+
+func (r *CreateWebhookEndpointRequest) ToWebhookEndpoint(merchantID uuid.UUID) WebhookEndpoint {
+	return WebhookEndpoint{
+		MerchantID: merchantID,
+		URL:        r.URL,
+		EventTypes: r.EventTypes,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// WebhookDeliveryFilter narrows the delivery log of one endpoint.
+type WebhookDeliveryFilter struct {
+	EndpointID uuid.UUID
+	Status     *WebhookDeliveryStatus
+	Limit      int
+}
+
+type ListWebhookDeliveriesRequest struct {
+	Status string `query:"status"`
+	Limit  string `query:"limit"`
+}
+
+// ToFilter validates the query parameters and converts them into a
+// WebhookDeliveryFilter.
+func (r *ListWebhookDeliveriesRequest) ToFilter() (WebhookDeliveryFilter, error) {
+	filter := WebhookDeliveryFilter{Limit: DefaultWebhookDeliveryPageSize}
+
+	if r.Status != "" {
+		status := WebhookDeliveryStatus(r.Status)
+		switch status {
+		case WebhookDeliveryPending, WebhookDeliverySucceeded, WebhookDeliveryFailed:
+		default:
+			return WebhookDeliveryFilter{}, fmt.Errorf("invalid status: %s", r.Status)
+		}
+		filter.Status = &status
+	}
+
+	if r.Limit != "" {
+		limit, err := strconv.Atoi(r.Limit)
+		if err != nil || limit < 1 || limit > MaxWebhookDeliveryPageSize {
+			return WebhookDeliveryFilter{}, fmt.Errorf("limit must be between 1 and %d", MaxWebhookDeliveryPageSize)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+type WebhookEndpointResponse struct {
+	ID         uuid.UUID `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreateWebhookEndpointResponse is the only response that contains the
+// endpoint's signing secret.
+type CreateWebhookEndpointResponse struct {
+	WebhookEndpointResponse
+	Secret string `json:"secret"`
+}
+
+type DeleteWebhookEndpointResponse struct {
+	ID      uuid.UUID `json:"id"`
+	Deleted bool      `json:"deleted"`
+}
+
+// PaymentStatusEvent returns the event announcing that a payment reached
+// status, if merchants are told about that status.
+func PaymentStatusEvent(status PaymentStatus) (string, bool) {
+	switch status {
+	case SUCCESS:
+		return EventPaymentSucceeded, true
+	case FAILED:
+		return EventPaymentFailed, true
+	default:
+		return "", false
+	}
+}
@@ -36,6 +36,46 @@ var Error = []ErrorType{
 		StatusCode: http.StatusBadRequest,
 		Type:       ErrDuplicateReference,
 	},
+	{
+		StatusCode: http.StatusConflict,
+		Type:       ErrRefundNotAllowed,
+	},
+	{
+		StatusCode: http.StatusBadRequest,
+		Type:       ErrRefundAmountExceeded,
+	},
+	{
+		StatusCode: http.StatusConflict,
+		Type:       ErrIdempotencyKeyInUse,
+	},
+	{
+		StatusCode: http.StatusUnprocessableEntity,
+		Type:       ErrIdempotencyKeyMismatch,
+	},
+	{
+		StatusCode: http.StatusConflict,
+		Type:       ErrInvalidStatusTransition,
+	},
+	{
+		StatusCode: http.StatusServiceUnavailable,
+		Type:       ErrBrokerUnavailable,
+	},
+	{
+		StatusCode: http.StatusUnauthorized,
+		Type:       ErrUnauthorized,
+	},
+	{
+		StatusCode: http.StatusConflict,
+		Type:       ErrAPIKeyInactive,
+	},
+	{
+		StatusCode: http.StatusConflict,
+		Type:       ErrSigningSecretInactive,
+	},
+	{
+		StatusCode: http.StatusConflict,
+		Type:       ErrWebhookDeliveryPending,
+	},
 }
 
 // list of error namespaces
@@ -43,6 +83,8 @@ var (
 	databaseError    = errorx.NewNamespace("database error").ApplyModifiers(errorx.TypeModifierOmitStackTrace)
 	invalidInput     = errorx.NewNamespace("validation error").ApplyModifiers(errorx.TypeModifierOmitStackTrace)
 	resourceNotFound = errorx.NewNamespace("not found").ApplyModifiers(errorx.TypeModifierOmitStackTrace)
+	conflict         = errorx.NewNamespace("conflict").ApplyModifiers(errorx.TypeModifierOmitStackTrace)
+	unauthorized     = errorx.NewNamespace("unauthorized").ApplyModifiers(errorx.TypeModifierOmitStackTrace)
 	serverError      = errorx.NewNamespace("server error")
 	dbError          = errorx.NewNamespace("db error")
 	requestFailed    = errorx.NewNamespace("request binding Failed").ApplyModifiers(errorx.TypeModifierOmitStackTrace)
@@ -63,4 +105,20 @@ var (
 	ErrReadingResponseBody = errorx.NewType(bodyreadFailed, "reading body failure")
 	UnexpectedError        = errorx.NewType(serverError, "invalid value")
 	ErrDuplicateReference  = errorx.NewType(invalidInput, "reference should be unique")
+
+	ErrRefundNotAllowed     = errorx.NewType(invalidInput, "payment cannot be refunded")
+	ErrRefundAmountExceeded = errorx.NewType(invalidInput, "refund amount exceeds refundable amount")
+
+	ErrIdempotencyKeyInUse    = errorx.NewType(conflict, "idempotency key in use")
+	ErrIdempotencyKeyMismatch = errorx.NewType(invalidInput, "idempotency key reused with a different request")
+
+	ErrInvalidStatusTransition = errorx.NewType(conflict, "invalid status transition")
+
+	ErrBrokerUnavailable = errorx.NewType(serverError, "message broker unavailable")
+
+	ErrUnauthorized          = errorx.NewType(unauthorized, "unauthorized")
+	ErrAPIKeyInactive        = errorx.NewType(conflict, "api key is revoked or expired")
+	ErrSigningSecretInactive = errorx.NewType(conflict, "signing secret is revoked or expired")
+
+	ErrWebhookDeliveryPending = errorx.NewType(conflict, "webhook delivery is already scheduled")
 )
@@ -5,6 +5,7 @@
 package db
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"fmt"
 	"time"
@@ -14,6 +15,48 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusINPROGRESS IdempotencyStatus = "IN_PROGRESS"
+	IdempotencyStatusCOMPLETED  IdempotencyStatus = "COMPLETED"
+)
+
+func (e *IdempotencyStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = IdempotencyStatus(s)
+	case string:
+		*e = IdempotencyStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for IdempotencyStatus: %T", src)
+	}
+	return nil
+}
+
+type NullIdempotencyStatus struct {
+	IdempotencyStatus IdempotencyStatus
+	Valid             bool // Valid is true if IdempotencyStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullIdempotencyStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.IdempotencyStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.IdempotencyStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullIdempotencyStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.IdempotencyStatus), nil
+}
+
 type OutboxStatus string
 
 const (
@@ -102,9 +145,12 @@ func (ns NullPaymentCurrency) Value() (driver.Value, error) {
 type PaymentStatus string
 
 const (
-	PaymentStatusPENDING PaymentStatus = "PENDING"
-	PaymentStatusSUCCESS PaymentStatus = "SUCCESS"
-	PaymentStatusFAILED  PaymentStatus = "FAILED"
+	PaymentStatusPENDING           PaymentStatus = "PENDING"
+	PaymentStatusPROCESSING        PaymentStatus = "PROCESSING"
+	PaymentStatusSUCCESS           PaymentStatus = "SUCCESS"
+	PaymentStatusFAILED            PaymentStatus = "FAILED"
+	PaymentStatusPARTIALLYREFUNDED PaymentStatus = "PARTIALLY_REFUNDED"
+	PaymentStatusREFUNDED          PaymentStatus = "REFUNDED"
 )
 
 func (e *PaymentStatus) Scan(src interface{}) error {
@@ -142,20 +188,203 @@ func (ns NullPaymentStatus) Value() (driver.Value, error) {
 	return string(ns.PaymentStatus), nil
 }
 
-type OutboxEvent struct {
+type RefundStatus string
+
+const (
+	RefundStatusPENDING RefundStatus = "PENDING"
+	RefundStatusSUCCESS RefundStatus = "SUCCESS"
+	RefundStatusFAILED  RefundStatus = "FAILED"
+)
+
+func (e *RefundStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RefundStatus(s)
+	case string:
+		*e = RefundStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RefundStatus: %T", src)
+	}
+	return nil
+}
+
+type NullRefundStatus struct {
+	RefundStatus RefundStatus
+	Valid        bool // Valid is true if RefundStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRefundStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.RefundStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RefundStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRefundStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RefundStatus), nil
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPENDING   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusSUCCEEDED WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookDeliveryStatusFAILED    WebhookDeliveryStatus = "FAILED"
+)
+
+func (e *WebhookDeliveryStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookDeliveryStatus(s)
+	case string:
+		*e = WebhookDeliveryStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookDeliveryStatus: %T", src)
+	}
+	return nil
+}
+
+type NullWebhookDeliveryStatus struct {
+	WebhookDeliveryStatus WebhookDeliveryStatus
+	Valid                 bool // Valid is true if WebhookDeliveryStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWebhookDeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WebhookDeliveryStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WebhookDeliveryStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWebhookDeliveryStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WebhookDeliveryStatus), nil
+}
+
+type ApiKey struct {
+	ID         uuid.UUID
+	MerchantID uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	ExpiresAt  sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
+}
+
+type IdempotencyKey struct {
+	Key          string
+	RequestHash  string
+	Status       IdempotencyStatus
+	ResponseCode int32
+	ResponseBody []byte
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type Merchant struct {
 	ID        uuid.UUID
-	Payload   pgtype.JSONB
-	Status    OutboxStatus
+	Name      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+type OutboxEvent struct {
+	ID            uuid.UUID
+	Payload       pgtype.JSONB
+	Status        OutboxStatus
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	EventType     string
+	AggregateType string
+	AggregateID   uuid.UUID
+	Headers       pgtype.JSONB
+	Attempts      int32
+	LastError     sql.NullString
+	NextAttemptAt time.Time
+}
+
 type Payment struct {
-	ID        uuid.UUID
-	Reference uuid.UUID
-	Amount    decimal.Decimal
-	Currency  PaymentCurrency
-	Status    PaymentStatus
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID         uuid.UUID
+	Reference  uuid.UUID
+	Amount     decimal.Decimal
+	Currency   PaymentCurrency
+	Status     PaymentStatus
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	MerchantID uuid.UUID
+}
+
+type PaymentStatusHistory struct {
+	ID         uuid.UUID
+	PaymentID  uuid.UUID
+	FromStatus NullPaymentStatus
+	ToStatus   PaymentStatus
+	Actor      string
+	Reason     string
+	CreatedAt  time.Time
+}
+
+type Refund struct {
+	ID            uuid.UUID
+	PaymentID     uuid.UUID
+	Amount        decimal.Decimal
+	Currency      PaymentCurrency
+	Reason        string
+	Status        RefundStatus
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	FailureReason string
+}
+
+type SigningSecret struct {
+	ID         uuid.UUID
+	MerchantID uuid.UUID
+	Name       string
+	Secret     string
+	ExpiresAt  sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	EndpointID     uuid.UUID
+	MerchantID     uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	Payload        pgtype.JSONB
+	Status         WebhookDeliveryStatus
+	Attempts       int32
+	LastStatusCode sql.NullInt32
+	LastError      string
+	LastAttemptAt  sql.NullTime
+	DeliveredAt    sql.NullTime
+	NextAttemptAt  time.Time
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type WebhookEndpoint struct {
+	ID         uuid.UUID
+	MerchantID uuid.UUID
+	Url        string
+	EventTypes []string
+	Secret     string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
@@ -7,26 +7,50 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
 )
 
+const countPendingOutboxEvents = `-- name: CountPendingOutboxEvents :one
+SELECT count(*) FROM outbox_events WHERE status = 'PENDING'
+`
+
+func (q *Queries) CountPendingOutboxEvents(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countPendingOutboxEvents)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createOutboxEvent = `-- name: CreateOutboxEvent :one
-INSERT INTO outbox_events (payload, status, created_at, updated_at)
-VALUES ($1, $2, $3, $3)
-RETURNING id, payload, status, created_at, updated_at
+INSERT INTO outbox_events (event_type, aggregate_type, aggregate_id, payload, headers, status, created_at, updated_at, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $7)
+RETURNING id, payload, status, created_at, updated_at, event_type, aggregate_type, aggregate_id, headers, attempts, last_error, next_attempt_at
 `
 
 type CreateOutboxEventParams struct {
-	Payload   pgtype.JSONB
-	Status    OutboxStatus
-	CreatedAt time.Time
+	EventType     string
+	AggregateType string
+	AggregateID   uuid.UUID
+	Payload       pgtype.JSONB
+	Headers       pgtype.JSONB
+	Status        OutboxStatus
+	CreatedAt     time.Time
 }
 
 func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error) {
-	row := q.db.QueryRow(ctx, createOutboxEvent, arg.Payload, arg.Status, arg.CreatedAt)
+	row := q.db.QueryRow(ctx, createOutboxEvent,
+		arg.EventType,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.Payload,
+		arg.Headers,
+		arg.Status,
+		arg.CreatedAt,
+	)
 	var i OutboxEvent
 	err := row.Scan(
 		&i.ID,
@@ -34,6 +58,13 @@ func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventPa
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EventType,
+		&i.AggregateType,
+		&i.AggregateID,
+		&i.Headers,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
 	)
 	return i, err
 }
@@ -51,16 +82,21 @@ func (q *Queries) DeleteOutboxEvent(ctx context.Context, id uuid.UUID) (int64, e
 }
 
 const getPendingOutboxEventsForUpdate = `-- name: GetPendingOutboxEventsForUpdate :many
-SELECT id, payload, status, created_at, updated_at
+SELECT id, payload, status, created_at, updated_at, event_type, aggregate_type, aggregate_id, headers, attempts, last_error, next_attempt_at
 FROM outbox_events
-WHERE status = 'PENDING'
+WHERE status = 'PENDING' AND next_attempt_at <= $1
 ORDER BY created_at ASC
-LIMIT $1
+LIMIT $2
 FOR UPDATE SKIP LOCKED
 `
 
-func (q *Queries) GetPendingOutboxEventsForUpdate(ctx context.Context, limit int32) ([]OutboxEvent, error) {
-	rows, err := q.db.Query(ctx, getPendingOutboxEventsForUpdate, limit)
+type GetPendingOutboxEventsForUpdateParams struct {
+	NextAttemptAt time.Time
+	Limit         int32
+}
+
+func (q *Queries) GetPendingOutboxEventsForUpdate(ctx context.Context, arg GetPendingOutboxEventsForUpdateParams) ([]OutboxEvent, error) {
+	rows, err := q.db.Query(ctx, getPendingOutboxEventsForUpdate, arg.NextAttemptAt, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +110,13 @@ func (q *Queries) GetPendingOutboxEventsForUpdate(ctx context.Context, limit int
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EventType,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.Headers,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
 		); err != nil {
 			return nil, err
 		}
@@ -85,6 +128,39 @@ func (q *Queries) GetPendingOutboxEventsForUpdate(ctx context.Context, limit int
 	return items, nil
 }
 
+const recordOutboxFailure = `-- name: RecordOutboxFailure :execrows
+UPDATE outbox_events
+SET
+    status = $2,
+    attempts = attempts + 1,
+    last_error = $3,
+    next_attempt_at = $4,
+    updated_at = $5
+WHERE id = $1
+`
+
+type RecordOutboxFailureParams struct {
+	ID            uuid.UUID
+	Status        OutboxStatus
+	LastError     sql.NullString
+	NextAttemptAt time.Time
+	UpdatedAt     time.Time
+}
+
+func (q *Queries) RecordOutboxFailure(ctx context.Context, arg RecordOutboxFailureParams) (int64, error) {
+	result, err := q.db.Exec(ctx, recordOutboxFailure,
+		arg.ID,
+		arg.Status,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.UpdatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateOutboxStatus = `-- name: UpdateOutboxStatus :execrows
 UPDATE outbox_events
 SET
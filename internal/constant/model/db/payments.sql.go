@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,21 +15,23 @@ import (
 )
 
 const createPayment = `-- name: CreatePayment :one
-INSERT INTO payments (reference, amount, currency, status, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $5)
-RETURNING id, reference, amount, currency, status, created_at, updated_at
+INSERT INTO payments (merchant_id, reference, amount, currency, status, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $6)
+RETURNING id, reference, amount, currency, status, created_at, updated_at, merchant_id
 `
 
 type CreatePaymentParams struct {
-	Reference uuid.UUID
-	Amount    decimal.Decimal
-	Currency  PaymentCurrency
-	Status    PaymentStatus
-	CreatedAt time.Time
+	MerchantID uuid.UUID
+	Reference  uuid.UUID
+	Amount     decimal.Decimal
+	Currency   PaymentCurrency
+	Status     PaymentStatus
+	CreatedAt  time.Time
 }
 
 func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (Payment, error) {
 	row := q.db.QueryRow(ctx, createPayment,
+		arg.MerchantID,
 		arg.Reference,
 		arg.Amount,
 		arg.Currency,
@@ -44,12 +47,40 @@ func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (P
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MerchantID,
+	)
+	return i, err
+}
+
+const getMerchantPaymentByID = `-- name: GetMerchantPaymentByID :one
+SELECT id, reference, amount, currency, status, created_at, updated_at, merchant_id
+FROM payments
+WHERE id = $1 AND merchant_id = $2
+`
+
+type GetMerchantPaymentByIDParams struct {
+	ID         uuid.UUID
+	MerchantID uuid.UUID
+}
+
+func (q *Queries) GetMerchantPaymentByID(ctx context.Context, arg GetMerchantPaymentByIDParams) (Payment, error) {
+	row := q.db.QueryRow(ctx, getMerchantPaymentByID, arg.ID, arg.MerchantID)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.Reference,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MerchantID,
 	)
 	return i, err
 }
 
 const getPaymentByID = `-- name: GetPaymentByID :one
-SELECT id, reference, amount, currency, status, created_at, updated_at
+SELECT id, reference, amount, currency, status, created_at, updated_at, merchant_id
 FROM payments
 WHERE id = $1
 `
@@ -65,12 +96,13 @@ func (q *Queries) GetPaymentByID(ctx context.Context, id uuid.UUID) (Payment, er
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MerchantID,
 	)
 	return i, err
 }
 
 const getPaymentByIDForUpdate = `-- name: GetPaymentByIDForUpdate :one
-SELECT id, reference, amount, currency, status, created_at, updated_at
+SELECT id, reference, amount, currency, status, created_at, updated_at, merchant_id
 FROM payments
 WHERE id = $1
 FOR UPDATE
@@ -87,24 +119,99 @@ func (q *Queries) GetPaymentByIDForUpdate(ctx context.Context, id uuid.UUID) (Pa
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MerchantID,
 	)
 	return i, err
 }
 
+const listPayments = `-- name: ListPayments :many
+SELECT id, reference, amount, currency, status, created_at, updated_at, merchant_id
+FROM payments
+WHERE merchant_id = $1
+  AND ($2::payment_status IS NULL OR status = $2)
+  AND ($3::payment_currency IS NULL OR currency = $3)
+  AND ($4::NUMERIC IS NULL OR amount >= $4)
+  AND ($5::NUMERIC IS NULL OR amount <= $5)
+  AND ($6::TIMESTAMP IS NULL OR created_at >= $6)
+  AND ($7::TIMESTAMP IS NULL OR created_at < $7)
+  AND ($8::UUID IS NULL OR reference = $8)
+  AND ($9::TIMESTAMP IS NULL OR (created_at, id) < ($9, $10::UUID))
+ORDER BY created_at DESC, id DESC
+LIMIT $11
+`
+
+type ListPaymentsParams struct {
+	MerchantID      uuid.UUID
+	Status          NullPaymentStatus
+	Currency        NullPaymentCurrency
+	MinAmount       decimal.NullDecimal
+	MaxAmount       decimal.NullDecimal
+	CreatedFrom     sql.NullTime
+	CreatedTo       sql.NullTime
+	Reference       uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) ListPayments(ctx context.Context, arg ListPaymentsParams) ([]Payment, error) {
+	rows, err := q.db.Query(ctx, listPayments,
+		arg.MerchantID,
+		arg.Status,
+		arg.Currency,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Reference,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Payment
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.Reference,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MerchantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updatePaymentStatus = `-- name: UpdatePaymentStatus :one
 UPDATE payments
-SET status = $2
+SET
+    status = $2,
+    updated_at = $3
 WHERE id = $1
-RETURNING id, reference, amount, currency, status, created_at, updated_at
+RETURNING id, reference, amount, currency, status, created_at, updated_at, merchant_id
 `
 
 type UpdatePaymentStatusParams struct {
-	ID     uuid.UUID
-	Status PaymentStatus
+	ID        uuid.UUID
+	Status    PaymentStatus
+	UpdatedAt time.Time
 }
 
 func (q *Queries) UpdatePaymentStatus(ctx context.Context, arg UpdatePaymentStatusParams) (Payment, error) {
-	row := q.db.QueryRow(ctx, updatePaymentStatus, arg.ID, arg.Status)
+	row := q.db.QueryRow(ctx, updatePaymentStatus, arg.ID, arg.Status, arg.UpdatedAt)
 	var i Payment
 	err := row.Scan(
 		&i.ID,
@@ -114,6 +221,7 @@ func (q *Queries) UpdatePaymentStatus(ctx context.Context, arg UpdatePaymentStat
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MerchantID,
 	)
 	return i, err
 }
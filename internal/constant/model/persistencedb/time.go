@@ -0,0 +1,10 @@
+package persistencedb
+
+import "time"
+
+// LocalTime returns t, read from a TIMESTAMP WITHOUT TIME ZONE column, in the
+// local zone it was written in. pgx labels such values UTC, which puts them
+// off by the zone's offset when compared with time.Now().
+func LocalTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
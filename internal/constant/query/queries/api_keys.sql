@@ -0,0 +1,33 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (merchant_id, name, prefix, key_hash, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT *
+FROM api_keys
+WHERE prefix = $1;
+
+-- name: GetMerchantAPIKeyForUpdate :one
+SELECT *
+FROM api_keys
+WHERE id = $1 AND merchant_id = $2
+FOR UPDATE;
+
+-- name: ListMerchantAPIKeys :many
+SELECT *
+FROM api_keys
+WHERE merchant_id = $1
+ORDER BY created_at DESC;
+
+-- name: ExpireAPIKey :one
+UPDATE api_keys
+SET expires_at = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = COALESCE(revoked_at, $3)
+WHERE id = $1 AND merchant_id = $2
+RETURNING *;
@@ -0,0 +1,41 @@
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (key, request_hash, status, created_at, updated_at)
+VALUES (sqlc.arg('key'), sqlc.arg('request_hash'), 'IN_PROGRESS', sqlc.arg('created_at'), sqlc.arg('created_at'))
+ON CONFLICT (key) DO UPDATE
+SET
+    request_hash = EXCLUDED.request_hash,
+    status = 'IN_PROGRESS',
+    response_code = 0,
+    response_body = NULL,
+    created_at = EXCLUDED.created_at,
+    updated_at = EXCLUDED.updated_at
+WHERE idempotency_keys.created_at < sqlc.arg('expired_before')
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT *
+FROM idempotency_keys
+WHERE key = $1;
+
+-- name: ReclaimIdempotencyKey :one
+UPDATE idempotency_keys
+SET updated_at = sqlc.arg('updated_at')
+WHERE key = sqlc.arg('key')
+  AND status = 'IN_PROGRESS'
+  AND updated_at < sqlc.arg('stale_before')
+RETURNING *;
+
+-- name: CompleteIdempotencyKey :execrows
+UPDATE idempotency_keys
+SET
+    status = 'COMPLETED',
+    response_code = $2,
+    response_body = $3,
+    updated_at = $4
+WHERE key = $1;
+
+-- name: DeleteIdempotencyKey :execrows
+DELETE FROM idempotency_keys WHERE key = $1;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys WHERE created_at < $1;
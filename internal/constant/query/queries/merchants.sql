@@ -0,0 +1,9 @@
+-- name: CreateMerchant :one
+INSERT INTO merchants (name, created_at, updated_at)
+VALUES ($1, $2, $2)
+RETURNING *;
+
+-- name: GetMerchantByID :one
+SELECT *
+FROM merchants
+WHERE id = $1;
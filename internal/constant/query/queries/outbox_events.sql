@@ -1,16 +1,19 @@
 -- name: CreateOutboxEvent :one
-INSERT INTO outbox_events (payload, status, created_at, updated_at)
-VALUES ($1, $2, $3, $3)
+INSERT INTO outbox_events (event_type, aggregate_type, aggregate_id, payload, headers, status, created_at, updated_at, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $7)
 RETURNING *;
 
 -- name: GetPendingOutboxEventsForUpdate :many
 SELECT *
 FROM outbox_events
-WHERE status = 'PENDING'
+WHERE status = 'PENDING' AND next_attempt_at <= $1
 ORDER BY created_at ASC
-LIMIT $1
+LIMIT $2
 FOR UPDATE SKIP LOCKED;
 
+-- name: CountPendingOutboxEvents :one
+SELECT count(*) FROM outbox_events WHERE status = 'PENDING';
+
 -- name: UpdateOutboxStatus :execrows
 UPDATE outbox_events
 SET
@@ -18,5 +21,15 @@ SET
     updated_at = $3
 WHERE id = $1;
 
+-- name: RecordOutboxFailure :execrows
+UPDATE outbox_events
+SET
+    status = $2,
+    attempts = attempts + 1,
+    last_error = $3,
+    next_attempt_at = $4,
+    updated_at = $5
+WHERE id = $1;
+
 -- name: DeleteOutboxEvent :execrows
 DELETE FROM outbox_events WHERE id = $1;
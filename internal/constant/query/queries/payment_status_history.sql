@@ -0,0 +1,10 @@
+-- name: CreatePaymentStatusHistory :one
+INSERT INTO payment_status_history (payment_id, from_status, to_status, actor, reason, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetPaymentStatusHistory :many
+SELECT *
+FROM payment_status_history
+WHERE payment_id = $1
+ORDER BY created_at ASC, id ASC;
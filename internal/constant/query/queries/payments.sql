@@ -1,6 +1,6 @@
 -- name: CreatePayment :one
-INSERT INTO payments (reference, amount, currency, status, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $5)
+INSERT INTO payments (merchant_id, reference, amount, currency, status, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $6)
 RETURNING *;
 
 -- name: GetPaymentByID :one
@@ -8,9 +8,16 @@ SELECT *
 FROM payments
 WHERE id = $1;
 
+-- name: GetMerchantPaymentByID :one
+SELECT *
+FROM payments
+WHERE id = $1 AND merchant_id = $2;
+
 -- name: UpdatePaymentStatus :one
 UPDATE payments
-SET status = $2
+SET
+    status = $2,
+    updated_at = $3
 WHERE id = $1
 RETURNING *;
 
@@ -19,3 +26,18 @@ SELECT *
 FROM payments
 WHERE id = $1
 FOR UPDATE;
+
+-- name: ListPayments :many
+SELECT *
+FROM payments
+WHERE merchant_id = sqlc.arg('merchant_id')
+  AND (sqlc.narg('status')::payment_status IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('currency')::payment_currency IS NULL OR currency = sqlc.narg('currency'))
+  AND (sqlc.narg('min_amount')::NUMERIC IS NULL OR amount >= sqlc.narg('min_amount'))
+  AND (sqlc.narg('max_amount')::NUMERIC IS NULL OR amount <= sqlc.narg('max_amount'))
+  AND (sqlc.narg('created_from')::TIMESTAMP IS NULL OR created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::TIMESTAMP IS NULL OR created_at < sqlc.narg('created_to'))
+  AND (sqlc.narg('reference')::UUID IS NULL OR reference = sqlc.narg('reference'))
+  AND (sqlc.narg('cursor_created_at')::TIMESTAMP IS NULL OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::UUID))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
@@ -0,0 +1,37 @@
+-- name: CreateRefund :one
+INSERT INTO refunds (payment_id, amount, currency, reason, status, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $6)
+RETURNING *;
+
+-- name: GetRefundByID :one
+SELECT *
+FROM refunds
+WHERE id = $1;
+
+-- name: GetRefundByIDForUpdate :one
+SELECT *
+FROM refunds
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetRefundsByPaymentID :many
+SELECT *
+FROM refunds
+WHERE payment_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetRefundTotalsByPaymentID :one
+SELECT
+    COALESCE(SUM(amount) FILTER (WHERE status IN ('PENDING', 'SUCCESS')), 0)::NUMERIC(18,2) AS reserved,
+    COALESCE(SUM(amount) FILTER (WHERE status = 'SUCCESS'), 0)::NUMERIC(18,2) AS refunded
+FROM refunds
+WHERE payment_id = $1;
+
+-- name: UpdateRefundStatus :one
+UPDATE refunds
+SET
+    status = $2,
+    failure_reason = $3,
+    updated_at = $4
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,36 @@
+-- name: CreateSigningSecret :one
+INSERT INTO signing_secrets (merchant_id, name, secret, created_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetMerchantSigningSecretForUpdate :one
+SELECT *
+FROM signing_secrets
+WHERE id = $1 AND merchant_id = $2
+FOR UPDATE;
+
+-- name: ListMerchantSigningSecrets :many
+SELECT *
+FROM signing_secrets
+WHERE merchant_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListActiveMerchantSigningSecrets :many
+SELECT *
+FROM signing_secrets
+WHERE merchant_id = sqlc.arg('merchant_id')
+    AND revoked_at IS NULL
+    AND (expires_at IS NULL OR expires_at > sqlc.arg('now')::timestamp)
+ORDER BY created_at DESC;
+
+-- name: ExpireSigningSecret :one
+UPDATE signing_secrets
+SET expires_at = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: RevokeSigningSecret :one
+UPDATE signing_secrets
+SET revoked_at = COALESCE(revoked_at, $3)
+WHERE id = $1 AND merchant_id = $2
+RETURNING *;
@@ -0,0 +1,82 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (merchant_id, url, event_types, secret, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $5)
+RETURNING *;
+
+-- name: GetWebhookEndpointByID :one
+SELECT *
+FROM webhook_endpoints
+WHERE id = $1;
+
+-- name: GetMerchantWebhookEndpointByID :one
+SELECT *
+FROM webhook_endpoints
+WHERE id = $1 AND merchant_id = $2;
+
+-- name: ListMerchantWebhookEndpoints :many
+SELECT *
+FROM webhook_endpoints
+WHERE merchant_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = $1 AND merchant_id = $2;
+
+-- name: CreateWebhookDeliveriesForEvent :execrows
+INSERT INTO webhook_deliveries (endpoint_id, merchant_id, event_id, event_type, payload, status, next_attempt_at, expires_at, created_at, updated_at)
+SELECT e.id, e.merchant_id, sqlc.arg('event_id'), sqlc.arg('event_type'), sqlc.arg('payload'), 'PENDING', sqlc.arg('created_at'), sqlc.arg('expires_at'), sqlc.arg('created_at'), sqlc.arg('created_at')
+FROM webhook_endpoints e
+WHERE e.merchant_id = sqlc.arg('merchant_id') AND sqlc.arg('event_type')::text = ANY(e.event_types)
+ON CONFLICT (endpoint_id, event_id) DO NOTHING;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET next_attempt_at = sqlc.arg('lease_until'), updated_at = sqlc.arg('now')
+WHERE d.id IN (
+    SELECT id
+    FROM webhook_deliveries
+    WHERE status = 'PENDING' AND next_attempt_at <= sqlc.arg('now')
+    ORDER BY next_attempt_at ASC
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING d.*;
+
+-- name: RecordWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET
+    status = $2,
+    attempts = attempts + 1,
+    last_status_code = $3,
+    last_error = $4,
+    last_attempt_at = $5,
+    delivered_at = $6,
+    next_attempt_at = $7,
+    updated_at = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: GetMerchantWebhookDeliveryByID :one
+SELECT *
+FROM webhook_deliveries
+WHERE id = $1 AND merchant_id = $2;
+
+-- name: ListEndpointWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE endpoint_id = sqlc.arg('endpoint_id')
+    AND (sqlc.narg('status')::webhook_delivery_status IS NULL OR status = sqlc.narg('status'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET
+    status = 'PENDING',
+    attempts = 0,
+    next_attempt_at = sqlc.arg('now'),
+    expires_at = sqlc.arg('expires_at'),
+    updated_at = sqlc.arg('now')
+WHERE id = sqlc.arg('id') AND merchant_id = sqlc.arg('merchant_id') AND status <> 'PENDING'
+RETURNING *;
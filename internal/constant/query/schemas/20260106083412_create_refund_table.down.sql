@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS refunds;
+DROP TYPE refund_status;
+
+UPDATE payments SET status = 'SUCCESS' WHERE status IN ('PARTIALLY_REFUNDED', 'REFUNDED');
+ALTER TYPE payment_status RENAME TO payment_status_old;
+CREATE TYPE payment_status AS ENUM (
+    'PENDING',
+    'SUCCESS',
+    'FAILED'
+);
+ALTER TABLE payments
+    ALTER COLUMN status DROP DEFAULT,
+    ALTER COLUMN status TYPE payment_status USING status::text::payment_status,
+    ALTER COLUMN status SET DEFAULT 'PENDING';
+DROP TYPE payment_status_old;
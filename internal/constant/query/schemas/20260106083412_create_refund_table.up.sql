@@ -0,0 +1,21 @@
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'PARTIALLY_REFUNDED';
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'REFUNDED';
+
+CREATE TYPE refund_status AS ENUM (
+    'PENDING',
+    'SUCCESS',
+    'FAILED'
+);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    amount NUMERIC(18,2) NOT NULL,
+    currency payment_currency NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    status refund_status NOT NULL DEFAULT 'PENDING',
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_refunds_payment_id ON refunds(payment_id);
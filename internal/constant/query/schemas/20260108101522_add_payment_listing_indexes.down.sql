@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_payments_amount;
+DROP INDEX IF EXISTS idx_payments_currency_created_at_id;
+DROP INDEX IF EXISTS idx_payments_status_created_at_id;
+DROP INDEX IF EXISTS idx_payments_created_at_id;
@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_payments_created_at_id ON payments(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_payments_status_created_at_id ON payments(status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_payments_currency_created_at_id ON payments(currency, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_payments_amount ON payments(amount);
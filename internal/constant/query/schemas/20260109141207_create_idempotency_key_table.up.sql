@@ -0,0 +1,11 @@
+CREATE TYPE idempotency_status AS ENUM ('IN_PROGRESS', 'COMPLETED');
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key TEXT PRIMARY KEY,
+    request_hash TEXT NOT NULL,
+    status idempotency_status NOT NULL DEFAULT 'IN_PROGRESS',
+    response_code INT NOT NULL DEFAULT 0,
+    response_body JSONB,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS payment_status_history;
+
+UPDATE payments SET status = 'PENDING' WHERE status = 'PROCESSING';
+ALTER TYPE payment_status RENAME TO payment_status_old;
+CREATE TYPE payment_status AS ENUM (
+    'PENDING',
+    'SUCCESS',
+    'FAILED',
+    'PARTIALLY_REFUNDED',
+    'REFUNDED'
+);
+ALTER TABLE payments
+    ALTER COLUMN status DROP DEFAULT,
+    ALTER COLUMN status TYPE payment_status USING status::text::payment_status,
+    ALTER COLUMN status SET DEFAULT 'PENDING';
+DROP TYPE payment_status_old;
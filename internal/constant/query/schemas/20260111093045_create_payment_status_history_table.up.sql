@@ -0,0 +1,13 @@
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'PROCESSING' AFTER 'PENDING';
+
+CREATE TABLE IF NOT EXISTS payment_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    from_status payment_status,
+    to_status payment_status NOT NULL,
+    actor TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_payment_status_history_payment_id ON payment_status_history(payment_id, created_at);
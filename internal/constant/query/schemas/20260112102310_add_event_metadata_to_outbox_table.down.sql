@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_outbox_events_aggregate;
+
+ALTER TABLE outbox_events
+    DROP COLUMN IF EXISTS headers,
+    DROP COLUMN IF EXISTS aggregate_id,
+    DROP COLUMN IF EXISTS aggregate_type,
+    DROP COLUMN IF EXISTS event_type;
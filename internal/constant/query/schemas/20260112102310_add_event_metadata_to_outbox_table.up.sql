@@ -0,0 +1,20 @@
+ALTER TABLE outbox_events
+    ADD COLUMN event_type TEXT,
+    ADD COLUMN aggregate_type TEXT,
+    ADD COLUMN aggregate_id UUID,
+    ADD COLUMN headers JSONB NOT NULL DEFAULT '{}';
+
+-- Events written before this migration are either payments or refunds,
+-- refund payloads are the only ones that carry a payment_id
+UPDATE outbox_events
+SET
+    event_type = CASE WHEN payload ? 'payment_id' THEN 'refund.created' ELSE 'payment.created' END,
+    aggregate_type = CASE WHEN payload ? 'payment_id' THEN 'refund' ELSE 'payment' END,
+    aggregate_id = (payload->>'id')::UUID;
+
+ALTER TABLE outbox_events
+    ALTER COLUMN event_type SET NOT NULL,
+    ALTER COLUMN aggregate_type SET NOT NULL,
+    ALTER COLUMN aggregate_id SET NOT NULL;
+
+CREATE INDEX idx_outbox_events_aggregate ON outbox_events(aggregate_type, aggregate_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_outbox_events_status_next_attempt;
+
+CREATE INDEX idx_outbox_events_status ON outbox_events(status);
+
+ALTER TABLE outbox_events
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS attempts;
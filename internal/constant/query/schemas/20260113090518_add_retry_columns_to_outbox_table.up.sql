@@ -0,0 +1,8 @@
+ALTER TABLE outbox_events
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT,
+    ADD COLUMN next_attempt_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now();
+
+DROP INDEX IF EXISTS idx_outbox_events_status;
+
+CREATE INDEX idx_outbox_events_status_next_attempt ON outbox_events(status, next_attempt_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_payments_merchant_created_at_id;
+DROP INDEX IF EXISTS payments_merchant_id_reference_key;
+ALTER TABLE payments ADD CONSTRAINT payments_reference_key UNIQUE (reference);
+ALTER TABLE payments DROP COLUMN IF EXISTS merchant_id;
+
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS merchants;
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS merchants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+-- Only a SHA-256 hash of each key is stored, prefix is the public part of the
+-- key used to look it up. A key stops working once it is revoked or expires_at
+-- has passed.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    name TEXT NOT NULL DEFAULT '',
+    prefix TEXT UNIQUE NOT NULL,
+    key_hash TEXT NOT NULL,
+    expires_at TIMESTAMP WITHOUT TIME ZONE,
+    revoked_at TIMESTAMP WITHOUT TIME ZONE,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_merchant_id ON api_keys(merchant_id, created_at DESC);
+
+-- Payments made before merchants existed are handed to a legacy merchant
+INSERT INTO merchants (id, name, created_at, updated_at)
+SELECT '00000000-0000-0000-0000-000000000001', 'legacy', now(), now()
+WHERE EXISTS (SELECT 1 FROM payments);
+
+ALTER TABLE payments ADD COLUMN merchant_id UUID REFERENCES merchants(id);
+UPDATE payments SET merchant_id = '00000000-0000-0000-0000-000000000001';
+ALTER TABLE payments ALTER COLUMN merchant_id SET NOT NULL;
+
+-- References only have to be unique per merchant
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_reference_key;
+CREATE UNIQUE INDEX IF NOT EXISTS payments_merchant_id_reference_key ON payments(merchant_id, reference);
+CREATE INDEX IF NOT EXISTS idx_payments_merchant_created_at_id ON payments(merchant_id, created_at DESC, id DESC);
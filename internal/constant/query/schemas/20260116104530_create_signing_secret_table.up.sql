@@ -0,0 +1,14 @@
+-- Secrets merchants sign their requests with. Unlike API keys they are kept
+-- in the clear, because checking an HMAC needs the secret itself. A merchant
+-- with at least one active secret must sign every request to a signed route.
+CREATE TABLE IF NOT EXISTS signing_secrets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    name TEXT NOT NULL DEFAULT '',
+    secret TEXT NOT NULL,
+    expires_at TIMESTAMP WITHOUT TIME ZONE,
+    revoked_at TIMESTAMP WITHOUT TIME ZONE,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_signing_secrets_merchant_id ON signing_secrets(merchant_id, created_at DESC);
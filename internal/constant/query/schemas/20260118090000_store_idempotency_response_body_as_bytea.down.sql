@@ -0,0 +1,2 @@
+ALTER TABLE idempotency_keys
+    ALTER COLUMN response_body TYPE JSONB USING convert_from(response_body, 'UTF8')::jsonb;
@@ -0,0 +1,4 @@
+-- JSONB reorders keys and drops whitespace, a replay has to return the
+-- original body byte for byte
+ALTER TABLE idempotency_keys
+    ALTER COLUMN response_body TYPE BYTEA USING convert_to(response_body::text, 'UTF8');
@@ -0,0 +1 @@
+ALTER TABLE refunds DROP COLUMN IF EXISTS failure_reason;
@@ -0,0 +1 @@
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS failure_reason TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,39 @@
+package deadletter
+
+import (
+	"net/http"
+
+	"github.com/kalom60/cashflow/internal/glue/routing"
+	"github.com/kalom60/cashflow/internal/handler"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterDeadLetterRoutes(
+	group *echo.Group,
+	deadLetterHandler handler.DeadLetter,
+	admin echo.MiddlewareFunc,
+	log logger.Logger,
+) {
+
+	deadLetters := []routing.Route{
+		{
+			Method:     http.MethodGet,
+			Path:       "/api/v1/admin/dlq/:queue",
+			Handler:    deadLetterHandler.ListDeadLetters,
+			Middleware: []echo.MiddlewareFunc{admin},
+		}, {
+			Method:     http.MethodPost,
+			Path:       "/api/v1/admin/dlq/:queue/replay",
+			Handler:    deadLetterHandler.ReplayDeadLetters,
+			Middleware: []echo.MiddlewareFunc{admin},
+		}, {
+			Method:     http.MethodDelete,
+			Path:       "/api/v1/admin/dlq/:queue",
+			Handler:    deadLetterHandler.PurgeDeadLetters,
+			Middleware: []echo.MiddlewareFunc{admin},
+		},
+	}
+
+	routing.RegisterRoute(group, deadLetters, log)
+}
@@ -0,0 +1,27 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/kalom60/cashflow/internal/glue/routing"
+	"github.com/kalom60/cashflow/internal/handler"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterHealthRoutes(
+	group *echo.Group,
+	healthHandler handler.Health,
+	log logger.Logger,
+) {
+
+	health := []routing.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/health",
+			Handler: healthHandler.GetHealth,
+		},
+	}
+
+	routing.RegisterRoute(group, health, log)
+}
@@ -0,0 +1,73 @@
+package merchant
+
+import (
+	"net/http"
+
+	"github.com/kalom60/cashflow/internal/glue/routing"
+	"github.com/kalom60/cashflow/internal/handler"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterMerchantRoutes(
+	group *echo.Group,
+	merchantHandler handler.Merchant,
+	auth echo.MiddlewareFunc,
+	signature echo.MiddlewareFunc,
+	admin echo.MiddlewareFunc,
+	log logger.Logger,
+) {
+
+	// Changing signing secrets is signed too, otherwise a leaked API key
+	// would be enough to revoke them and stop signatures being required
+	merchants := []routing.Route{
+		{
+			Method:     http.MethodPost,
+			Path:       "/api/v1/admin/merchants",
+			Handler:    merchantHandler.CreateMerchant,
+			Middleware: []echo.MiddlewareFunc{admin},
+		}, {
+			Method:     http.MethodPost,
+			Path:       "/api/v1/api-keys",
+			Handler:    merchantHandler.CreateAPIKey,
+			Middleware: []echo.MiddlewareFunc{auth},
+		}, {
+			Method:     http.MethodGet,
+			Path:       "/api/v1/api-keys",
+			Handler:    merchantHandler.ListAPIKeys,
+			Middleware: []echo.MiddlewareFunc{auth},
+		}, {
+			Method:     http.MethodPost,
+			Path:       "/api/v1/api-keys/:id/rotate",
+			Handler:    merchantHandler.RotateAPIKey,
+			Middleware: []echo.MiddlewareFunc{auth},
+		}, {
+			Method:     http.MethodDelete,
+			Path:       "/api/v1/api-keys/:id",
+			Handler:    merchantHandler.RevokeAPIKey,
+			Middleware: []echo.MiddlewareFunc{auth},
+		}, {
+			Method:     http.MethodPost,
+			Path:       "/api/v1/signing-secrets",
+			Handler:    merchantHandler.CreateSigningSecret,
+			Middleware: []echo.MiddlewareFunc{auth, signature},
+		}, {
+			Method:     http.MethodGet,
+			Path:       "/api/v1/signing-secrets",
+			Handler:    merchantHandler.ListSigningSecrets,
+			Middleware: []echo.MiddlewareFunc{auth},
+		}, {
+			Method:     http.MethodPost,
+			Path:       "/api/v1/signing-secrets/:id/rotate",
+			Handler:    merchantHandler.RotateSigningSecret,
+			Middleware: []echo.MiddlewareFunc{auth, signature},
+		}, {
+			Method:     http.MethodDelete,
+			Path:       "/api/v1/signing-secrets/:id",
+			Handler:    merchantHandler.RevokeSigningSecret,
+			Middleware: []echo.MiddlewareFunc{auth, signature},
+		},
+	}
+
+	routing.RegisterRoute(group, merchants, log)
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/constant/response"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Auth requires a valid X-API-Key and stores the merchant it belongs to in
+// the request context, where handlers scope their queries to it and the
+// logger records it as the user.
+func Auth(merchantModule module.Merchant, log logger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(dto.APIKeyHeader)
+			if key == "" {
+				return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+			}
+
+			ctx := c.Request().Context()
+
+			apiKey, err := merchantModule.Authenticate(ctx, key)
+			if err != nil {
+				log.Named("AuthMiddleware-Authenticate").Warn(ctx, "api key rejected", zap.Error(err))
+				return response.SendErrorResponseFormated(c, err)
+			}
+
+			trace.SpanFromContext(ctx).SetAttributes(
+				attribute.String("merchant.id", apiKey.MerchantID.String()),
+				attribute.String("api_key.prefix", apiKey.Prefix),
+			)
+
+			ctx = dto.WithMerchantID(ctx, apiKey.MerchantID)
+			ctx = logger.WithUser(ctx, apiKey.MerchantID.String())
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
+
+// AdminAuth guards the operator routes with a shared key sent in
+// X-Admin-Key. The routes are closed entirely when no key is configured.
+func AdminAuth(adminKey string, log logger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(dto.AdminKeyHeader)
+			if adminKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+				log.Named("AdminAuthMiddleware").Warn(c.Request().Context(), "admin key rejected", zap.String("path", c.Path()))
+				return response.SendErrorResponse(c, http.StatusUnauthorized, "invalid admin key")
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/glue/middleware"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// staticKeys authenticates the keys it was built with.
+type staticKeys struct {
+	module.Merchant
+	keys map[string]dto.APIKey
+}
+
+func (s staticKeys) Authenticate(ctx context.Context, key string) (dto.APIKey, error) {
+	apiKey, ok := s.keys[key]
+	if !ok {
+		return dto.APIKey{}, customErrors.ErrUnauthorized.New("invalid api key")
+	}
+	return apiKey, nil
+}
+
+func TestAuth(t *testing.T) {
+	merchantID := uuid.New()
+	keys := staticKeys{keys: map[string]dto.APIKey{
+		"valid": {ID: uuid.New(), MerchantID: merchantID, Prefix: "abc", CreatedAt: time.Now()},
+	}}
+
+	var seenMerchant uuid.UUID
+	var seenUser string
+	e := echo.New()
+	e.GET("/api/v1/payments", func(c echo.Context) error {
+		seenMerchant, _ = dto.MerchantIDFromContext(c.Request().Context())
+		seenUser = logger.User(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	}, middleware.Auth(keys, logger.New(zap.NewNop())))
+
+	cases := []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{name: "missing", key: "", status: http.StatusUnauthorized},
+		{name: "invalid", key: "invalid", status: http.StatusUnauthorized},
+		{name: "valid", key: "valid", status: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/payments", nil)
+			if tc.key != "" {
+				req.Header.Set(dto.APIKeyHeader, tc.key)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+		})
+	}
+
+	assert.Equal(t, merchantID, seenMerchant)
+	assert.Equal(t, merchantID.String(), seenUser)
+}
+
+func TestAdminAuth(t *testing.T) {
+	cases := []struct {
+		name     string
+		adminKey string
+		sent     string
+		status   int
+	}{
+		{name: "valid", adminKey: "secret", sent: "secret", status: http.StatusOK},
+		{name: "wrong key", adminKey: "secret", sent: "guess", status: http.StatusUnauthorized},
+		{name: "missing key", adminKey: "secret", sent: "", status: http.StatusUnauthorized},
+		{name: "not configured", adminKey: "", sent: "", status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			e.GET("/api/v1/admin/dlq/payments", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, middleware.AdminAuth(tc.adminKey, logger.New(zap.NewNop())))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/dlq/payments", nil)
+			if tc.sent != "" {
+				req.Header.Set(dto.AdminKeyHeader, tc.sent)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+		})
+	}
+}
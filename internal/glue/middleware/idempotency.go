@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/constant/response"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotency makes a route safe to retry when the client sends an
+// Idempotency-Key header. The first request with a key runs normally and its
+// response is stored; replays with the same payload get the stored response
+// back, replays with a different payload get a 422 and replays that arrive
+// while the first request is still running get a 409. Behind Auth, keys are
+// scoped to the calling merchant.
+func Idempotency(idempotencyModule module.Idempotency, log logger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(dto.IdempotencyKeyHeader)
+			if key == "" {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+
+			if len(key) > maxIdempotencyKeyLength {
+				return response.SendErrorResponse(c, http.StatusBadRequest, "idempotency key cannot be longer than 255 characters")
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				log.Named("IdempotencyMiddleware-ReadBody").Error(ctx, "failed to read request body", zap.Error(err))
+				return response.SendErrorResponse(c, http.StatusBadRequest, "invalid request payload")
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			// Keys only have to be unique per merchant, and one merchant must
+			// never be handed the stored response of another
+			if merchantID, ok := dto.MerchantIDFromContext(ctx); ok {
+				key = merchantID.String() + ":" + key
+			}
+
+			record, err := idempotencyModule.Begin(ctx, key, requestHash(c.Request(), body))
+			if err != nil {
+				log.Named("IdempotencyMiddleware-Begin").Warn(ctx, "idempotency key rejected", zap.String("key", key), zap.Error(err))
+				return response.SendErrorResponseFormated(c, err)
+			}
+
+			if record.Status == dto.IdempotencyStatusCompleted {
+				c.Response().Header().Set("Idempotent-Replayed", "true")
+				return c.JSONBlob(record.ResponseCode, record.ResponseBody)
+			}
+
+			recorder := &bodyRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			// The client may have given up and cancelled the request. The key
+			// must still be settled, or its retry is turned away until the
+			// lock times out.
+			ctx = context.WithoutCancel(ctx)
+
+			// Server errors are not worth replaying, let the client retry them
+			if c.Response().Status >= http.StatusInternalServerError {
+				if err := idempotencyModule.Release(ctx, key); err != nil {
+					log.Named("IdempotencyMiddleware-Release").Error(ctx, "failed to release idempotency key", zap.String("key", key), zap.Error(err))
+				}
+				return nil
+			}
+
+			if err := idempotencyModule.Complete(ctx, key, c.Response().Status, recorder.body.Bytes()); err != nil {
+				log.Named("IdempotencyMiddleware-Complete").Error(ctx, "failed to store idempotent response", zap.String("key", key), zap.Error(err))
+			}
+
+			return nil
+		}
+	}
+}
+
+func requestHash(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(req.URL.Path))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,238 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/glue/middleware"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type memoryIdempotency struct {
+	mu      sync.Mutex
+	records map[string]dto.IdempotencyKey
+}
+
+func (m *memoryIdempotency) Begin(ctx context.Context, key, requestHash string) (dto.IdempotencyKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.records[key]
+	if !ok {
+		record := dto.IdempotencyKey{Key: key, RequestHash: requestHash, Status: dto.IdempotencyStatusInProgress}
+		m.records[key] = record
+		return record, nil
+	}
+	if existing.RequestHash != requestHash {
+		return dto.IdempotencyKey{}, customErrors.ErrIdempotencyKeyMismatch.New("mismatch")
+	}
+	if existing.Status != dto.IdempotencyStatusCompleted {
+		return dto.IdempotencyKey{}, customErrors.ErrIdempotencyKeyInUse.New("in use")
+	}
+	return existing, nil
+}
+
+func (m *memoryIdempotency) Complete(ctx context.Context, key string, responseCode int, responseBody []byte) error {
+	// Like a query through pgx, fail once the context is cancelled
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record := m.records[key]
+	record.Status = dto.IdempotencyStatusCompleted
+	record.ResponseCode = responseCode
+	record.ResponseBody = append([]byte(nil), responseBody...)
+	m.records[key] = record
+	return nil
+}
+
+func (m *memoryIdempotency) Release(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.records, key)
+	return nil
+}
+
+func newServer(handler echo.HandlerFunc) (*echo.Echo, *memoryIdempotency) {
+	store := &memoryIdempotency{records: map[string]dto.IdempotencyKey{}}
+	e := echo.New()
+	e.POST("/api/v1/payments", handler, middleware.Idempotency(store, logger.New(zap.NewNop())))
+	return e, store
+}
+
+func send(e *echo.Echo, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/payments", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(dto.IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	e, _ := newServer(func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusCreated, map[string]int{"call": calls})
+	})
+
+	first := send(e, "key-1", `{"amount":10}`)
+	second := send(e, "key-1", `{"amount":10}`)
+
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyRejectsDifferentPayload(t *testing.T) {
+	e, _ := newServer(func(c echo.Context) error {
+		return c.JSON(http.StatusCreated, map[string]string{"ok": "true"})
+	})
+
+	send(e, "key-1", `{"amount":10}`)
+	rec := send(e, "key-1", `{"amount":20}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestIdempotencyRejectsConcurrentDuplicate(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e, _ := newServer(func(c echo.Context) error {
+		close(started)
+		<-release
+		return c.JSON(http.StatusCreated, map[string]string{"ok": "true"})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send(e, "key-1", `{"amount":10}`) }()
+
+	<-started
+	rec := send(e, "key-1", `{"amount":10}`)
+	close(release)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	calls := 0
+	e, store := newServer(func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "boom"})
+	})
+
+	send(e, "key-1", `{"amount":10}`)
+	send(e, "key-1", `{"amount":10}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, store.records)
+}
+
+func TestIdempotencySettlesKeyAfterClientDisconnects(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   int
+	}{
+		{name: "completed", status: http.StatusCreated, want: 1},
+		{name: "released", status: http.StatusInternalServerError, want: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			e, store := newServer(func(c echo.Context) error {
+				// The client times out while the handler is still running
+				cancel()
+				return c.JSON(tc.status, map[string]string{"ok": "true"})
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/payments", strings.NewReader(`{"amount":10}`)).WithContext(ctx)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(dto.IdempotencyKeyHeader, "key-1")
+			e.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Len(t, store.records, tc.want)
+			if tc.want > 0 {
+				assert.Equal(t, dto.IdempotencyStatusCompleted, store.records["key-1"].Status)
+			}
+		})
+	}
+}
+
+func TestIdempotencyWithoutHeaderPassesThrough(t *testing.T) {
+	calls := 0
+	e, store := newServer(func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusCreated, map[string]string{"ok": "true"})
+	})
+
+	send(e, "", `{"amount":10}`)
+	send(e, "", `{"amount":10}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, store.records)
+}
+
+func TestIdempotencyKeysAreScopedToMerchant(t *testing.T) {
+	calls := 0
+	store := &memoryIdempotency{records: map[string]dto.IdempotencyKey{}}
+	asMerchant := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			merchantID := uuid.MustParse(c.Request().Header.Get("X-Merchant"))
+			c.SetRequest(c.Request().WithContext(dto.WithMerchantID(c.Request().Context(), merchantID)))
+			return next(c)
+		}
+	}
+
+	e := echo.New()
+	e.POST("/api/v1/payments", func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusCreated, map[string]int{"call": calls})
+	}, asMerchant, middleware.Idempotency(store, logger.New(zap.NewNop())))
+
+	sendAs := func(merchantID uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/payments", strings.NewReader(`{"amount":10}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(dto.IdempotencyKeyHeader, "key-1")
+		req.Header.Set("X-Merchant", merchantID.String())
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first, second := uuid.New(), uuid.New()
+	sendAs(first)
+	rec := sendAs(second)
+	replay := sendAs(first)
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.JSONEq(t, `{"call":1}`, replay.Body.String())
+}
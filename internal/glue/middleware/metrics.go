@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/kalom60/cashflow/platform/metrics"
+	"github.com/labstack/echo/v4"
+)
+
+// Metrics records the duration of every request by method, route template
+// and status code. Using the template instead of the raw path keeps IDs out
+// of the label values.
+func Metrics() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			// Write the error response here so the final status is recorded
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			metrics.HTTPRequestDuration.
+				WithLabelValues(c.Request().Method, route, strconv.Itoa(c.Response().Status)).
+				Observe(time.Since(start).Seconds())
+
+			return nil
+		}
+	}
+}
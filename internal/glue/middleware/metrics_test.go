@@ -0,0 +1,31 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kalom60/cashflow/internal/glue/middleware"
+	"github.com/kalom60/cashflow/platform/metrics"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsRecordsRouteTemplate(t *testing.T) {
+	e := echo.New()
+	e.Use(middleware.Metrics())
+	e.GET("/api/v1/payments/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "payment not found")
+	})
+
+	before := testutil.CollectAndCount(metrics.HTTPRequestDuration)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/payments/4f7c2a86-2c1b-4f5e-9d55-3c2c8f0b1a11", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// One new series labelled with the template, not the payment ID
+	assert.Equal(t, before+1, testutil.CollectAndCount(metrics.HTTPRequestDuration))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.HTTPRequestDuration.MustCurryWith(map[string]string{"route": "/api/v1/payments/:id"})))
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+)
+
+// requestIDPattern limits client supplied IDs to what is safe to log and to
+// forward in message headers.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID accepts the X-Request-ID of the caller, or generates one when it
+// is missing or malformed. The ID and the arrival time are stored in the
+// request context, where the logger picks them up, and the ID is echoed in
+// the response.
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			requestID := c.Request().Header.Get(dto.RequestIDHeader)
+			if !requestIDPattern.MatchString(requestID) {
+				requestID = uuid.NewString()
+			}
+
+			ctx := logger.WithRequestID(c.Request().Context(), requestID)
+			ctx = logger.WithRequestStart(ctx, time.Now())
+			c.SetRequest(c.Request().WithContext(ctx))
+			c.Response().Header().Set(dto.RequestIDHeader, requestID)
+
+			return next(c)
+		}
+	}
+}
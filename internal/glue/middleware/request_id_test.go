@@ -0,0 +1,59 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/glue/middleware"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveRequestID(t *testing.T, header string) (string, *httptest.ResponseRecorder) {
+	t.Helper()
+
+	var seen string
+	e := echo.New()
+	e.Use(middleware.RequestID())
+	e.GET("/health", func(c echo.Context) error {
+		seen = logger.RequestID(c.Request().Context())
+		_, ok := logger.RequestStart(c.Request().Context())
+		assert.True(t, ok)
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	if header != "" {
+		req.Header.Set(dto.RequestIDHeader, header)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return seen, rec
+}
+
+func TestRequestIDKeepsCallerID(t *testing.T) {
+	seen, rec := serveRequestID(t, "req-42")
+
+	assert.Equal(t, "req-42", seen)
+	assert.Equal(t, "req-42", rec.Header().Get(dto.RequestIDHeader))
+}
+
+func TestRequestIDGeneratesMissingID(t *testing.T) {
+	seen, rec := serveRequestID(t, "")
+
+	_, err := uuid.Parse(seen)
+	assert.NoError(t, err)
+	assert.Equal(t, seen, rec.Header().Get(dto.RequestIDHeader))
+}
+
+func TestRequestIDReplacesMalformedID(t *testing.T) {
+	seen, rec := serveRequestID(t, "bad id\nwith newline")
+
+	assert.NotEqual(t, "bad id\nwith newline", seen)
+	assert.Equal(t, seen, rec.Header().Get(dto.RequestIDHeader))
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/constant/response"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/signature"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const maxNonceLength = 128
+
+// NonceStore remembers the nonces of accepted signed requests. Use reports
+// false when nonce was already used within its ttl.
+type NonceStore interface {
+	Use(nonce string, ttl time.Duration) bool
+}
+
+// Signature requires merchants that have an active signing secret to sign
+// their requests, see platform/signature for the scheme. A request is
+// rejected when its X-Timestamp is more than maxSkew away from the server
+// clock, when its signature does not match any of the merchant's active
+// secrets, or when its X-Nonce was already used. Merchants without a signing
+// secret are let through unsigned. It must run after Auth.
+func Signature(merchantModule module.Merchant, nonces NonceStore, maxSkew time.Duration, log logger.Logger) echo.MiddlewareFunc {
+	if maxSkew <= 0 {
+		maxSkew = dto.DefaultSignatureSkew
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+
+			merchantID, ok := dto.MerchantIDFromContext(ctx)
+			if !ok {
+				return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+			}
+
+			secrets, err := merchantModule.ActiveSigningSecrets(ctx, merchantID)
+			if err != nil {
+				log.Named("SignatureMiddleware-ActiveSigningSecrets").Error(ctx, "failed to get signing secrets", zap.Any("merchant_id", merchantID), zap.Error(err))
+				return response.SendErrorResponseFormated(c, err)
+			}
+
+			if len(secrets) == 0 {
+				return next(c)
+			}
+
+			header := c.Request().Header.Get(dto.SignatureHeader)
+			if header == "" {
+				return response.SendErrorResponse(c, http.StatusUnauthorized, "missing request signature")
+			}
+
+			unix, err := strconv.ParseInt(c.Request().Header.Get(dto.TimestampHeader), 10, 64)
+			if err != nil {
+				return response.SendErrorResponse(c, http.StatusUnauthorized, "invalid signature timestamp")
+			}
+
+			timestamp := time.Unix(unix, 0)
+			if skew := time.Since(timestamp).Abs(); skew > maxSkew {
+				log.Named("SignatureMiddleware-Timestamp").Warn(ctx, "signature timestamp outside the allowed window", zap.Duration("skew", skew))
+				return response.SendErrorResponse(c, http.StatusUnauthorized, "signature timestamp outside the allowed window")
+			}
+
+			nonce := c.Request().Header.Get(dto.NonceHeader)
+			if nonce == "" || len(nonce) > maxNonceLength {
+				return response.SendErrorResponse(c, http.StatusUnauthorized, "missing or invalid nonce")
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				log.Named("SignatureMiddleware-ReadBody").Error(ctx, "failed to read request body", zap.Error(err))
+				return response.SendErrorResponse(c, http.StatusBadRequest, "invalid request payload")
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			keys := make([]string, 0, len(secrets))
+			for _, secret := range secrets {
+				keys = append(keys, secret.Secret)
+			}
+
+			payload := signature.Payload{
+				Method:    c.Request().Method,
+				Path:      c.Request().URL.RequestURI(),
+				Timestamp: timestamp,
+				Nonce:     nonce,
+				Body:      body,
+			}
+			if !signature.Verify(keys, header, payload) {
+				log.Named("SignatureMiddleware-Verify").Warn(ctx, "request signature rejected", zap.Any("merchant_id", merchantID))
+				return response.SendErrorResponse(c, http.StatusUnauthorized, "invalid request signature")
+			}
+
+			// Only a correctly signed request may use up a nonce. A timestamp
+			// is accepted for maxSkew either side of now, so the nonce has to
+			// be remembered for twice that.
+			if !nonces.Use(merchantID.String()+":"+nonce, 2*maxSkew) {
+				log.Named("SignatureMiddleware-Nonce").Warn(ctx, "replayed request rejected", zap.Any("merchant_id", merchantID), zap.String("nonce", nonce))
+				return response.SendErrorResponse(c, http.StatusUnauthorized, "nonce has already been used")
+			}
+
+			return next(c)
+		}
+	}
+}
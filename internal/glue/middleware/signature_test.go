@@ -0,0 +1,157 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/glue/middleware"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/nonce"
+	"github.com/kalom60/cashflow/platform/signature"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// staticSecrets hands out the signing secrets it was built with.
+type staticSecrets struct {
+	module.Merchant
+	secrets map[uuid.UUID][]string
+}
+
+func (s staticSecrets) ActiveSigningSecrets(ctx context.Context, merchantID uuid.UUID) ([]dto.SigningSecret, error) {
+	var secrets []dto.SigningSecret
+	for _, secret := range s.secrets[merchantID] {
+		secrets = append(secrets, dto.SigningSecret{ID: uuid.New(), MerchantID: merchantID, Secret: secret})
+	}
+	return secrets, nil
+}
+
+type signedRequest struct {
+	secret    string
+	timestamp time.Time
+	nonce     string
+	body      string
+	// signedBody is what the signature covers when it differs from body
+	signedBody string
+}
+
+func newSignatureServer(t *testing.T, secrets staticSecrets, merchantID uuid.UUID) (*echo.Echo, *string) {
+	t.Helper()
+
+	var seenBody string
+	withMerchant := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.SetRequest(c.Request().WithContext(dto.WithMerchantID(c.Request().Context(), merchantID)))
+			return next(c)
+		}
+	}
+
+	e := echo.New()
+	e.POST("/api/v1/payments", func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		require.NoError(t, err)
+		seenBody = string(body)
+		return c.NoContent(http.StatusCreated)
+	}, withMerchant, middleware.Signature(secrets, nonce.NewStore(), time.Minute, logger.New(zap.NewNop())))
+
+	return e, &seenBody
+}
+
+func sendSigned(e *echo.Echo, r signedRequest) int {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/payments", strings.NewReader(r.body))
+	if r.secret != "" {
+		signedBody := r.body
+		if r.signedBody != "" {
+			signedBody = r.signedBody
+		}
+		req.Header.Set(dto.SignatureHeader, signature.Sign(r.secret, signature.Payload{
+			Method:    http.MethodPost,
+			Path:      "/api/v1/payments",
+			Timestamp: r.timestamp,
+			Nonce:     r.nonce,
+			Body:      []byte(signedBody),
+		}))
+		req.Header.Set(dto.TimestampHeader, strconv.FormatInt(r.timestamp.Unix(), 10))
+		req.Header.Set(dto.NonceHeader, r.nonce)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestSignature(t *testing.T) {
+	merchantID := uuid.New()
+	secrets := staticSecrets{secrets: map[uuid.UUID][]string{
+		merchantID: {"new-secret", "old-secret"},
+	}}
+	body := `{"amount":"10.00","currency":"ETB","reference":"order-1"}`
+	now := time.Now()
+
+	cases := []struct {
+		name   string
+		req    signedRequest
+		status int
+	}{
+		{name: "unsigned", req: signedRequest{body: body}, status: http.StatusUnauthorized},
+		{name: "valid", req: signedRequest{secret: "new-secret", timestamp: now, nonce: "n-1", body: body}, status: http.StatusCreated},
+		{name: "secret being rotated out", req: signedRequest{secret: "old-secret", timestamp: now, nonce: "n-2", body: body}, status: http.StatusCreated},
+		{name: "unknown secret", req: signedRequest{secret: "other-secret", timestamp: now, nonce: "n-3", body: body}, status: http.StatusUnauthorized},
+		{name: "tampered body", req: signedRequest{secret: "new-secret", timestamp: now, nonce: "n-4", body: strings.Replace(body, "10.00", "1000.00", 1), signedBody: body}, status: http.StatusUnauthorized},
+		{name: "too old", req: signedRequest{secret: "new-secret", timestamp: now.Add(-2 * time.Minute), nonce: "n-5", body: body}, status: http.StatusUnauthorized},
+		{name: "too far ahead", req: signedRequest{secret: "new-secret", timestamp: now.Add(2 * time.Minute), nonce: "n-6", body: body}, status: http.StatusUnauthorized},
+		{name: "missing nonce", req: signedRequest{secret: "new-secret", timestamp: now, body: body}, status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, _ := newSignatureServer(t, secrets, merchantID)
+
+			assert.Equal(t, tc.status, sendSigned(e, tc.req))
+		})
+	}
+}
+
+func TestSignatureRejectsReplays(t *testing.T) {
+	merchantID := uuid.New()
+	secrets := staticSecrets{secrets: map[uuid.UUID][]string{merchantID: {"secret"}}}
+	e, seenBody := newSignatureServer(t, secrets, merchantID)
+
+	req := signedRequest{secret: "secret", timestamp: time.Now(), nonce: "n-1", body: `{"amount":"10.00"}`}
+	assert.Equal(t, http.StatusCreated, sendSigned(e, req))
+	assert.Equal(t, req.body, *seenBody, "the handler still reads the body")
+
+	assert.Equal(t, http.StatusUnauthorized, sendSigned(e, req))
+
+	req.nonce = "n-2"
+	assert.Equal(t, http.StatusCreated, sendSigned(e, req))
+}
+
+func TestSignatureForgedRequestsDoNotUseUpNonces(t *testing.T) {
+	merchantID := uuid.New()
+	secrets := staticSecrets{secrets: map[uuid.UUID][]string{merchantID: {"secret"}}}
+	e, _ := newSignatureServer(t, secrets, merchantID)
+
+	forged := signedRequest{secret: "guessed", timestamp: time.Now(), nonce: "n-1", body: `{}`}
+	assert.Equal(t, http.StatusUnauthorized, sendSigned(e, forged))
+
+	forged.secret = "secret"
+	assert.Equal(t, http.StatusCreated, sendSigned(e, forged))
+}
+
+func TestSignatureIsOptionalWithoutSigningSecrets(t *testing.T) {
+	e, _ := newSignatureServer(t, staticSecrets{}, uuid.New())
+
+	assert.Equal(t, http.StatusCreated, sendSigned(e, signedRequest{body: `{}`}))
+}
@@ -12,18 +12,33 @@ import (
 func RegisterPaymentRoutes(
 	group *echo.Group,
 	paymentHandler handler.Payment,
+	auth echo.MiddlewareFunc,
+	signature echo.MiddlewareFunc,
+	idempotency echo.MiddlewareFunc,
 	log logger.Logger,
 ) {
 
 	payments := []routing.Route{
 		{
-			Method:  http.MethodPost,
-			Path:    "/api/v1/payments",
-			Handler: paymentHandler.CreatePayment,
+			Method:     http.MethodPost,
+			Path:       "/api/v1/payments",
+			Handler:    paymentHandler.CreatePayment,
+			Middleware: []echo.MiddlewareFunc{auth, signature, idempotency},
 		}, {
-			Method:  http.MethodGet,
-			Path:    "/api/v1/payments/:id",
-			Handler: paymentHandler.GetPaymentDetails,
+			Method:     http.MethodGet,
+			Path:       "/api/v1/payments",
+			Handler:    paymentHandler.ListPayments,
+			Middleware: []echo.MiddlewareFunc{auth},
+		}, {
+			Method:     http.MethodGet,
+			Path:       "/api/v1/payments/:id",
+			Handler:    paymentHandler.GetPaymentDetails,
+			Middleware: []echo.MiddlewareFunc{auth},
+		}, {
+			Method:     http.MethodGet,
+			Path:       "/api/v1/payments/:id/history",
+			Handler:    paymentHandler.GetPaymentHistory,
+			Middleware: []echo.MiddlewareFunc{auth},
 		},
 	}
 
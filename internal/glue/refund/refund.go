@@ -0,0 +1,34 @@
+package refund
+
+import (
+	"net/http"
+
+	"github.com/kalom60/cashflow/internal/glue/routing"
+	"github.com/kalom60/cashflow/internal/handler"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterRefundRoutes(
+	group *echo.Group,
+	refundHandler handler.Refund,
+	auth echo.MiddlewareFunc,
+	log logger.Logger,
+) {
+
+	refunds := []routing.Route{
+		{
+			Method:     http.MethodPost,
+			Path:       "/api/v1/payments/:id/refunds",
+			Handler:    refundHandler.CreateRefund,
+			Middleware: []echo.MiddlewareFunc{auth},
+		}, {
+			Method:     http.MethodGet,
+			Path:       "/api/v1/payments/:id/refunds",
+			Handler:    refundHandler.GetPaymentRefunds,
+			Middleware: []echo.MiddlewareFunc{auth},
+		},
+	}
+
+	routing.RegisterRoute(group, refunds, log)
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/kalom60/cashflow/internal/glue/routing"
+	"github.com/kalom60/cashflow/internal/handler"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterWebhookRoutes(
+	group *echo.Group,
+	webhookHandler handler.Webhook,
+	auth echo.MiddlewareFunc,
+	signature echo.MiddlewareFunc,
+	log logger.Logger,
+) {
+
+	// Registering and deleting endpoints decides where payment events go, so
+	// it is signed like changes to signing secrets
+	webhooks := []routing.Route{
+		{
+			Method:     http.MethodPost,
+			Path:       "/api/v1/webhooks",
+			Handler:    webhookHandler.CreateWebhookEndpoint,
+			Middleware: []echo.MiddlewareFunc{auth, signature},
+		}, {
+			Method:     http.MethodGet,
+			Path:       "/api/v1/webhooks",
+			Handler:    webhookHandler.ListWebhookEndpoints,
+			Middleware: []echo.MiddlewareFunc{auth},
+		}, {
+			Method:     http.MethodDelete,
+			Path:       "/api/v1/webhooks/:id",
+			Handler:    webhookHandler.DeleteWebhookEndpoint,
+			Middleware: []echo.MiddlewareFunc{auth, signature},
+		}, {
+			Method:     http.MethodGet,
+			Path:       "/api/v1/webhooks/:id/deliveries",
+			Handler:    webhookHandler.ListWebhookDeliveries,
+			Middleware: []echo.MiddlewareFunc{auth},
+		}, {
+			Method:     http.MethodPost,
+			Path:       "/api/v1/webhook-deliveries/:id/redeliver",
+			Handler:    webhookHandler.RedeliverWebhookDelivery,
+			Middleware: []echo.MiddlewareFunc{auth},
+		},
+	}
+
+	routing.RegisterRoute(group, webhooks, log)
+}
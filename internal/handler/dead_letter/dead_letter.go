@@ -0,0 +1,118 @@
+package deadletter
+
+import (
+	"net/http"
+
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/constant/response"
+	"github.com/kalom60/cashflow/internal/handler"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type deadLetterHandler struct {
+	logger           logger.Logger
+	deadLetterModule module.DeadLetter
+}
+
+func Init(logger logger.Logger, deadLetterModule module.DeadLetter) handler.DeadLetter {
+	return &deadLetterHandler{
+		logger:           logger,
+		deadLetterModule: deadLetterModule,
+	}
+}
+
+// ListDeadLetters godoc
+//
+//	@Summary		Inspect dead-lettered messages
+//	@Description	Returns messages parked in the payments.dlq or refunds.dlq queue without removing them
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			queue	path		string	true	"Dead-letter queue"	Enums(payments, refunds)
+//	@Param			limit	query		int		false	"Number of messages (1-100, default 10)"
+//	@Success		200		{array}		dto.DeadLetter
+//	@Failure		400		{object}	map[string]string	"Invalid limit"
+//	@Failure		401		{object}	map[string]string	"Missing or invalid admin key"
+//	@Failure		404		{object}	map[string]string	"Unknown dead-letter queue"
+//	@Failure		503		{object}	map[string]string	"Message broker unavailable"
+//	@Security		AdminKeyAuth
+//	@Router			/api/v1/admin/dlq/{queue} [get]
+func (dh *deadLetterHandler) ListDeadLetters(c echo.Context) error {
+	limit, err := dh.bindLimit(c)
+	if err != nil {
+		return response.SendErrorResponse(c, 400, err.Error())
+	}
+
+	deadLetters, err := dh.deadLetterModule.ListDeadLetters(c.Request().Context(), c.Param("queue"), limit)
+	if err != nil {
+		dh.logger.Named("DeadLetterHandler-ListDeadLetters-Module").Error(c.Request().Context(), "failed to list dead letters", zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, deadLetters)
+}
+
+// ReplayDeadLetters godoc
+//
+//	@Summary		Replay dead-lettered messages
+//	@Description	Moves up to limit messages from payments.dlq or refunds.dlq back onto the queue they were rejected from, oldest first
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			queue	path		string	true	"Dead-letter queue"	Enums(payments, refunds)
+//	@Param			limit	query		int		false	"Number of messages (1-100, default 10)"
+//	@Success		200		{object}	dto.ReplayDeadLettersResponse
+//	@Failure		400		{object}	map[string]string	"Invalid limit"
+//	@Failure		401		{object}	map[string]string	"Missing or invalid admin key"
+//	@Failure		404		{object}	map[string]string	"Unknown dead-letter queue"
+//	@Failure		503		{object}	map[string]string	"Message broker unavailable"
+//	@Security		AdminKeyAuth
+//	@Router			/api/v1/admin/dlq/{queue}/replay [post]
+func (dh *deadLetterHandler) ReplayDeadLetters(c echo.Context) error {
+	limit, err := dh.bindLimit(c)
+	if err != nil {
+		return response.SendErrorResponse(c, 400, err.Error())
+	}
+
+	replayed, err := dh.deadLetterModule.ReplayDeadLetters(c.Request().Context(), c.Param("queue"), limit)
+	if err != nil {
+		dh.logger.Named("DeadLetterHandler-ReplayDeadLetters-Module").Error(c.Request().Context(), "failed to replay dead letters", zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, dto.ReplayDeadLettersResponse{Replayed: replayed})
+}
+
+// PurgeDeadLetters godoc
+//
+//	@Summary		Purge dead-lettered messages
+//	@Description	Permanently drops every message in payments.dlq or refunds.dlq
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			queue	path		string	true	"Dead-letter queue"	Enums(payments, refunds)
+//	@Success		200		{object}	dto.PurgeDeadLettersResponse
+//	@Failure		401		{object}	map[string]string	"Missing or invalid admin key"
+//	@Failure		404		{object}	map[string]string	"Unknown dead-letter queue"
+//	@Failure		503		{object}	map[string]string	"Message broker unavailable"
+//	@Security		AdminKeyAuth
+//	@Router			/api/v1/admin/dlq/{queue} [delete]
+func (dh *deadLetterHandler) PurgeDeadLetters(c echo.Context) error {
+	purged, err := dh.deadLetterModule.PurgeDeadLetters(c.Request().Context(), c.Param("queue"))
+	if err != nil {
+		dh.logger.Named("DeadLetterHandler-PurgeDeadLetters-Module").Error(c.Request().Context(), "failed to purge dead letters", zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, dto.PurgeDeadLettersResponse{Purged: purged})
+}
+
+func (dh *deadLetterHandler) bindLimit(c echo.Context) (int, error) {
+	var req dto.DeadLetterRequest
+	if err := c.Bind(&req); err != nil {
+		dh.logger.Named("DeadLetterHandler-Bind").Error(c.Request().Context(), "failed to bind request", zap.Any("error", err.Error()))
+		return 0, err
+	}
+
+	return req.ToLimit()
+}
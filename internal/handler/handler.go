@@ -5,4 +5,41 @@ import "github.com/labstack/echo/v4"
 type Payment interface {
 	CreatePayment(c echo.Context) error
 	GetPaymentDetails(c echo.Context) error
+	ListPayments(c echo.Context) error
+	GetPaymentHistory(c echo.Context) error
+}
+
+type Refund interface {
+	CreateRefund(c echo.Context) error
+	GetPaymentRefunds(c echo.Context) error
+}
+
+type Health interface {
+	GetHealth(c echo.Context) error
+}
+
+type DeadLetter interface {
+	ListDeadLetters(c echo.Context) error
+	ReplayDeadLetters(c echo.Context) error
+	PurgeDeadLetters(c echo.Context) error
+}
+
+type Merchant interface {
+	CreateMerchant(c echo.Context) error
+	CreateAPIKey(c echo.Context) error
+	ListAPIKeys(c echo.Context) error
+	RotateAPIKey(c echo.Context) error
+	RevokeAPIKey(c echo.Context) error
+	CreateSigningSecret(c echo.Context) error
+	ListSigningSecrets(c echo.Context) error
+	RotateSigningSecret(c echo.Context) error
+	RevokeSigningSecret(c echo.Context) error
+}
+
+type Webhook interface {
+	CreateWebhookEndpoint(c echo.Context) error
+	ListWebhookEndpoints(c echo.Context) error
+	DeleteWebhookEndpoint(c echo.Context) error
+	ListWebhookDeliveries(c echo.Context) error
+	RedeliverWebhookDelivery(c echo.Context) error
 }
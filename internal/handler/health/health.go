@@ -0,0 +1,49 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/handler"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/labstack/echo/v4"
+)
+
+type healthHandler struct {
+	logger    logger.Logger
+	msgClient messaging.MessagingClient
+}
+
+func Init(logger logger.Logger, msgClient messaging.MessagingClient) handler.Health {
+	return &healthHandler{
+		logger:    logger,
+		msgClient: msgClient,
+	}
+}
+
+// GetHealth godoc
+//
+//	@Summary		Service health
+//	@Description	Reports whether the service and its RabbitMQ connection are healthy. Also reports how many delivered messages are still being processed. Returns 503 while RabbitMQ is reconnecting.
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200	{object}	dto.HealthResponse
+//	@Failure		503	{object}	dto.HealthResponse
+//	@Router			/health [get]
+func (hh *healthHandler) GetHealth(c echo.Context) error {
+	rabbitMQ := hh.msgClient.State()
+
+	resp := dto.HealthResponse{
+		Status:   dto.HealthStatusOK,
+		RabbitMQ: string(rabbitMQ),
+		InFlight: hh.msgClient.InFlight(),
+	}
+
+	if rabbitMQ != messaging.StateConnected {
+		resp.Status = dto.HealthStatusUnavailable
+		return c.JSON(http.StatusServiceUnavailable, resp)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,241 @@
+package merchant
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/constant/response"
+	"github.com/kalom60/cashflow/internal/handler"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type merchantHandler struct {
+	logger         logger.Logger
+	merchantModule module.Merchant
+}
+
+func Init(logger logger.Logger, merchantModule module.Merchant) handler.Merchant {
+	return &merchantHandler{
+		logger:         logger,
+		merchantModule: merchantModule,
+	}
+}
+
+// CreateMerchant godoc
+//
+//	@Summary		Create a merchant
+//	@Description	Creates a merchant and issues its first API key. The key is only returned in this response.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			merchant	body		dto.CreateMerchantRequest	true	"Merchant creation request"
+//	@Success		201			{object}	dto.CreateMerchantResponse
+//	@Failure		400			{object}	map[string]string	"Invalid input"
+//	@Failure		401			{object}	map[string]string	"Missing or invalid admin key"
+//	@Failure		500			{object}	map[string]string	"Internal server error"
+//	@Security		AdminKeyAuth
+//	@Router			/api/v1/admin/merchants [post]
+func (mh *merchantHandler) CreateMerchant(c echo.Context) error {
+	var req dto.CreateMerchantRequest
+	if err := c.Bind(&req); err != nil {
+		mh.logger.Named("MerchantHandler-CreateMerchant-Bind").Error(c.Request().Context(), "failed to bind request", zap.Any("error", err.Error()))
+		return response.SendErrorResponse(c, 400, "invalid request payload")
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.SendErrorResponse(c, 400, err.Error())
+	}
+
+	merchant, key, err := mh.merchantModule.CreateMerchant(c.Request().Context(), req.ToMerchant())
+	if err != nil {
+		mh.logger.Named("MerchantHandler-CreateMerchant-Module").Error(c.Request().Context(), "failed to create merchant", zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusCreated, dto.CreateMerchantResponse{
+		Merchant: dto.MerchantResponse{
+			ID:        merchant.ID,
+			Name:      merchant.Name,
+			CreatedAt: merchant.CreatedAt,
+		},
+		APIKey: toIssuedAPIKeyResponse(key),
+	})
+}
+
+// CreateAPIKey godoc
+//
+//	@Summary		Create an API key
+//	@Description	Issues an additional API key for the calling merchant. The key is only returned in this response.
+//	@Tags			API Keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			key	body		dto.CreateAPIKeyRequest	false	"API key creation request"
+//	@Success		201	{object}	dto.IssuedAPIKeyResponse
+//	@Failure		400	{object}	map[string]string	"Invalid input"
+//	@Failure		401	{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/api-keys [post]
+func (mh *merchantHandler) CreateAPIKey(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	var req dto.CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		mh.logger.Named("MerchantHandler-CreateAPIKey-Bind").Error(c.Request().Context(), "failed to bind request", zap.Any("error", err.Error()))
+		return response.SendErrorResponse(c, 400, "invalid request payload")
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.SendErrorResponse(c, 400, err.Error())
+	}
+
+	key, err := mh.merchantModule.CreateAPIKey(c.Request().Context(), merchantID, req.Name)
+	if err != nil {
+		mh.logger.Named("MerchantHandler-CreateAPIKey-Module").Error(c.Request().Context(), "failed to create api key", zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusCreated, toIssuedAPIKeyResponse(key))
+}
+
+// ListAPIKeys godoc
+//
+//	@Summary		List API keys
+//	@Description	Lists every API key of the calling merchant, newest first, including revoked and expired ones
+//	@Tags			API Keys
+//	@Produce		json
+//	@Success		200	{array}		dto.APIKeyResponse
+//	@Failure		401	{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/api-keys [get]
+func (mh *merchantHandler) ListAPIKeys(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	keys, err := mh.merchantModule.ListAPIKeys(c.Request().Context(), merchantID)
+	if err != nil {
+		mh.logger.Named("MerchantHandler-ListAPIKeys-Module").Error(c.Request().Context(), "failed to list api keys", zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	resp := make([]dto.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, toAPIKeyResponse(key))
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, resp)
+}
+
+// RotateAPIKey godoc
+//
+//	@Summary		Rotate an API key
+//	@Description	Issues a replacement for an active API key. The old key keeps working for overlap_seconds (default one day, 0 retires it straight away) so clients can switch over.
+//	@Tags			API Keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"API key ID"
+//	@Param			rotate	body		dto.RotateAPIKeyRequest	false	"Rotation options"
+//	@Success		201		{object}	dto.RotateAPIKeyResponse
+//	@Failure		400		{object}	map[string]string	"Invalid input"
+//	@Failure		401		{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		404		{object}	map[string]string	"API key not found"
+//	@Failure		409		{object}	map[string]string	"API key is revoked or expired"
+//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/api-keys/{id}/rotate [post]
+func (mh *merchantHandler) RotateAPIKey(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.SendErrorResponse(c, 400, "invalid api key id format")
+	}
+
+	var req dto.RotateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		mh.logger.Named("MerchantHandler-RotateAPIKey-Bind").Error(c.Request().Context(), "failed to bind request", zap.Any("error", err.Error()))
+		return response.SendErrorResponse(c, 400, "invalid request payload")
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.SendErrorResponse(c, 400, err.Error())
+	}
+
+	previous, current, err := mh.merchantModule.RotateAPIKey(c.Request().Context(), merchantID, id, req.Overlap())
+	if err != nil {
+		mh.logger.Named("MerchantHandler-RotateAPIKey-Module").Error(c.Request().Context(), "failed to rotate api key", zap.Any("id", id), zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusCreated, dto.RotateAPIKeyResponse{
+		Previous: toAPIKeyResponse(previous),
+		Current:  toIssuedAPIKeyResponse(current),
+	})
+}
+
+// RevokeAPIKey godoc
+//
+//	@Summary		Revoke an API key
+//	@Description	Stops an API key from working straight away. Revoking an already revoked key is a no-op.
+//	@Tags			API Keys
+//	@Produce		json
+//	@Param			id	path		string	true	"API key ID"
+//	@Success		200	{object}	dto.APIKeyResponse
+//	@Failure		400	{object}	map[string]string	"Invalid ID format"
+//	@Failure		401	{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		404	{object}	map[string]string	"API key not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/api-keys/{id} [delete]
+func (mh *merchantHandler) RevokeAPIKey(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.SendErrorResponse(c, 400, "invalid api key id format")
+	}
+
+	key, err := mh.merchantModule.RevokeAPIKey(c.Request().Context(), merchantID, id)
+	if err != nil {
+		mh.logger.Named("MerchantHandler-RevokeAPIKey-Module").Error(c.Request().Context(), "failed to revoke api key", zap.Any("id", id), zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, toAPIKeyResponse(key))
+}
+
+func toAPIKeyResponse(key dto.APIKey) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Active:    key.Active(time.Now()),
+		ExpiresAt: key.ExpiresAt,
+		RevokedAt: key.RevokedAt,
+		CreatedAt: key.CreatedAt,
+	}
+}
+
+func toIssuedAPIKeyResponse(key dto.IssuedAPIKey) dto.IssuedAPIKeyResponse {
+	return dto.IssuedAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(key.APIKey),
+		Key:            key.Key,
+	}
+}
@@ -0,0 +1,184 @@
+package merchant
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/constant/response"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// CreateSigningSecret godoc
+//
+//	@Summary		Create a signing secret
+//	@Description	Issues a secret the calling merchant signs its requests with. Once a merchant has an active signing secret, its payment creation requests, and changes to its signing secrets, must be signed. The secret is only returned in this response.
+//	@Tags			Signing Secrets
+//	@Accept			json
+//	@Produce		json
+//	@Param			secret	body		dto.CreateSigningSecretRequest	false	"Signing secret creation request"
+//	@Success		201		{object}	dto.IssuedSigningSecretResponse
+//	@Failure		400		{object}	map[string]string	"Invalid input"
+//	@Failure		401		{object}	map[string]string	"Missing or invalid API key or request signature"
+//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/signing-secrets [post]
+func (mh *merchantHandler) CreateSigningSecret(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	var req dto.CreateSigningSecretRequest
+	if err := c.Bind(&req); err != nil {
+		mh.logger.Named("MerchantHandler-CreateSigningSecret-Bind").Error(c.Request().Context(), "failed to bind request", zap.Any("error", err.Error()))
+		return response.SendErrorResponse(c, 400, "invalid request payload")
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.SendErrorResponse(c, 400, err.Error())
+	}
+
+	secret, err := mh.merchantModule.CreateSigningSecret(c.Request().Context(), merchantID, req.Name)
+	if err != nil {
+		mh.logger.Named("MerchantHandler-CreateSigningSecret-Module").Error(c.Request().Context(), "failed to create signing secret", zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusCreated, toIssuedSigningSecretResponse(secret))
+}
+
+// ListSigningSecrets godoc
+//
+//	@Summary		List signing secrets
+//	@Description	Lists every signing secret of the calling merchant, newest first, including revoked and expired ones. The secrets themselves are not returned.
+//	@Tags			Signing Secrets
+//	@Produce		json
+//	@Success		200	{array}		dto.SigningSecretResponse
+//	@Failure		401	{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/signing-secrets [get]
+func (mh *merchantHandler) ListSigningSecrets(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	secrets, err := mh.merchantModule.ListSigningSecrets(c.Request().Context(), merchantID)
+	if err != nil {
+		mh.logger.Named("MerchantHandler-ListSigningSecrets-Module").Error(c.Request().Context(), "failed to list signing secrets", zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	resp := make([]dto.SigningSecretResponse, 0, len(secrets))
+	for _, secret := range secrets {
+		resp = append(resp, toSigningSecretResponse(secret))
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, resp)
+}
+
+// RotateSigningSecret godoc
+//
+//	@Summary		Rotate a signing secret
+//	@Description	Issues a replacement for an active signing secret. Signatures made with the old secret keep being accepted for overlap_seconds (default one day, 0 retires it straight away) so clients can switch over. The request must be signed.
+//	@Tags			Signing Secrets
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"Signing secret ID"
+//	@Param			rotate	body		dto.RotateSigningSecretRequest	false	"Rotation options"
+//	@Success		201		{object}	dto.RotateSigningSecretResponse
+//	@Failure		400		{object}	map[string]string	"Invalid input"
+//	@Failure		401		{object}	map[string]string	"Missing or invalid API key or request signature"
+//	@Failure		404		{object}	map[string]string	"Signing secret not found"
+//	@Failure		409		{object}	map[string]string	"Signing secret is revoked or expired"
+//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/signing-secrets/{id}/rotate [post]
+func (mh *merchantHandler) RotateSigningSecret(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.SendErrorResponse(c, 400, "invalid signing secret id format")
+	}
+
+	var req dto.RotateSigningSecretRequest
+	if err := c.Bind(&req); err != nil {
+		mh.logger.Named("MerchantHandler-RotateSigningSecret-Bind").Error(c.Request().Context(), "failed to bind request", zap.Any("error", err.Error()))
+		return response.SendErrorResponse(c, 400, "invalid request payload")
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.SendErrorResponse(c, 400, err.Error())
+	}
+
+	previous, current, err := mh.merchantModule.RotateSigningSecret(c.Request().Context(), merchantID, id, req.Overlap())
+	if err != nil {
+		mh.logger.Named("MerchantHandler-RotateSigningSecret-Module").Error(c.Request().Context(), "failed to rotate signing secret", zap.Any("id", id), zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusCreated, dto.RotateSigningSecretResponse{
+		Previous: toSigningSecretResponse(previous),
+		Current:  toIssuedSigningSecretResponse(current),
+	})
+}
+
+// RevokeSigningSecret godoc
+//
+//	@Summary		Revoke a signing secret
+//	@Description	Stops a signing secret from being accepted straight away. Revoking an already revoked secret is a no-op. The request must be signed. Once no active secret is left, requests no longer need to be signed.
+//	@Tags			Signing Secrets
+//	@Produce		json
+//	@Param			id	path		string	true	"Signing secret ID"
+//	@Success		200	{object}	dto.SigningSecretResponse
+//	@Failure		400	{object}	map[string]string	"Invalid ID format"
+//	@Failure		401	{object}	map[string]string	"Missing or invalid API key or request signature"
+//	@Failure		404	{object}	map[string]string	"Signing secret not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/signing-secrets/{id} [delete]
+func (mh *merchantHandler) RevokeSigningSecret(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.SendErrorResponse(c, 400, "invalid signing secret id format")
+	}
+
+	secret, err := mh.merchantModule.RevokeSigningSecret(c.Request().Context(), merchantID, id)
+	if err != nil {
+		mh.logger.Named("MerchantHandler-RevokeSigningSecret-Module").Error(c.Request().Context(), "failed to revoke signing secret", zap.Any("id", id), zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, toSigningSecretResponse(secret))
+}
+
+func toSigningSecretResponse(secret dto.SigningSecret) dto.SigningSecretResponse {
+	return dto.SigningSecretResponse{
+		ID:        secret.ID,
+		Name:      secret.Name,
+		Active:    secret.Active(time.Now()),
+		ExpiresAt: secret.ExpiresAt,
+		RevokedAt: secret.RevokedAt,
+		CreatedAt: secret.CreatedAt,
+	}
+}
+
+func toIssuedSigningSecretResponse(secret dto.SigningSecret) dto.IssuedSigningSecretResponse {
+	return dto.IssuedSigningSecretResponse{
+		SigningSecretResponse: toSigningSecretResponse(secret),
+		Secret:                secret.Secret,
+	}
+}
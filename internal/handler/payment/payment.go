@@ -32,12 +32,25 @@ func Init(logger logger.Logger, paymentModule module.Payment) handler.Payment {
 //	@Tags			Payments
 //	@Accept			json
 //	@Produce		json
-//	@Param			payment	body		dto.CreatePaymentRequest	true	"Payment creation request"
-//	@Success		201		{object}	dto.CreatePaymentResponse
-//	@Failure		400		{object}	map[string]string	"Invalid input"
-//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Param			Idempotency-Key	header		string						false	"Unique key that makes retries of this request safe"
+//	@Param			X-Signature		header		string						false	"HMAC-SHA256 request signature, required once the merchant has a signing secret"
+//	@Param			X-Timestamp		header		string						false	"Unix time the request was signed at"
+//	@Param			X-Nonce			header		string						false	"Value never reused by the client, required on signed requests"
+//	@Param			payment			body		dto.CreatePaymentRequest	true	"Payment creation request"
+//	@Success		201				{object}	dto.CreatePaymentResponse
+//	@Failure		400				{object}	map[string]string	"Invalid input"
+//	@Failure		401				{object}	map[string]string	"Missing or invalid API key or request signature"
+//	@Failure		409				{object}	map[string]string	"A request with the same idempotency key is in progress"
+//	@Failure		422				{object}	map[string]string	"Idempotency key reused with a different payload"
+//	@Failure		500				{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
 //	@Router			/api/v1/payments [post]
 func (ph *paymentHandler) CreatePayment(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
 	var req dto.CreatePaymentRequest
 	if err := c.Bind(&req); err != nil {
 		ph.logger.Named("PaymentHandler-CreatePayment-Bind").Error(c.Request().Context(), "failed to bind request", zap.Any("error", err.Error()))
@@ -49,7 +62,7 @@ func (ph *paymentHandler) CreatePayment(c echo.Context) error {
 		return response.SendErrorResponse(c, 400, err.Error())
 	}
 
-	payment, err := ph.paymentModule.CreatePayment(c.Request().Context(), req.ToPayment())
+	payment, err := ph.paymentModule.CreatePayment(c.Request().Context(), req.ToPayment(merchantID))
 
 	if err != nil {
 		ph.logger.Named("PaymentHandler-CreatePayment-Module").Error(c.Request().Context(), "failed to create payment", zap.Any("error", err.Error()))
@@ -65,23 +78,30 @@ func (ph *paymentHandler) CreatePayment(c echo.Context) error {
 // GetPaymentDetails godoc
 //
 //	@Summary		Get payment details
-//	@Description	Retrieves details of a payment by its unique ID
+//	@Description	Retrieves details of one of the caller's payments by its unique ID
 //	@Tags			Payments
 //	@Produce		json
 //	@Param			id	path		string	true	"Payment ID"
 //	@Success		200	{object}	dto.GetPaymentDetailsResponse
 //	@Failure		400	{object}	map[string]string	"Invalid ID format"
+//	@Failure		401	{object}	map[string]string	"Missing or invalid API key"
 //	@Failure		404	{object}	map[string]string	"Payment not found"
 //	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
 //	@Router			/api/v1/payments/{id} [get]
 func (ph *paymentHandler) GetPaymentDetails(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
 		return response.SendErrorResponse(c, 400, "invalid payment id format")
 	}
 
-	payment, err := ph.paymentModule.GetPaymentByID(c.Request().Context(), id)
+	payment, err := ph.paymentModule.GetPaymentByID(c.Request().Context(), merchantID, id)
 	if err != nil {
 		ph.logger.Named("PaymentHandler-GetPaymentDetails-Module").Error(c.Request().Context(), "failed to get payment", zap.Any("id", id), zap.Any("error", err.Error()))
 		return response.SendErrorResponseFormated(c, err)
@@ -96,3 +116,101 @@ func (ph *paymentHandler) GetPaymentDetails(c echo.Context) error {
 		CreatedAt: payment.CreatedAt,
 	})
 }
+
+// ListPayments godoc
+//
+//	@Summary		List payments
+//	@Description	Lists the caller's payments newest first using cursor pagination. Pass next_cursor from the previous page as cursor to fetch the next one.
+//	@Tags			Payments
+//	@Produce		json
+//	@Param			status			query		string	false	"Payment status"	Enums(PENDING, PROCESSING, SUCCESS, FAILED, PARTIALLY_REFUNDED, REFUNDED)
+//	@Param			currency		query		string	false	"Payment currency"	Enums(ETB, USD)
+//	@Param			min_amount		query		number	false	"Minimum amount (inclusive)"
+//	@Param			max_amount		query		number	false	"Maximum amount (inclusive)"
+//	@Param			created_from	query		string	false	"Created at or after (RFC3339)"
+//	@Param			created_to		query		string	false	"Created before (RFC3339)"
+//	@Param			reference		query		string	false	"Payment reference"
+//	@Param			cursor			query		string	false	"Cursor returned by the previous page"
+//	@Param			limit			query		int		false	"Page size (1-100, default 20)"
+//	@Success		200				{object}	dto.ListPaymentsResponse
+//	@Failure		400				{object}	map[string]string	"Invalid filter"
+//	@Failure		401				{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		500				{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/payments [get]
+func (ph *paymentHandler) ListPayments(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	var req dto.ListPaymentsRequest
+	if err := c.Bind(&req); err != nil {
+		ph.logger.Named("PaymentHandler-ListPayments-Bind").Error(c.Request().Context(), "failed to bind request", zap.Any("error", err.Error()))
+		return response.SendErrorResponse(c, 400, "invalid query parameters")
+	}
+
+	filter, err := req.ToFilter()
+	if err != nil {
+		ph.logger.Named("PaymentHandler-ListPayments-Validate").Error(c.Request().Context(), "validation failed", zap.Any("error", err.Error()))
+		return response.SendErrorResponse(c, 400, err.Error())
+	}
+	filter.MerchantID = merchantID
+
+	page, err := ph.paymentModule.ListPayments(c.Request().Context(), filter)
+	if err != nil {
+		ph.logger.Named("PaymentHandler-ListPayments-Module").Error(c.Request().Context(), "failed to list payments", zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	resp := dto.ListPaymentsResponse{
+		Payments:   make([]dto.GetPaymentDetailsResponse, 0, len(page.Payments)),
+		NextCursor: page.NextCursor,
+	}
+	for _, payment := range page.Payments {
+		resp.Payments = append(resp.Payments, dto.GetPaymentDetailsResponse{
+			ID:        payment.ID,
+			Amount:    payment.Amount,
+			Currency:  payment.Currency,
+			Reference: payment.Reference,
+			Status:    payment.Status,
+			CreatedAt: payment.CreatedAt,
+		})
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, resp)
+}
+
+// GetPaymentHistory godoc
+//
+//	@Summary		Get payment status history
+//	@Description	Retrieves every status transition of a payment, oldest first, with the actor and reason behind each change
+//	@Tags			Payments
+//	@Produce		json
+//	@Param			id	path		string	true	"Payment ID"
+//	@Success		200	{array}		dto.PaymentStatusHistory
+//	@Failure		400	{object}	map[string]string	"Invalid ID format"
+//	@Failure		401	{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		404	{object}	map[string]string	"Payment not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/payments/{id}/history [get]
+func (ph *paymentHandler) GetPaymentHistory(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.SendErrorResponse(c, 400, "invalid payment id format")
+	}
+
+	history, err := ph.paymentModule.GetPaymentHistory(c.Request().Context(), merchantID, id)
+	if err != nil {
+		ph.logger.Named("PaymentHandler-GetPaymentHistory-Module").Error(c.Request().Context(), "failed to get payment history", zap.Any("id", id), zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, history)
+}
@@ -0,0 +1,126 @@
+package refund
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/constant/response"
+	"github.com/kalom60/cashflow/internal/handler"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type refundHandler struct {
+	logger       logger.Logger
+	refundModule module.Refund
+}
+
+func Init(logger logger.Logger, refundModule module.Refund) handler.Refund {
+	return &refundHandler{
+		logger:       logger,
+		refundModule: refundModule,
+	}
+}
+
+// CreateRefund godoc
+//
+//	@Summary		Refund a payment
+//	@Description	Creates a full or partial refund for a successful payment and processes it asynchronously via RabbitMQ. Omit amount to refund everything still refundable.
+//	@Tags			Refunds
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Payment ID"
+//	@Param			refund	body		dto.CreateRefundRequest	true	"Refund creation request"
+//	@Success		201		{object}	dto.RefundResponse
+//	@Failure		400		{object}	map[string]string	"Invalid input or amount exceeds refundable amount"
+//	@Failure		401		{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		404		{object}	map[string]string	"Payment not found"
+//	@Failure		409		{object}	map[string]string	"Payment cannot be refunded"
+//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/payments/{id}/refunds [post]
+func (rh *refundHandler) CreateRefund(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.SendErrorResponse(c, 400, "invalid payment id format")
+	}
+
+	var req dto.CreateRefundRequest
+	if err := c.Bind(&req); err != nil {
+		rh.logger.Named("RefundHandler-CreateRefund-Bind").Error(c.Request().Context(), "failed to bind request", zap.Any("error", err.Error()))
+		return response.SendErrorResponse(c, 400, "invalid request payload")
+	}
+
+	if err := req.Validate(); err != nil {
+		rh.logger.Named("RefundHandler-CreateRefund-Validate").Error(c.Request().Context(), "validation failed", zap.Any("error", err.Error()))
+		return response.SendErrorResponse(c, 400, err.Error())
+	}
+
+	refund, err := rh.refundModule.CreateRefund(c.Request().Context(), merchantID, req.ToRefund(paymentID))
+	if err != nil {
+		rh.logger.Named("RefundHandler-CreateRefund-Module").Error(c.Request().Context(), "failed to create refund", zap.Any("payment_id", paymentID), zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusCreated, toRefundResponse(refund))
+}
+
+// GetPaymentRefunds godoc
+//
+//	@Summary		List payment refunds
+//	@Description	Retrieves every refund issued against a payment, oldest first
+//	@Tags			Refunds
+//	@Produce		json
+//	@Param			id	path		string	true	"Payment ID"
+//	@Success		200	{array}		dto.RefundResponse
+//	@Failure		400	{object}	map[string]string	"Invalid ID format"
+//	@Failure		401	{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		404	{object}	map[string]string	"Payment not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/payments/{id}/refunds [get]
+func (rh *refundHandler) GetPaymentRefunds(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.SendErrorResponse(c, 400, "invalid payment id format")
+	}
+
+	refunds, err := rh.refundModule.GetRefundsByPaymentID(c.Request().Context(), merchantID, paymentID)
+	if err != nil {
+		rh.logger.Named("RefundHandler-GetPaymentRefunds-Module").Error(c.Request().Context(), "failed to get refunds", zap.Any("payment_id", paymentID), zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	resp := make([]dto.RefundResponse, 0, len(refunds))
+	for _, refund := range refunds {
+		resp = append(resp, toRefundResponse(refund))
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, resp)
+}
+
+func toRefundResponse(refund dto.Refund) dto.RefundResponse {
+	return dto.RefundResponse{
+		ID:            refund.ID,
+		PaymentID:     refund.PaymentID,
+		Amount:        refund.Amount,
+		Currency:      refund.Currency,
+		Reason:        refund.Reason,
+		Status:        refund.Status,
+		FailureReason: refund.FailureReason,
+		CreatedAt:     refund.CreatedAt,
+	}
+}
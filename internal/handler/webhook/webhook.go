@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/constant/response"
+	"github.com/kalom60/cashflow/internal/handler"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type webhookHandler struct {
+	logger        logger.Logger
+	webhookModule module.Webhook
+}
+
+func Init(logger logger.Logger, webhookModule module.Webhook) handler.Webhook {
+	return &webhookHandler{
+		logger:        logger,
+		webhookModule: webhookModule,
+	}
+}
+
+// CreateWebhookEndpoint godoc
+//
+//	@Summary		Register a webhook endpoint
+//	@Description	Registers a URL the calling merchant receives events on. Every delivery is signed with the returned secret the same way requests to the gateway are signed, see X-Signature, X-Timestamp and X-Nonce. The secret is only returned in this response. The request must be signed if the merchant has an active signing secret.
+//	@Tags			Webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			endpoint	body		dto.CreateWebhookEndpointRequest	true	"Webhook endpoint registration request"
+//	@Success		201			{object}	dto.CreateWebhookEndpointResponse
+//	@Failure		400			{object}	map[string]string	"Invalid input"
+//	@Failure		401			{object}	map[string]string	"Missing or invalid API key or request signature"
+//	@Failure		500			{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/webhooks [post]
+func (wh *webhookHandler) CreateWebhookEndpoint(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	var req dto.CreateWebhookEndpointRequest
+	if err := c.Bind(&req); err != nil {
+		wh.logger.Named("WebhookHandler-CreateWebhookEndpoint-Bind").Error(c.Request().Context(), "failed to bind request", zap.Any("error", err.Error()))
+		return response.SendErrorResponse(c, 400, "invalid request payload")
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.SendErrorResponse(c, 400, err.Error())
+	}
+
+	endpoint, err := wh.webhookModule.CreateWebhookEndpoint(c.Request().Context(), req.ToWebhookEndpoint(merchantID))
+	if err != nil {
+		wh.logger.Named("WebhookHandler-CreateWebhookEndpoint-Module").Error(c.Request().Context(), "failed to create webhook endpoint", zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusCreated, dto.CreateWebhookEndpointResponse{
+		WebhookEndpointResponse: toWebhookEndpointResponse(endpoint),
+		Secret:                  endpoint.Secret,
+	})
+}
+
+// ListWebhookEndpoints godoc
+//
+//	@Summary		List webhook endpoints
+//	@Description	Lists the webhook endpoints of the calling merchant, newest first. The secrets are not returned.
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Success		200	{array}		dto.WebhookEndpointResponse
+//	@Failure		401	{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/webhooks [get]
+func (wh *webhookHandler) ListWebhookEndpoints(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	endpoints, err := wh.webhookModule.ListWebhookEndpoints(c.Request().Context(), merchantID)
+	if err != nil {
+		wh.logger.Named("WebhookHandler-ListWebhookEndpoints-Module").Error(c.Request().Context(), "failed to list webhook endpoints", zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	resp := make([]dto.WebhookEndpointResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		resp = append(resp, toWebhookEndpointResponse(endpoint))
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, resp)
+}
+
+// DeleteWebhookEndpoint godoc
+//
+//	@Summary		Delete a webhook endpoint
+//	@Description	Stops sending events to an endpoint and deletes its delivery log, including deliveries that were still being retried. The request must be signed if the merchant has an active signing secret.
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Param			id	path		string	true	"Webhook endpoint ID"
+//	@Success		200	{object}	dto.DeleteWebhookEndpointResponse
+//	@Failure		400	{object}	map[string]string	"Invalid ID format"
+//	@Failure		401	{object}	map[string]string	"Missing or invalid API key or request signature"
+//	@Failure		404	{object}	map[string]string	"Webhook endpoint not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/webhooks/{id} [delete]
+func (wh *webhookHandler) DeleteWebhookEndpoint(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.SendErrorResponse(c, 400, "invalid webhook endpoint id format")
+	}
+
+	if err := wh.webhookModule.DeleteWebhookEndpoint(c.Request().Context(), merchantID, id); err != nil {
+		wh.logger.Named("WebhookHandler-DeleteWebhookEndpoint-Module").Error(c.Request().Context(), "failed to delete webhook endpoint", zap.Any("id", id), zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, dto.DeleteWebhookEndpointResponse{ID: id, Deleted: true})
+}
+
+// ListWebhookDeliveries godoc
+//
+//	@Summary		List webhook deliveries
+//	@Description	Returns the most recent deliveries to a webhook endpoint, newest first, with the outcome of their latest attempt.
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Param			id		path		string	true	"Webhook endpoint ID"
+//	@Param			status	query		string	false	"Delivery status"	Enums(PENDING, SUCCEEDED, FAILED)
+//	@Param			limit	query		int		false	"Deliveries to return (1-100, default 20)"
+//	@Success		200		{array}		dto.WebhookDelivery
+//	@Failure		400		{object}	map[string]string	"Invalid input"
+//	@Failure		401		{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		404		{object}	map[string]string	"Webhook endpoint not found"
+//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/webhooks/{id}/deliveries [get]
+func (wh *webhookHandler) ListWebhookDeliveries(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.SendErrorResponse(c, 400, "invalid webhook endpoint id format")
+	}
+
+	var req dto.ListWebhookDeliveriesRequest
+	if err := c.Bind(&req); err != nil {
+		wh.logger.Named("WebhookHandler-ListWebhookDeliveries-Bind").Error(c.Request().Context(), "failed to bind request", zap.Any("error", err.Error()))
+		return response.SendErrorResponse(c, 400, "invalid query parameters")
+	}
+
+	filter, err := req.ToFilter()
+	if err != nil {
+		return response.SendErrorResponse(c, 400, err.Error())
+	}
+	filter.EndpointID = id
+
+	deliveries, err := wh.webhookModule.ListWebhookDeliveries(c.Request().Context(), merchantID, filter)
+	if err != nil {
+		wh.logger.Named("WebhookHandler-ListWebhookDeliveries-Module").Error(c.Request().Context(), "failed to list webhook deliveries", zap.Any("endpoint_id", id), zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusOK, deliveries)
+}
+
+// RedeliverWebhookDelivery godoc
+//
+//	@Summary		Redeliver a webhook
+//	@Description	Sends a delivery that succeeded or was given up on again, with the same event ID, and retries it for another 72 hours if it fails. Deliveries that are still being retried cannot be redelivered.
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Param			id	path		string	true	"Webhook delivery ID"
+//	@Success		202	{object}	dto.WebhookDelivery
+//	@Failure		400	{object}	map[string]string	"Invalid ID format"
+//	@Failure		401	{object}	map[string]string	"Missing or invalid API key"
+//	@Failure		404	{object}	map[string]string	"Webhook delivery not found"
+//	@Failure		409	{object}	map[string]string	"Webhook delivery is still being retried"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/api/v1/webhook-deliveries/{id}/redeliver [post]
+func (wh *webhookHandler) RedeliverWebhookDelivery(c echo.Context) error {
+	merchantID, ok := dto.MerchantIDFromContext(c.Request().Context())
+	if !ok {
+		return response.SendErrorResponse(c, http.StatusUnauthorized, "missing api key")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return response.SendErrorResponse(c, 400, "invalid webhook delivery id format")
+	}
+
+	delivery, err := wh.webhookModule.RedeliverWebhookDelivery(c.Request().Context(), merchantID, id)
+	if err != nil {
+		wh.logger.Named("WebhookHandler-RedeliverWebhookDelivery-Module").Error(c.Request().Context(), "failed to redeliver webhook", zap.Any("id", id), zap.Any("error", err.Error()))
+		return response.SendErrorResponseFormated(c, err)
+	}
+
+	return response.SendSuccessResponse(c, http.StatusAccepted, delivery)
+}
+
+func toWebhookEndpointResponse(endpoint dto.WebhookEndpoint) dto.WebhookEndpointResponse {
+	return dto.WebhookEndpointResponse{
+		ID:         endpoint.ID,
+		URL:        endpoint.URL,
+		EventTypes: endpoint.EventTypes,
+		CreatedAt:  endpoint.CreatedAt,
+	}
+}
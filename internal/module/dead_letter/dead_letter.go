@@ -0,0 +1,115 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/messaging"
+	"go.uber.org/zap"
+)
+
+type deadLetterModule struct {
+	logger    logger.Logger
+	msgClient messaging.MessagingClient
+}
+
+func Init(logger logger.Logger, msgClient messaging.MessagingClient) module.DeadLetter {
+	return &deadLetterModule{
+		logger:    logger,
+		msgClient: msgClient,
+	}
+}
+
+// deadLetterQueues maps the queue names used by the admin API to the
+// dead-letter queues on the broker.
+var deadLetterQueues = map[string]messaging.DeadLetterQueue{
+	dto.DeadLetterQueuePayments: messaging.PaymentDeadLetters,
+	dto.DeadLetterQueueRefunds:  messaging.RefundDeadLetters,
+}
+
+func lookupQueue(queue string) (messaging.DeadLetterQueue, error) {
+	dlq, ok := deadLetterQueues[queue]
+	if !ok {
+		return messaging.DeadLetterQueue{}, customErrors.ErrResourceNotFound.New("unknown dead-letter queue: %s", queue)
+	}
+
+	return dlq, nil
+}
+
+func (dm *deadLetterModule) ListDeadLetters(ctx context.Context, queue string, limit int) ([]dto.DeadLetter, error) {
+	dlq, err := lookupQueue(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters, err := dm.msgClient.PeekDeadLetters(ctx, dlq, limit)
+	if err != nil {
+		dm.logger.Named("DeadLetterModule-ListDeadLetters").Error(ctx, "failed to peek dead letters", zap.Error(err))
+		return nil, customErrors.ErrBrokerUnavailable.New("failed to read dead-letter queue")
+	}
+
+	resp := make([]dto.DeadLetter, 0, len(deadLetters))
+	for _, deadLetter := range deadLetters {
+		resp = append(resp, toDeadLetter(deadLetter))
+	}
+
+	return resp, nil
+}
+
+func (dm *deadLetterModule) ReplayDeadLetters(ctx context.Context, queue string, limit int) (int, error) {
+	dlq, err := lookupQueue(queue)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed, err := dm.msgClient.ReplayDeadLetters(ctx, dlq, limit)
+	if err != nil {
+		dm.logger.Named("DeadLetterModule-ReplayDeadLetters").Error(ctx, "failed to replay dead letters", zap.Int("replayed", replayed), zap.Error(err))
+		return replayed, customErrors.ErrBrokerUnavailable.New("replayed %d messages before failing", replayed)
+	}
+
+	dm.logger.Info(ctx, "replayed dead letters", zap.String("queue", dlq.Name), zap.Int("replayed", replayed))
+	return replayed, nil
+}
+
+func (dm *deadLetterModule) PurgeDeadLetters(ctx context.Context, queue string) (int, error) {
+	dlq, err := lookupQueue(queue)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := dm.msgClient.PurgeDeadLetters(ctx, dlq)
+	if err != nil {
+		dm.logger.Named("DeadLetterModule-PurgeDeadLetters").Error(ctx, "failed to purge dead letters", zap.Error(err))
+		return 0, customErrors.ErrBrokerUnavailable.New("failed to purge dead-letter queue")
+	}
+
+	dm.logger.Info(ctx, "purged dead letters", zap.String("queue", dlq.Name), zap.Int("purged", purged))
+	return purged, nil
+}
+
+func toDeadLetter(deadLetter messaging.DeadLetter) dto.DeadLetter {
+	resp := dto.DeadLetter{
+		MessageID: deadLetter.MessageID,
+		Body:      deadLetter.Body,
+		Queue:     deadLetter.Queue,
+		Reason:    deadLetter.Reason,
+		Count:     deadLetter.Count,
+	}
+
+	// Keep the response valid JSON even if the poisoned body is not
+	if !json.Valid(resp.Body) {
+		resp.Body, _ = json.Marshal(string(deadLetter.Body))
+	}
+
+	if !deadLetter.DeadLetteredAt.IsZero() {
+		deadLetteredAt := deadLetter.DeadLetteredAt
+		resp.DeadLetteredAt = &deadLetteredAt
+	}
+
+	return resp
+}
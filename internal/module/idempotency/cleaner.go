@@ -0,0 +1,77 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/platform/logger"
+	"go.uber.org/zap"
+)
+
+const DefaultCleanupInterval = time.Hour
+
+// Cleaner deletes idempotency keys once their retention has passed, so the
+// table does not grow forever. Begin already ignores expired keys, the
+// cleaner only reclaims the space.
+type Cleaner struct {
+	logger             logger.Logger
+	idempotencyStorage storage.Idempotency
+	retention          time.Duration
+	interval           time.Duration
+	done               chan struct{}
+}
+
+func NewCleaner(logger logger.Logger, idempotencyStorage storage.Idempotency, retention, interval time.Duration) *Cleaner {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	if interval <= 0 {
+		interval = DefaultCleanupInterval
+	}
+
+	return &Cleaner{
+		logger:             logger,
+		idempotencyStorage: idempotencyStorage,
+		retention:          retention,
+		interval:           interval,
+		done:               make(chan struct{}),
+	}
+}
+
+// Start deletes expired keys every interval until ctx is cancelled.
+func (c *Cleaner) Start(ctx context.Context) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.logger.Info(ctx, "Starting Idempotency Key Cleaner...")
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Info(ctx, "Stopping Idempotency Key Cleaner...")
+			return
+		case <-ticker.C:
+			c.clean(ctx)
+		}
+	}
+}
+
+// Done is closed once Start has returned.
+func (c *Cleaner) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Cleaner) clean(ctx context.Context) {
+	deleted, err := c.idempotencyStorage.DeleteExpiredIdempotencyKeys(ctx, time.Now().Add(-c.retention))
+	if err != nil {
+		c.logger.Named("IdempotencyCleaner-Clean").Error(ctx, "failed to delete expired idempotency keys", zap.Error(err))
+		return
+	}
+
+	if deleted > 0 {
+		c.logger.Info(ctx, "deleted expired idempotency keys", zap.Int64("count", deleted))
+	}
+}
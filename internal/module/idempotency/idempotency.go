@@ -0,0 +1,83 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/platform/logger"
+)
+
+const (
+	DefaultLockTimeout = time.Minute
+	DefaultRetention   = 24 * time.Hour
+)
+
+type idempotencyModule struct {
+	logger             logger.Logger
+	idempotencyStorage storage.Idempotency
+	lockTimeout        time.Duration
+	retention          time.Duration
+}
+
+// Init returns the idempotency module. An IN_PROGRESS key that has not been
+// completed within lockTimeout is considered abandoned and can be reclaimed.
+// A key expires retention after it was first used, and can then be used for
+// a new request.
+func Init(logger logger.Logger, idempotencyStorage storage.Idempotency, lockTimeout, retention time.Duration) module.Idempotency {
+	if lockTimeout <= 0 {
+		lockTimeout = DefaultLockTimeout
+	}
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+
+	return &idempotencyModule{
+		logger:             logger,
+		idempotencyStorage: idempotencyStorage,
+		lockTimeout:        lockTimeout,
+		retention:          retention,
+	}
+}
+
+// Begin claims key for the request identified by requestHash. The returned
+// record is IN_PROGRESS when the caller owns the key and should run the
+// request, or COMPLETED when the stored response should be replayed.
+func (im *idempotencyModule) Begin(ctx context.Context, key, requestHash string) (dto.IdempotencyKey, error) {
+	record, err := im.idempotencyStorage.CreateIdempotencyKey(ctx, key, requestHash, time.Now().Add(-im.retention))
+	if err == nil {
+		return record, nil
+	}
+	if !errorx.IsOfType(err, customErrors.ErrIdempotencyKeyInUse) {
+		return dto.IdempotencyKey{}, err
+	}
+
+	existing, err := im.idempotencyStorage.GetIdempotencyKey(ctx, key)
+	if err != nil {
+		return dto.IdempotencyKey{}, err
+	}
+
+	if existing.RequestHash != requestHash {
+		return dto.IdempotencyKey{}, customErrors.ErrIdempotencyKeyMismatch.New("idempotency key was already used with a different request payload")
+	}
+
+	if existing.Status == dto.IdempotencyStatusCompleted {
+		return existing, nil
+	}
+
+	return im.idempotencyStorage.ReclaimIdempotencyKey(ctx, key, time.Now().Add(-im.lockTimeout))
+}
+
+func (im *idempotencyModule) Complete(ctx context.Context, key string, responseCode int, responseBody []byte) error {
+	return im.idempotencyStorage.CompleteIdempotencyKey(ctx, key, responseCode, responseBody)
+}
+
+// Release forgets key so that the client can retry a request that failed
+// without producing a result worth replaying.
+func (im *idempotencyModule) Release(ctx context.Context, key string) error {
+	return im.idempotencyStorage.DeleteIdempotencyKey(ctx, key)
+}
@@ -0,0 +1,133 @@
+package idempotency_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joomcode/errorx"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/module"
+	idempotencyModule "github.com/kalom60/cashflow/internal/module/idempotency"
+	"github.com/kalom60/cashflow/internal/storage"
+	idempotencyStorage "github.com/kalom60/cashflow/internal/storage/idempotency"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/tests/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ctx     context.Context
+	iStore  storage.Idempotency
+	iModule module.Idempotency
+	log     logger.Logger
+
+	key = uuid.NewString()
+)
+
+func TestMain(m *testing.M) {
+	ctx = context.Background()
+	testDB := testutils.SetupTestDB()
+	log = testutils.NewTestLogger()
+	iStore = idempotencyStorage.Init(log, &testDB)
+	iModule = idempotencyModule.Init(log, iStore, time.Second, time.Hour)
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestBeginNewKey(t *testing.T) {
+	record, err := iModule.Begin(ctx, key, "hash-a")
+	assert.NoError(t, err)
+	assert.Equal(t, dto.IdempotencyStatusInProgress, record.Status)
+}
+
+func TestBeginWhileInProgress(t *testing.T) {
+	_, err := iModule.Begin(ctx, key, "hash-a")
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrIdempotencyKeyInUse))
+}
+
+func TestBeginWithDifferentPayload(t *testing.T) {
+	_, err := iModule.Begin(ctx, key, "hash-b")
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrIdempotencyKeyMismatch))
+}
+
+func TestCompleteAndReplay(t *testing.T) {
+	// Replayed byte for byte, key order and whitespace included
+	body := []byte(`{"data": {"status":"PENDING", "id":"1"}}` + "\n")
+	assert.NoError(t, iModule.Complete(ctx, key, 201, body))
+
+	record, err := iModule.Begin(ctx, key, "hash-a")
+	assert.NoError(t, err)
+	assert.Equal(t, dto.IdempotencyStatusCompleted, record.Status)
+	assert.Equal(t, 201, record.ResponseCode)
+	assert.Equal(t, body, record.ResponseBody)
+}
+
+func TestReclaimAbandonedKey(t *testing.T) {
+	abandoned := uuid.NewString()
+	_, err := iModule.Begin(ctx, abandoned, "hash-a")
+	assert.NoError(t, err)
+
+	time.Sleep(1500 * time.Millisecond)
+
+	record, err := iModule.Begin(ctx, abandoned, "hash-a")
+	assert.NoError(t, err)
+	assert.Equal(t, dto.IdempotencyStatusInProgress, record.Status)
+}
+
+func TestReleaseAllowsRetry(t *testing.T) {
+	released := uuid.NewString()
+	_, err := iModule.Begin(ctx, released, "hash-a")
+	assert.NoError(t, err)
+	assert.NoError(t, iModule.Release(ctx, released))
+
+	_, err = iModule.Begin(ctx, released, "hash-b")
+	assert.NoError(t, err)
+}
+
+func TestBeginAfterRetention(t *testing.T) {
+	expiring := idempotencyModule.Init(log, iStore, time.Second, 100*time.Millisecond)
+
+	expired := uuid.NewString()
+	_, err := expiring.Begin(ctx, expired, "hash-a")
+	assert.NoError(t, err)
+	assert.NoError(t, expiring.Complete(ctx, expired, 201, []byte(`{"id":"1"}`)))
+
+	time.Sleep(200 * time.Millisecond)
+
+	// An expired key is free for a new request, whatever its payload
+	record, err := expiring.Begin(ctx, expired, "hash-b")
+	assert.NoError(t, err)
+	assert.Equal(t, dto.IdempotencyStatusInProgress, record.Status)
+	assert.Empty(t, record.ResponseBody)
+}
+
+func TestCleanerDeletesExpiredKeys(t *testing.T) {
+	expired, kept := uuid.NewString(), uuid.NewString()
+	_, err := iModule.Begin(ctx, expired, "hash-a")
+	assert.NoError(t, err)
+
+	time.Sleep(600 * time.Millisecond)
+
+	_, err = iModule.Begin(ctx, kept, "hash-a")
+	assert.NoError(t, err)
+
+	cleanerCtx, cancel := context.WithCancel(ctx)
+	cleaner := idempotencyModule.NewCleaner(log, iStore, 500*time.Millisecond, 10*time.Millisecond)
+	go cleaner.Start(cleanerCtx)
+
+	assert.Eventually(t, func() bool {
+		_, err := iStore.GetIdempotencyKey(ctx, expired)
+		return errorx.IsOfType(err, customErrors.ErrResourceNotFound)
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-cleaner.Done()
+
+	_, err = iStore.GetIdempotencyKey(ctx, kept)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,76 @@
+package merchant
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// API keys look like cf_<prefix>_<secret>. The prefix is stored in the clear
+// to find the key, the secret is only ever stored as part of the key's hash.
+const (
+	keyScheme    = "cf_"
+	prefixBytes  = 6
+	secretBytes  = 24
+	prefixLength = prefixBytes * 2
+	secretLength = secretBytes * 2
+
+	signingSecretScheme = "cfsig_"
+	signingSecretBytes  = 32
+)
+
+// generateKey returns a new random key and its prefix.
+func generateKey() (string, string, error) {
+	buf := make([]byte, prefixBytes+secretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	prefix := hex.EncodeToString(buf[:prefixBytes])
+	secret := hex.EncodeToString(buf[prefixBytes:])
+
+	return keyScheme + prefix + "_" + secret, prefix, nil
+}
+
+// generateSigningSecret returns a new random secret for signing requests.
+func generateSigningSecret() (string, error) {
+	buf := make([]byte, signingSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return signingSecretScheme + hex.EncodeToString(buf), nil
+}
+
+// parseKey returns the prefix of key, or false if key is not shaped like one
+// of our keys.
+func parseKey(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, keyScheme)
+	if !ok {
+		return "", false
+	}
+
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != prefixLength || len(secret) != secretLength {
+		return "", false
+	}
+
+	if !isHex(prefix) || !isHex(secret) {
+		return "", false
+	}
+
+	return prefix, true
+}
+
+// hashKey is a plain SHA-256: keys carry 192 bits of randomness, so a slow
+// password hash would only add latency to every request.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,56 @@
+package merchant
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateKey(t *testing.T) {
+	key, prefix, err := generateKey()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(key, keyScheme+prefix+"_"))
+
+	parsed, ok := parseKey(key)
+	assert.True(t, ok)
+	assert.Equal(t, prefix, parsed)
+
+	other, _, err := generateKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+	assert.NotEqual(t, hashKey(key), hashKey(other))
+}
+
+func TestParseKeyRejectsMalformedKeys(t *testing.T) {
+	key, _, err := generateKey()
+	require.NoError(t, err)
+
+	for _, candidate := range []string{
+		"",
+		"cf_",
+		strings.TrimPrefix(key, keyScheme),
+		"xx_" + strings.TrimPrefix(key, keyScheme),
+		key[:len(key)-1],
+		key + "0",
+		strings.Replace(key, "_", "-", 2),
+		key[:len(key)-1] + "z",
+	} {
+		_, ok := parseKey(candidate)
+		assert.False(t, ok, candidate)
+	}
+}
+
+func TestGenerateSigningSecret(t *testing.T) {
+	secret, err := generateSigningSecret()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(secret, signingSecretScheme))
+	assert.Len(t, secret, len(signingSecretScheme)+signingSecretBytes*2)
+
+	other, err := generateSigningSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
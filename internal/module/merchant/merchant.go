@@ -0,0 +1,142 @@
+package merchant
+
+import (
+	"context"
+	"crypto/subtle"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joomcode/errorx"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/platform/logger"
+	"go.uber.org/zap"
+)
+
+type merchantModule struct {
+	logger          logger.Logger
+	merchantStorage storage.Merchant
+}
+
+func Init(logger logger.Logger, merchantStorage storage.Merchant) module.Merchant {
+	return &merchantModule{
+		logger:          logger,
+		merchantStorage: merchantStorage,
+	}
+}
+
+// CreateMerchant creates a merchant and issues its first API key.
+func (mm *merchantModule) CreateMerchant(ctx context.Context, req dto.Merchant) (dto.Merchant, dto.IssuedAPIKey, error) {
+	key, issued, err := mm.newKey(ctx, uuid.Nil, "default")
+	if err != nil {
+		return dto.Merchant{}, dto.IssuedAPIKey{}, err
+	}
+
+	merchant, stored, err := mm.merchantStorage.CreateMerchant(ctx, req, issued)
+	if err != nil {
+		return dto.Merchant{}, dto.IssuedAPIKey{}, err
+	}
+
+	return merchant, dto.IssuedAPIKey{APIKey: stored, Key: key}, nil
+}
+
+// Authenticate returns the API key matching key if it is still active.
+func (mm *merchantModule) Authenticate(ctx context.Context, key string) (dto.APIKey, error) {
+	prefix, ok := parseKey(key)
+	if !ok {
+		return dto.APIKey{}, customErrors.ErrUnauthorized.New("invalid api key")
+	}
+
+	stored, err := mm.merchantStorage.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errorx.IsOfType(err, customErrors.ErrResourceNotFound) {
+			return dto.APIKey{}, customErrors.ErrUnauthorized.New("invalid api key")
+		}
+		return dto.APIKey{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashKey(key)), []byte(stored.KeyHash)) != 1 {
+		return dto.APIKey{}, customErrors.ErrUnauthorized.New("invalid api key")
+	}
+
+	// The caller has proven they hold the key, so it is safe to say why it no
+	// longer works
+	now := time.Now()
+	if stored.RevokedAt != nil {
+		return dto.APIKey{}, customErrors.ErrUnauthorized.New("api key has been revoked")
+	}
+	if !stored.Active(now) {
+		return dto.APIKey{}, customErrors.ErrUnauthorized.New("api key has expired")
+	}
+
+	return stored, nil
+}
+
+func (mm *merchantModule) CreateAPIKey(ctx context.Context, merchantID uuid.UUID, name string) (dto.IssuedAPIKey, error) {
+	key, issued, err := mm.newKey(ctx, merchantID, name)
+	if err != nil {
+		return dto.IssuedAPIKey{}, err
+	}
+
+	stored, err := mm.merchantStorage.CreateAPIKey(ctx, issued)
+	if err != nil {
+		return dto.IssuedAPIKey{}, err
+	}
+
+	return dto.IssuedAPIKey{APIKey: stored, Key: key}, nil
+}
+
+func (mm *merchantModule) ListAPIKeys(ctx context.Context, merchantID uuid.UUID) ([]dto.APIKey, error) {
+	keys, err := mm.merchantStorage.ListAPIKeys(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RotateAPIKey issues a replacement for the key id with the same name. The
+// old key keeps working for overlap so clients can move over to the new one.
+func (mm *merchantModule) RotateAPIKey(ctx context.Context, merchantID, id uuid.UUID, overlap time.Duration) (dto.APIKey, dto.IssuedAPIKey, error) {
+	// The new key takes over the old one's name when it is stored
+	key, next, err := mm.newKey(ctx, merchantID, "")
+	if err != nil {
+		return dto.APIKey{}, dto.IssuedAPIKey{}, err
+	}
+
+	previous, stored, err := mm.merchantStorage.RotateAPIKey(ctx, merchantID, id, next, overlap)
+	if err != nil {
+		return dto.APIKey{}, dto.IssuedAPIKey{}, err
+	}
+
+	return previous, dto.IssuedAPIKey{APIKey: stored, Key: key}, nil
+}
+
+func (mm *merchantModule) RevokeAPIKey(ctx context.Context, merchantID, id uuid.UUID) (dto.APIKey, error) {
+	key, err := mm.merchantStorage.RevokeAPIKey(ctx, merchantID, id)
+	if err != nil {
+		return dto.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// newKey generates a key for merchantID and returns it with the record to
+// store for it.
+func (mm *merchantModule) newKey(ctx context.Context, merchantID uuid.UUID, name string) (string, dto.APIKey, error) {
+	key, prefix, err := generateKey()
+	if err != nil {
+		mm.logger.Named("MerchantModule-NewKey").Error(ctx, "failed to generate api key", zap.Error(err))
+		return "", dto.APIKey{}, customErrors.ErrInternalServerError.New("failed to generate api key")
+	}
+
+	return key, dto.APIKey{
+		MerchantID: merchantID,
+		Name:       name,
+		Prefix:     prefix,
+		KeyHash:    hashKey(key),
+		CreatedAt:  time.Now(),
+	}, nil
+}
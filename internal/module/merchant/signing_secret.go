@@ -0,0 +1,80 @@
+package merchant
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"go.uber.org/zap"
+)
+
+// CreateSigningSecret issues a secret merchantID signs its requests with.
+// From then on the merchant's requests to signed routes must carry a valid
+// signature.
+func (mm *merchantModule) CreateSigningSecret(ctx context.Context, merchantID uuid.UUID, name string) (dto.SigningSecret, error) {
+	secret, err := mm.newSigningSecret(ctx, merchantID, name)
+	if err != nil {
+		return dto.SigningSecret{}, err
+	}
+
+	return mm.merchantStorage.CreateSigningSecret(ctx, secret)
+}
+
+func (mm *merchantModule) ListSigningSecrets(ctx context.Context, merchantID uuid.UUID) ([]dto.SigningSecret, error) {
+	secrets, err := mm.merchantStorage.ListSigningSecrets(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// ActiveSigningSecrets returns the secrets a signature from merchantID may
+// currently be made with. There is more than one while a rotation overlaps.
+func (mm *merchantModule) ActiveSigningSecrets(ctx context.Context, merchantID uuid.UUID) ([]dto.SigningSecret, error) {
+	secrets, err := mm.merchantStorage.ListActiveSigningSecrets(ctx, merchantID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// RotateSigningSecret issues a replacement for the secret id with the same
+// name. The old secret keeps verifying for overlap so the client can move
+// over to the new one.
+func (mm *merchantModule) RotateSigningSecret(ctx context.Context, merchantID, id uuid.UUID, overlap time.Duration) (dto.SigningSecret, dto.SigningSecret, error) {
+	// The new secret takes over the old one's name when it is stored
+	next, err := mm.newSigningSecret(ctx, merchantID, "")
+	if err != nil {
+		return dto.SigningSecret{}, dto.SigningSecret{}, err
+	}
+
+	return mm.merchantStorage.RotateSigningSecret(ctx, merchantID, id, next, overlap)
+}
+
+func (mm *merchantModule) RevokeSigningSecret(ctx context.Context, merchantID, id uuid.UUID) (dto.SigningSecret, error) {
+	secret, err := mm.merchantStorage.RevokeSigningSecret(ctx, merchantID, id)
+	if err != nil {
+		return dto.SigningSecret{}, err
+	}
+
+	return secret, nil
+}
+
+func (mm *merchantModule) newSigningSecret(ctx context.Context, merchantID uuid.UUID, name string) (dto.SigningSecret, error) {
+	secret, err := generateSigningSecret()
+	if err != nil {
+		mm.logger.Named("MerchantModule-NewSigningSecret").Error(ctx, "failed to generate signing secret", zap.Error(err))
+		return dto.SigningSecret{}, customErrors.ErrInternalServerError.New("failed to generate signing secret")
+	}
+
+	return dto.SigningSecret{
+		MerchantID: merchantID,
+		Name:       name,
+		Secret:     secret,
+		CreatedAt:  time.Now(),
+	}, nil
+}
@@ -2,6 +2,7 @@ package module
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kalom60/cashflow/internal/constant/dto"
@@ -9,5 +10,48 @@ import (
 
 type Payment interface {
 	CreatePayment(ctx context.Context, req dto.Payment) (dto.Payment, error)
-	GetPaymentByID(ctx context.Context, id uuid.UUID) (dto.Payment, error)
+	GetPaymentByID(ctx context.Context, merchantID, id uuid.UUID) (dto.Payment, error)
+	ListPayments(ctx context.Context, filter dto.PaymentFilter) (dto.PaymentPage, error)
+	GetPaymentHistory(ctx context.Context, merchantID, id uuid.UUID) ([]dto.PaymentStatusHistory, error)
+}
+
+type Refund interface {
+	CreateRefund(ctx context.Context, merchantID uuid.UUID, req dto.Refund) (dto.Refund, error)
+	GetRefundsByPaymentID(ctx context.Context, merchantID, paymentID uuid.UUID) ([]dto.Refund, error)
+}
+
+type Idempotency interface {
+	Begin(ctx context.Context, key, requestHash string) (dto.IdempotencyKey, error)
+	Complete(ctx context.Context, key string, responseCode int, responseBody []byte) error
+	Release(ctx context.Context, key string) error
+}
+
+type DeadLetter interface {
+	ListDeadLetters(ctx context.Context, queue string, limit int) ([]dto.DeadLetter, error)
+	ReplayDeadLetters(ctx context.Context, queue string, limit int) (int, error)
+	PurgeDeadLetters(ctx context.Context, queue string) (int, error)
+}
+
+type Merchant interface {
+	CreateMerchant(ctx context.Context, req dto.Merchant) (dto.Merchant, dto.IssuedAPIKey, error)
+	Authenticate(ctx context.Context, key string) (dto.APIKey, error)
+	CreateAPIKey(ctx context.Context, merchantID uuid.UUID, name string) (dto.IssuedAPIKey, error)
+	ListAPIKeys(ctx context.Context, merchantID uuid.UUID) ([]dto.APIKey, error)
+	RotateAPIKey(ctx context.Context, merchantID, id uuid.UUID, overlap time.Duration) (dto.APIKey, dto.IssuedAPIKey, error)
+	RevokeAPIKey(ctx context.Context, merchantID, id uuid.UUID) (dto.APIKey, error)
+
+	CreateSigningSecret(ctx context.Context, merchantID uuid.UUID, name string) (dto.SigningSecret, error)
+	ListSigningSecrets(ctx context.Context, merchantID uuid.UUID) ([]dto.SigningSecret, error)
+	ActiveSigningSecrets(ctx context.Context, merchantID uuid.UUID) ([]dto.SigningSecret, error)
+	RotateSigningSecret(ctx context.Context, merchantID, id uuid.UUID, overlap time.Duration) (dto.SigningSecret, dto.SigningSecret, error)
+	RevokeSigningSecret(ctx context.Context, merchantID, id uuid.UUID) (dto.SigningSecret, error)
+}
+
+type Webhook interface {
+	CreateWebhookEndpoint(ctx context.Context, endpoint dto.WebhookEndpoint) (dto.WebhookEndpoint, error)
+	ListWebhookEndpoints(ctx context.Context, merchantID uuid.UUID) ([]dto.WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, merchantID, id uuid.UUID) error
+	ListWebhookDeliveries(ctx context.Context, merchantID uuid.UUID, filter dto.WebhookDeliveryFilter) ([]dto.WebhookDelivery, error)
+	RedeliverWebhookDelivery(ctx context.Context, merchantID, id uuid.UUID) (dto.WebhookDelivery, error)
+	EnqueueWebhookEvent(ctx context.Context, merchantID uuid.UUID, event dto.WebhookEvent) error
 }
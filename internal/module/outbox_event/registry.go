@@ -0,0 +1,121 @@
+package outboxevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/platform/messaging"
+)
+
+// Handler publishes a single outbox event. A returned error leaves the event
+// in the outbox so it is retried on the next tick.
+type Handler func(ctx context.Context, event dto.OutboxEvent) error
+
+// Registry maps outbox event types to the handler that publishes them.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register binds handler to eventType, replacing any previous binding.
+func (r *Registry) Register(eventType string, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[eventType] = handler
+}
+
+// Lookup returns the handler registered for eventType.
+func (r *Registry) Lookup(eventType string) (Handler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	handler, ok := r.handlers[eventType]
+	return handler, ok
+}
+
+// PublishPaymentCreated hands a newly created payment to the payment workers.
+func PublishPaymentCreated(msgClient messaging.MessagingClient) Handler {
+	return func(ctx context.Context, event dto.OutboxEvent) error {
+		env, err := envelope(ctx, event, map[string]string{"payment_id": event.AggregateID.String()})
+		if err != nil {
+			return err
+		}
+
+		return msgClient.PublishPayment(ctx, env)
+	}
+}
+
+// PublishRefundCreated hands a newly created refund to the refund workers.
+func PublishRefundCreated(msgClient messaging.MessagingClient) Handler {
+	return func(ctx context.Context, event dto.OutboxEvent) error {
+		env, err := envelope(ctx, event, map[string]string{"refund_id": event.AggregateID.String()})
+		if err != nil {
+			return err
+		}
+
+		return msgClient.PublishRefund(ctx, env)
+	}
+}
+
+// EnqueueWebhooks queues a payment status event for the webhook endpoints of
+// the payment's merchant. The outbox event ID doubles as the webhook event ID
+// so a retried handler does not queue the event twice.
+func EnqueueWebhooks(webhookModule module.Webhook) Handler {
+	return func(ctx context.Context, event dto.OutboxEvent) error {
+		var payment dto.Payment
+		if err := json.Unmarshal(event.Payload.Bytes, &payment); err != nil {
+			return fmt.Errorf("failed to decode payment: %w", err)
+		}
+
+		return webhookModule.EnqueueWebhookEvent(ctx, payment.MerchantID, dto.WebhookEvent{
+			ID:        event.ID,
+			Type:      event.EventType,
+			CreatedAt: event.CreatedAt,
+			Data:      event.Payload.Bytes,
+		})
+	}
+}
+
+// envelope wraps data for event. The outbox event is the cause of the
+// message, and the correlation ID falls back to the aggregate when the event
+// does not carry one. The originating request ID and the trace context of
+// ctx go along so consumers can tie their work back to the request.
+func envelope(ctx context.Context, event dto.OutboxEvent, data any) (messaging.Envelope, error) {
+	env, err := messaging.NewEnvelope(event.EventType, data)
+	if err != nil {
+		return messaging.Envelope{}, err
+	}
+
+	env.CausationID = event.ID.String()
+	env.CorrelationID = event.Headers[dto.HeaderCorrelationID]
+	if env.CorrelationID == "" {
+		env.CorrelationID = event.AggregateID.String()
+	}
+	env.RequestID = event.Headers[dto.HeaderRequestID]
+	env.SetTraceContext(ctx)
+
+	return env, nil
+}
+
+// DefaultRegistry returns a registry with the handlers for every event type
+// produced by the gateway.
+func DefaultRegistry(msgClient messaging.MessagingClient, webhookModule module.Webhook) *Registry {
+	registry := NewRegistry()
+	registry.Register(dto.EventPaymentCreated, PublishPaymentCreated(msgClient))
+	registry.Register(dto.EventRefundCreated, PublishRefundCreated(msgClient))
+	registry.Register(dto.EventPaymentSucceeded, EnqueueWebhooks(webhookModule))
+	registry.Register(dto.EventPaymentFailed, EnqueueWebhooks(webhookModule))
+
+	return registry
+}
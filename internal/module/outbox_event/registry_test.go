@@ -0,0 +1,54 @@
+package outboxevent_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	outboxeventWorker "github.com/kalom60/cashflow/internal/module/outbox_event"
+	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryLookup(t *testing.T) {
+	registry := outboxeventWorker.NewRegistry()
+
+	var got dto.OutboxEvent
+	registry.Register("payout.created", func(ctx context.Context, event dto.OutboxEvent) error {
+		got = event
+		return nil
+	})
+
+	handler, ok := registry.Lookup("payout.created")
+	assert.True(t, ok)
+
+	event := dto.OutboxEvent{ID: uuid.New(), EventType: "payout.created", AggregateID: uuid.New()}
+	assert.NoError(t, handler(context.Background(), event))
+	assert.Equal(t, event.ID, got.ID)
+
+	_, ok = registry.Lookup("unknown.event")
+	assert.False(t, ok)
+}
+
+func TestRegistryRegisterReplaces(t *testing.T) {
+	registry := outboxeventWorker.NewRegistry()
+	errFirst := errors.New("first")
+
+	registry.Register("payout.created", func(ctx context.Context, event dto.OutboxEvent) error { return errFirst })
+	registry.Register("payout.created", func(ctx context.Context, event dto.OutboxEvent) error { return nil })
+
+	handler, ok := registry.Lookup("payout.created")
+	assert.True(t, ok)
+	assert.NoError(t, handler(context.Background(), dto.OutboxEvent{}))
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	registry := outboxeventWorker.DefaultRegistry(messaging.NewInMemoryClient(messaging.Options{}), nil)
+
+	for _, eventType := range []string{dto.EventPaymentCreated, dto.EventRefundCreated, dto.EventPaymentSucceeded, dto.EventPaymentFailed} {
+		_, ok := registry.Lookup(eventType)
+		assert.True(t, ok, eventType)
+	}
+}
@@ -0,0 +1,56 @@
+package outboxevent
+
+import (
+	"time"
+
+	"github.com/kalom60/cashflow/platform/backoff"
+)
+
+const (
+	DefaultMaxAttempts = 10
+	DefaultBaseBackoff = time.Second
+	DefaultMaxBackoff  = 5 * time.Minute
+)
+
+// RetryPolicy decides when a failed outbox event is retried and when it is
+// given up on.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// NewRetryPolicy builds a RetryPolicy, falling back to the defaults for any
+// value that is not positive.
+func NewRetryPolicy(maxAttempts int, baseBackoff, maxBackoff time.Duration) RetryPolicy {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	if baseBackoff <= 0 {
+		baseBackoff = DefaultBaseBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	if maxBackoff < baseBackoff {
+		maxBackoff = baseBackoff
+	}
+
+	return RetryPolicy{
+		MaxAttempts: maxAttempts,
+		BaseBackoff: baseBackoff,
+		MaxBackoff:  maxBackoff,
+	}
+}
+
+// Backoff returns the delay before the next attempt once attempts publishes
+// have failed.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	return backoff.Exponential(p.BaseBackoff, p.MaxBackoff, attempts)
+}
+
+// Exhausted reports whether an event that has failed attempts times should be
+// moved to FAILED instead of being retried.
+func (p RetryPolicy) Exhausted(attempts int) bool {
+	return attempts >= p.MaxAttempts
+}
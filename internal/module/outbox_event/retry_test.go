@@ -0,0 +1,36 @@
+package outboxevent_test
+
+import (
+	"testing"
+	"time"
+
+	outboxeventWorker "github.com/kalom60/cashflow/internal/module/outbox_event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := outboxeventWorker.NewRetryPolicy(5, time.Second, 10*time.Second)
+
+	assert.Equal(t, time.Second, policy.Backoff(1))
+	assert.Equal(t, 2*time.Second, policy.Backoff(2))
+	assert.Equal(t, 4*time.Second, policy.Backoff(3))
+	assert.Equal(t, 8*time.Second, policy.Backoff(4))
+	assert.Equal(t, 10*time.Second, policy.Backoff(5))
+	assert.Equal(t, 10*time.Second, policy.Backoff(64))
+}
+
+func TestRetryPolicyExhausted(t *testing.T) {
+	policy := outboxeventWorker.NewRetryPolicy(3, time.Second, time.Minute)
+
+	assert.False(t, policy.Exhausted(1))
+	assert.False(t, policy.Exhausted(2))
+	assert.True(t, policy.Exhausted(3))
+}
+
+func TestNewRetryPolicyDefaults(t *testing.T) {
+	policy := outboxeventWorker.NewRetryPolicy(0, 0, 0)
+
+	assert.Equal(t, outboxeventWorker.DefaultMaxAttempts, policy.MaxAttempts)
+	assert.Equal(t, outboxeventWorker.DefaultBaseBackoff, policy.BaseBackoff)
+	assert.Equal(t, outboxeventWorker.DefaultMaxBackoff, policy.MaxBackoff)
+}
@@ -0,0 +1,42 @@
+package outboxevent
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultInterval  = 15 * time.Second
+	DefaultBatchSize = 100
+)
+
+// Settings are the outbox worker options that can be changed while it runs.
+type Settings struct {
+	// Interval is how often the worker polls when no notification arrives.
+	Interval time.Duration
+	// BatchSize is the most events published in one transaction.
+	BatchSize int
+}
+
+// Validate rejects settings the worker cannot run with.
+func (s Settings) Validate() error {
+	if s.Interval <= 0 {
+		return fmt.Errorf("outbox interval must be positive, got %s", s.Interval)
+	}
+	if s.BatchSize <= 0 {
+		return fmt.Errorf("outbox batch size must be positive, got %d", s.BatchSize)
+	}
+
+	return nil
+}
+
+func (s Settings) withDefaults() Settings {
+	if s.Interval <= 0 {
+		s.Interval = DefaultInterval
+	}
+	if s.BatchSize <= 0 {
+		s.BatchSize = DefaultBatchSize
+	}
+
+	return s
+}
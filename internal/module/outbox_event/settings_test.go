@@ -0,0 +1,28 @@
+package outboxevent_test
+
+import (
+	"testing"
+	"time"
+
+	outboxeventWorker "github.com/kalom60/cashflow/internal/module/outbox_event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsValidate(t *testing.T) {
+	assert.NoError(t, outboxeventWorker.Settings{Interval: time.Second, BatchSize: 1}.Validate())
+	assert.Error(t, outboxeventWorker.Settings{Interval: 0, BatchSize: 10}.Validate())
+	assert.Error(t, outboxeventWorker.Settings{Interval: time.Second, BatchSize: 0}.Validate())
+}
+
+func TestReconfigure(t *testing.T) {
+	worker := outboxeventWorker.Init(log, oeStore, outboxeventWorker.NewRegistry(), outboxeventWorker.NewRetryPolicy(0, 0, 0), outboxeventWorker.Settings{})
+	assert.Equal(t, outboxeventWorker.Settings{Interval: outboxeventWorker.DefaultInterval, BatchSize: outboxeventWorker.DefaultBatchSize}, worker.Settings())
+
+	err := worker.Reconfigure(outboxeventWorker.Settings{Interval: time.Second, BatchSize: 0})
+	assert.Error(t, err)
+	assert.Equal(t, outboxeventWorker.DefaultBatchSize, worker.Settings().BatchSize)
+
+	settings := outboxeventWorker.Settings{Interval: 500 * time.Millisecond, BatchSize: 25}
+	assert.NoError(t, worker.Reconfigure(settings))
+	assert.Equal(t, settings, worker.Settings())
+}
@@ -2,79 +2,156 @@ package outboxevent
 
 import (
 	"context"
-	"encoding/json"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
 	"github.com/kalom60/cashflow/internal/storage"
 	"github.com/kalom60/cashflow/platform/logger"
-	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/kalom60/cashflow/platform/metrics"
+	"github.com/kalom60/cashflow/platform/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type OutboxEventWorker struct {
 	logger             logger.Logger
 	outboxEventStorage storage.OutboxEvent
-	msgClient          messaging.MessagingClient
-	interval           time.Duration
+	registry           *Registry
+	retryPolicy        RetryPolicy
+	settings           atomic.Pointer[Settings]
+	reconfigured       chan struct{}
+	done               chan struct{}
 }
 
-func Init(logger logger.Logger, outboxEventStorage storage.OutboxEvent, msgClient messaging.MessagingClient, interval time.Duration) *OutboxEventWorker {
-	return &OutboxEventWorker{
+func Init(logger logger.Logger, outboxEventStorage storage.OutboxEvent, registry *Registry, retryPolicy RetryPolicy, settings Settings) *OutboxEventWorker {
+	w := &OutboxEventWorker{
 		logger:             logger,
 		outboxEventStorage: outboxEventStorage,
-		msgClient:          msgClient,
-		interval:           interval,
+		registry:           registry,
+		retryPolicy:        retryPolicy,
+		reconfigured:       make(chan struct{}, 1),
+		done:               make(chan struct{}),
 	}
+	settings = settings.withDefaults()
+	w.settings.Store(&settings)
+
+	return w
 }
 
+// Reconfigure applies new settings to a running worker. The next batch uses
+// the new batch size and the poll ticker is reset to the new interval.
+func (w *OutboxEventWorker) Reconfigure(settings Settings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	w.settings.Store(&settings)
+	select {
+	case w.reconfigured <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Settings returns the settings the worker is currently running with.
+func (w *OutboxEventWorker) Settings() Settings {
+	return *w.settings.Load()
+}
+
+// Start polls the outbox until ctx is cancelled. A batch that is already
+// running is finished before Start returns.
 func (w *OutboxEventWorker) Start(ctx context.Context) {
-	ticker := time.NewTicker(w.interval)
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.Settings().Interval)
 	defer ticker.Stop()
 
+	batchCtx := context.WithoutCancel(ctx)
+
 	w.logger.Info(ctx, "Starting Global Outbox Worker...")
 
+	// New events wake the worker straight away, the ticker stays as a
+	// fallback for retries and for when the listener is down
+	notifications := w.outboxEventStorage.Listen(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
 			w.logger.Info(ctx, "Stopping Global Outbox Worker...")
 			return
+		case _, ok := <-notifications:
+			if !ok {
+				notifications = nil
+				continue
+			}
+			w.processEvents(batchCtx)
+			w.recordBacklog(batchCtx)
 		case <-ticker.C:
-			w.processEvents(ctx)
+			w.processEvents(batchCtx)
+			w.recordBacklog(batchCtx)
+		case <-w.reconfigured:
+			ticker.Reset(w.Settings().Interval)
 		}
 	}
 }
 
+// Done is closed once Start has returned.
+func (w *OutboxEventWorker) Done() <-chan struct{} {
+	return w.done
+}
+
+// outboxFailure is a failed event waiting to be recorded.
+type outboxFailure struct {
+	id      uuid.UUID
+	failure dto.OutboxFailure
+}
+
 func (w *OutboxEventWorker) processEvents(ctx context.Context) {
 	tx, err := w.outboxEventStorage.BeginTx(ctx)
 	if err != nil {
 		return
 	}
-	defer tx.Rollback(ctx)
 
-	events, err := w.outboxEventStorage.GetPendingOutboxEventsForUpdate(ctx, tx)
+	// Failures are recorded after the batch, outside its transaction. An
+	// error while recording one would otherwise roll back the deletes of the
+	// events that were already published, and they would go out again. The
+	// transaction has to end first, it holds the rows' locks.
+	var failures []outboxFailure
+	defer func() {
+		_ = tx.Rollback(ctx)
+		w.recordFailures(ctx, failures)
+	}()
+
+	events, err := w.outboxEventStorage.GetPendingOutboxEventsForUpdate(ctx, tx, w.Settings().BatchSize)
 	if err != nil {
 		return
 	}
 
 	for _, event := range events {
-		var payload map[string]any
-		if err := json.Unmarshal(event.Payload.Bytes, &payload); err != nil {
-			w.logger.Named("OutboxEventWorker-ProcessEvents").Error(ctx, "failed to Unmarshal event payload", zap.Any("event_id", event.ID), zap.Error(err))
-			w.logger.Named("OutboxEventWorker-ProcessEvents").Info(ctx, "deleting corrupted outbox event", zap.Any("event_id", event.ID), zap.Error(err))
-			w.outboxEventStorage.DeleteOutboxEvent(ctx, tx, event.ID)
-			continue
-		}
-		// Handle messaging
-		paymentID, ok := payload["id"].(string)
+		handler, ok := w.registry.Lookup(event.EventType)
 		if !ok {
-			w.logger.Named("OutboxEventWorker-ProcessEvents").Error(ctx, "payload missing payment id", zap.Any("event_id", event.ID))
-			w.outboxEventStorage.DeleteOutboxEvent(ctx, tx, event.ID)
+			w.logger.Named("OutboxEventWorker-ProcessEvents").Error(ctx, "no handler registered for event type", zap.Any("event_id", event.ID), zap.String("event_type", event.EventType))
+			failures = append(failures, outboxFailure{id: event.ID, failure: dto.OutboxFailure{
+				Status:        dto.OutboxStatusFailed,
+				LastError:     "no handler registered for event type " + event.EventType,
+				NextAttemptAt: event.NextAttemptAt,
+			}})
 			continue
 		}
 
-		if err := w.msgClient.PublishPayment(ctx, paymentID); err != nil {
-			w.logger.Named("OutboxEventWorker-ProcessEvents-Publish").Error(ctx, "failed to publish message", zap.Any("payment_id", paymentID), zap.Error(err))
-			return
+		start := time.Now()
+		err := w.publish(ctx, handler, event)
+		metrics.OutboxPublishDuration.WithLabelValues(event.EventType).Observe(time.Since(start).Seconds())
+		if err != nil {
+			metrics.OutboxPublishFailures.WithLabelValues(event.EventType).Inc()
+			w.logger.Named("OutboxEventWorker-ProcessEvents-Publish").Error(ctx, "failed to publish message", zap.Any("event_id", event.ID), zap.String("event_type", event.EventType), zap.Any("aggregate_id", event.AggregateID), zap.Int("attempt", event.Attempts+1), zap.Error(err))
+			failures = append(failures, outboxFailure{id: event.ID, failure: w.failure(event, err)})
+			continue
 		}
 
 		if err := w.outboxEventStorage.DeleteOutboxEvent(ctx, tx, event.ID); err != nil {
@@ -87,3 +164,65 @@ func (w *OutboxEventWorker) processEvents(ctx context.Context) {
 		w.logger.Named("OutboxEventWorker-ProcessEvents-Commit").Error(ctx, "failed to commit transaction", zap.Error(err))
 	}
 }
+
+// recordFailures records failures once the batch transaction has ended and
+// released its row locks. An event whose failure cannot be recorded stays
+// pending and is retried on the next tick.
+func (w *OutboxEventWorker) recordFailures(ctx context.Context, failures []outboxFailure) {
+	for _, f := range failures {
+		if err := w.outboxEventStorage.RecordOutboxFailure(ctx, nil, f.id, f.failure); err != nil {
+			w.logger.Named("OutboxEventWorker-RecordFailures").Error(ctx, "failed to record outbox failure", zap.Any("event_id", f.id), zap.Error(err))
+		}
+	}
+}
+
+// recordBacklog updates the outbox backlog gauge.
+func (w *OutboxEventWorker) recordBacklog(ctx context.Context) {
+	backlog, err := w.outboxEventStorage.CountPendingOutboxEvents(ctx)
+	if err != nil {
+		return
+	}
+
+	metrics.OutboxBacklog.Set(float64(backlog))
+}
+
+// publish runs handler for event in a producer span that continues the trace
+// of the request which created the event.
+func (w *OutboxEventWorker) publish(ctx context.Context, handler Handler, event dto.OutboxEvent) error {
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, event.Headers), "publish "+event.EventType,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("outbox.event_id", event.ID.String()),
+			attribute.String("outbox.aggregate_id", event.AggregateID.String()),
+			attribute.Int("outbox.attempt", event.Attempts+1),
+		),
+	)
+	defer span.End()
+
+	if err := handler(ctx, event); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "publish failed")
+		return err
+	}
+
+	return nil
+}
+
+// failure schedules the next attempt for event with exponential backoff, or
+// marks it FAILED once the retry policy is exhausted.
+func (w *OutboxEventWorker) failure(event dto.OutboxEvent, publishErr error) dto.OutboxFailure {
+	attempts := event.Attempts + 1
+	if w.retryPolicy.Exhausted(attempts) {
+		return dto.OutboxFailure{
+			Status:        dto.OutboxStatusFailed,
+			LastError:     publishErr.Error(),
+			NextAttemptAt: event.NextAttemptAt,
+		}
+	}
+
+	return dto.OutboxFailure{
+		Status:        dto.OutboxStatusPending,
+		LastError:     publishErr.Error(),
+		NextAttemptAt: time.Now().Add(w.retryPolicy.Backoff(attempts)),
+	}
+}
@@ -2,59 +2,54 @@ package outboxevent_test
 
 import (
 	"context"
+	"errors"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 	"github.com/kalom60/cashflow/internal/constant/dto"
 	"github.com/kalom60/cashflow/internal/module"
 
 	outboxeventWorker "github.com/kalom60/cashflow/internal/module/outbox_event"
 	paymentModule "github.com/kalom60/cashflow/internal/module/payment"
+	webhookModule "github.com/kalom60/cashflow/internal/module/webhook"
 	"github.com/kalom60/cashflow/internal/storage"
 	outboxeventStorage "github.com/kalom60/cashflow/internal/storage/outbox_event"
 	paymentStorage "github.com/kalom60/cashflow/internal/storage/payment"
+	webhookStorage "github.com/kalom60/cashflow/internal/storage/webhook"
 	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/messaging"
 	"github.com/kalom60/cashflow/tests/testutils"
-	"github.com/rabbitmq/amqp091-go"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
-type mockMessagingClient struct{}
-
-func (m *mockMessagingClient) PublishPayment(ctx context.Context, paymentID string) error {
-	return nil
-}
-
-func (m *mockMessagingClient) ConsumePayments(ctx context.Context) (<-chan amqp091.Delivery, error) {
-	return nil, nil
-}
-
-func (m *mockMessagingClient) Close() error {
-	return nil
-}
-
 var (
-	ctx      context.Context
-	log      logger.Logger
-	pStore   storage.Payment
-	pModule  module.Payment
-	oeStore  storage.OutboxEvent
-	oeWorker *outboxeventWorker.OutboxEventWorker
+	ctx        context.Context
+	merchantID uuid.UUID
+	log        logger.Logger
+	pStore     storage.Payment
+	pModule    module.Payment
+	oeStore    storage.OutboxEvent
+	oeWorker   *outboxeventWorker.OutboxEventWorker
 )
 
 func TestMain(m *testing.M) {
 	ctx = context.Background()
 	testDB := testutils.SetupTestDB()
+	merchantID = testutils.CreateMerchant(testDB)
 	log = testutils.NewTestLogger()
 
 	pStore = paymentStorage.Init(log, &testDB)
 	pModule = paymentModule.Init(log, pStore)
 
-	oeStore = outboxeventStorage.Init(log, &testDB, 100)
-	oeWorker = outboxeventWorker.Init(log, oeStore, &mockMessagingClient{}, 2*time.Second)
+	whModule := webhookModule.Init(log, webhookStorage.Init(log, &testDB), webhookModule.NewRetryPolicy(0, 0, 0))
+
+	oeStore = outboxeventStorage.Init(log, &testDB)
+	oeWorker = outboxeventWorker.Init(log, oeStore, outboxeventWorker.DefaultRegistry(messaging.NewInMemoryClient(messaging.Options{}), whModule), outboxeventWorker.NewRetryPolicy(3, time.Second, 10*time.Second), outboxeventWorker.Settings{Interval: 2 * time.Second, BatchSize: 100})
 
 	go oeWorker.Start(ctx)
 
@@ -64,11 +59,12 @@ func TestMain(m *testing.M) {
 
 func TestCreatePaymentETB(t *testing.T) {
 	req := dto.Payment{
-		Reference: uuid.New(),
-		Amount:    decimal.NewFromFloat(100000),
-		Currency:  dto.ETB,
-		Status:    dto.PENDING,
-		CreatedAt: time.Now(),
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(100000),
+		Currency:   dto.ETB,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
 	}
 
 	resp, err := pModule.CreatePayment(ctx, req)
@@ -81,11 +77,12 @@ func TestCreatePaymentETB(t *testing.T) {
 
 func TestCreatePaymentUSD(t *testing.T) {
 	req := dto.Payment{
-		Reference: uuid.New(),
-		Amount:    decimal.NewFromFloat(1000000),
-		Currency:  dto.USD,
-		Status:    dto.PENDING,
-		CreatedAt: time.Now(),
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(1000000),
+		Currency:   dto.USD,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
 	}
 
 	resp, err := pModule.CreatePayment(ctx, req)
@@ -99,7 +96,112 @@ func TestCreatePaymentUSD(t *testing.T) {
 func TestGetOutboxEventsForUpdate(t *testing.T) {
 	time.Sleep(5 * time.Second)
 	tx, _ := oeStore.BeginTx(ctx)
-	resp, err := oeStore.GetPendingOutboxEventsForUpdate(ctx, tx)
+	resp, err := oeStore.GetPendingOutboxEventsForUpdate(ctx, tx, 100)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(resp))
 }
+
+// batchStore serves one batch of events and records what the worker does
+// with it.
+type batchStore struct {
+	storage.OutboxEvent
+
+	mu        sync.Mutex
+	events    []dto.OutboxEvent
+	tx        *batchTx
+	deleted   []uuid.UUID
+	failures  []uuid.UUID
+	recorded  chan struct{}
+	committed bool
+}
+
+type batchTx struct {
+	pgx.Tx
+	store *batchStore
+}
+
+func (tx *batchTx) Commit(ctx context.Context) error {
+	tx.store.mu.Lock()
+	defer tx.store.mu.Unlock()
+
+	tx.store.committed = true
+	return nil
+}
+
+func (tx *batchTx) Rollback(ctx context.Context) error {
+	return nil
+}
+
+func (s *batchStore) BeginTx(ctx context.Context) (pgx.Tx, error) {
+	return s.tx, nil
+}
+
+func (s *batchStore) GetPendingOutboxEventsForUpdate(ctx context.Context, tx pgx.Tx, limit int) ([]dto.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := s.events
+	s.events = nil
+	return events, nil
+}
+
+func (s *batchStore) DeleteOutboxEvent(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func (s *batchStore) RecordOutboxFailure(ctx context.Context, tx pgx.Tx, id uuid.UUID, failure dto.OutboxFailure) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Recording inside the batch would block on the batch's own row locks
+	if tx == nil && s.committed {
+		s.failures = append(s.failures, id)
+	}
+	s.recorded <- struct{}{}
+
+	return errors.New("database is gone")
+}
+
+func (s *batchStore) CountPendingOutboxEvents(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (s *batchStore) Listen(ctx context.Context) <-chan struct{} {
+	return nil
+}
+
+func TestFailureRecordingDoesNotRollBackPublishedEvents(t *testing.T) {
+	failed := dto.OutboxEvent{ID: uuid.New(), EventType: "payout.failed"}
+	published := dto.OutboxEvent{ID: uuid.New(), EventType: "payout.created"}
+
+	store := &batchStore{events: []dto.OutboxEvent{failed, published}, recorded: make(chan struct{}, 1)}
+	store.tx = &batchTx{store: store}
+
+	registry := outboxeventWorker.NewRegistry()
+	registry.Register(failed.EventType, func(ctx context.Context, event dto.OutboxEvent) error { return errors.New("broker is down") })
+	registry.Register(published.EventType, func(ctx context.Context, event dto.OutboxEvent) error { return nil })
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	worker := outboxeventWorker.Init(log, store, registry, outboxeventWorker.NewRetryPolicy(3, time.Second, 10*time.Second), outboxeventWorker.Settings{Interval: 10 * time.Millisecond, BatchSize: 10})
+	go worker.Start(workerCtx)
+	defer func() {
+		cancel()
+		<-worker.Done()
+	}()
+
+	select {
+	case <-store.recorded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("failure was never recorded")
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	assert.True(t, store.committed)
+	assert.Equal(t, []uuid.UUID{published.ID}, store.deleted)
+	assert.Equal(t, []uuid.UUID{failed.ID}, store.failures)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/kalom60/cashflow/internal/module"
 	"github.com/kalom60/cashflow/internal/storage"
 	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/metrics"
 )
 
 type paymentModule struct {
@@ -28,14 +29,50 @@ func (pm *paymentModule) CreatePayment(ctx context.Context, req dto.Payment) (dt
 		return dto.Payment{}, err
 	}
 
+	metrics.PaymentsCreated.WithLabelValues(string(payment.Currency), string(payment.Status)).Inc()
+
 	return payment, nil
 }
 
-func (pm *paymentModule) GetPaymentByID(ctx context.Context, id uuid.UUID) (dto.Payment, error) {
-	payment, err := pm.paymentStorage.GetPaymentByID(ctx, id)
+func (pm *paymentModule) GetPaymentByID(ctx context.Context, merchantID, id uuid.UUID) (dto.Payment, error) {
+	payment, err := pm.paymentStorage.GetMerchantPaymentByID(ctx, merchantID, id)
 	if err != nil {
 		return dto.Payment{}, err
 	}
 
 	return payment, nil
 }
+
+func (pm *paymentModule) ListPayments(ctx context.Context, filter dto.PaymentFilter) (dto.PaymentPage, error) {
+	// Fetch one extra row to know whether another page exists
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	payments, err := pm.paymentStorage.ListPayments(ctx, filter)
+	if err != nil {
+		return dto.PaymentPage{}, err
+	}
+
+	page := dto.PaymentPage{Payments: payments}
+	if len(payments) > limit {
+		page.Payments = payments[:limit]
+		last := page.Payments[limit-1]
+		page.NextCursor = dto.PaymentCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
+	return page, nil
+}
+
+func (pm *paymentModule) GetPaymentHistory(ctx context.Context, merchantID, id uuid.UUID) ([]dto.PaymentStatusHistory, error) {
+	// Surface a 404 for unknown payments instead of an empty history
+	if _, err := pm.paymentStorage.GetMerchantPaymentByID(ctx, merchantID, id); err != nil {
+		return nil, err
+	}
+
+	history, err := pm.paymentStorage.GetPaymentStatusHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
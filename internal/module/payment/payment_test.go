@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/joomcode/errorx"
 	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
 	"github.com/kalom60/cashflow/internal/module"
 	paymentModule "github.com/kalom60/cashflow/internal/module/payment"
 	"github.com/kalom60/cashflow/internal/storage"
@@ -19,10 +21,12 @@ import (
 )
 
 var (
-	ctx     context.Context
-	store   storage.Payment
-	log     logger.Logger
-	pModule module.Payment
+	ctx             context.Context
+	merchantID      uuid.UUID
+	otherMerchantID uuid.UUID
+	store           storage.Payment
+	log             logger.Logger
+	pModule         module.Payment
 
 	paymentIDETB uuid.UUID
 	paymentIDUSD uuid.UUID
@@ -31,6 +35,8 @@ var (
 func TestMain(m *testing.M) {
 	ctx = context.Background()
 	testDB := testutils.SetupTestDB()
+	merchantID = testutils.CreateMerchant(testDB)
+	otherMerchantID = testutils.CreateMerchant(testDB)
 	log = testutils.NewTestLogger()
 	store = paymentStorage.Init(log, &testDB)
 	pModule = paymentModule.Init(log, store)
@@ -41,11 +47,12 @@ func TestMain(m *testing.M) {
 
 func TestCreatePaymentETB(t *testing.T) {
 	req := dto.Payment{
-		Reference: uuid.New(),
-		Amount:    decimal.NewFromFloat(100000),
-		Currency:  dto.ETB,
-		Status:    dto.PENDING,
-		CreatedAt: time.Now(),
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(100000),
+		Currency:   dto.ETB,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
 	}
 
 	resp, err := pModule.CreatePayment(ctx, req)
@@ -60,11 +67,12 @@ func TestCreatePaymentETB(t *testing.T) {
 
 func TestCreatePaymentUSD(t *testing.T) {
 	req := dto.Payment{
-		Reference: uuid.New(),
-		Amount:    decimal.NewFromFloat(1000000),
-		Currency:  dto.USD,
-		Status:    dto.PENDING,
-		CreatedAt: time.Now(),
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(1000000),
+		Currency:   dto.USD,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
 	}
 
 	resp, err := pModule.CreatePayment(ctx, req)
@@ -78,20 +86,71 @@ func TestCreatePaymentUSD(t *testing.T) {
 }
 
 func TestGetPaymentByIDETB(t *testing.T) {
-	resp, err := pModule.GetPaymentByID(ctx, paymentIDETB)
+	resp, err := pModule.GetPaymentByID(ctx, merchantID, paymentIDETB)
 	assert.NoError(t, err)
 	assert.Equal(t, dto.ETB, resp.Currency)
 	assert.Equal(t, dto.PENDING, resp.Status)
 }
 
 func TestGetPaymentByIDUSD(t *testing.T) {
-	resp, err := pModule.GetPaymentByID(ctx, paymentIDUSD)
+	resp, err := pModule.GetPaymentByID(ctx, merchantID, paymentIDUSD)
 	assert.NoError(t, err)
 	assert.Equal(t, dto.USD, resp.Currency)
 	assert.Equal(t, dto.PENDING, resp.Status)
 }
 
 func TestGetPaymentByNewID(t *testing.T) {
-	_, err := pModule.GetPaymentByID(ctx, uuid.New())
+	_, err := pModule.GetPaymentByID(ctx, merchantID, uuid.New())
 	assert.Error(t, err)
 }
+
+func TestGetPaymentOfAnotherMerchant(t *testing.T) {
+	_, err := pModule.GetPaymentByID(ctx, otherMerchantID, paymentIDETB)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+
+	_, err = pModule.GetPaymentHistory(ctx, otherMerchantID, paymentIDETB)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+}
+
+func TestListPaymentsOfAnotherMerchant(t *testing.T) {
+	page, err := pModule.ListPayments(ctx, dto.PaymentFilter{MerchantID: otherMerchantID, Limit: dto.DefaultPaymentPageSize})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Payments)
+}
+
+func TestListPaymentsByCurrency(t *testing.T) {
+	currency := dto.ETB
+	page, err := pModule.ListPayments(ctx, dto.PaymentFilter{MerchantID: merchantID, Currency: &currency, Limit: dto.DefaultPaymentPageSize})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(page.Payments))
+	assert.Equal(t, paymentIDETB, page.Payments[0].ID)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestListPaymentsByAmountRange(t *testing.T) {
+	page, err := pModule.ListPayments(ctx, dto.PaymentFilter{
+		MerchantID: merchantID,
+		MinAmount:  decimal.NullDecimal{Decimal: decimal.NewFromFloat(500000), Valid: true},
+		Limit:      dto.DefaultPaymentPageSize,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(page.Payments))
+	assert.Equal(t, paymentIDUSD, page.Payments[0].ID)
+}
+
+func TestListPaymentsPagination(t *testing.T) {
+	first, err := pModule.ListPayments(ctx, dto.PaymentFilter{MerchantID: merchantID, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(first.Payments))
+	assert.Equal(t, paymentIDUSD, first.Payments[0].ID)
+	assert.NotEmpty(t, first.NextCursor)
+
+	cursor, err := dto.DecodePaymentCursor(first.NextCursor)
+	assert.NoError(t, err)
+
+	second, err := pModule.ListPayments(ctx, dto.PaymentFilter{MerchantID: merchantID, Cursor: &cursor, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(second.Payments))
+	assert.Equal(t, paymentIDETB, second.Payments[0].ID)
+	assert.Empty(t, second.NextCursor)
+}
@@ -2,17 +2,21 @@ package payment
 
 import (
 	"context"
-	"crypto/rand"
 	"encoding/json"
-	"math/big"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/joomcode/errorx"
 	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
 	"github.com/kalom60/cashflow/internal/storage"
 	"github.com/kalom60/cashflow/platform/logger"
 	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/kalom60/cashflow/platform/metrics"
+	"github.com/kalom60/cashflow/platform/processor"
+	"github.com/kalom60/cashflow/platform/tracing"
 	"github.com/kalom60/cashflow/platform/workerpool"
-	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -21,14 +25,16 @@ type PaymentWorker struct {
 	pool           *workerpool.WorkerPool
 	paymentStorage storage.Payment
 	msgClient      messaging.MessagingClient
+	processor      processor.PaymentProcessor
 }
 
-func NewPaymentWorker(logger logger.Logger, pool *workerpool.WorkerPool, paymentStorage storage.Payment, msgClient messaging.MessagingClient) *PaymentWorker {
+func NewPaymentWorker(logger logger.Logger, pool *workerpool.WorkerPool, paymentStorage storage.Payment, msgClient messaging.MessagingClient, paymentProcessor processor.PaymentProcessor) *PaymentWorker {
 	return &PaymentWorker{
 		logger:         logger,
 		pool:           pool,
 		paymentStorage: paymentStorage,
 		msgClient:      msgClient,
+		processor:      paymentProcessor,
 	}
 }
 
@@ -40,45 +46,82 @@ func (pw *PaymentWorker) Start(ctx context.Context) {
 		pw.logger.Named("PaymentWorker-Start").Fatal(ctx, "failed to start consuming payments", zap.Error(err))
 	}
 
+	// Cancelling ctx stops new deliveries, but messages already handed to the
+	// pool are processed to completion
+	taskCtx := context.WithoutCancel(ctx)
+
 	go func() {
 		for msg := range msgs {
 			m := msg
-			pw.pool.Submit(func() {
-				pw.processMessage(ctx, m)
+			pw.pool.SubmitContext(taskCtx, func(ctx context.Context) {
+				start := time.Now()
+				pw.processMessage(ctx, metrics.InstrumentMessage(messaging.PaymentQueue, m))
+				metrics.ConsumerProcessingDuration.WithLabelValues(messaging.PaymentQueue).Observe(time.Since(start).Seconds())
 			})
 		}
 	}()
 }
 
-func (pw *PaymentWorker) processMessage(ctx context.Context, msg amqp.Delivery) {
+func (pw *PaymentWorker) processMessage(ctx context.Context, msg messaging.Message) {
+	env, err := messaging.DecodeEnvelope(msg)
+	if err != nil {
+		pw.logger.Named("PaymentWorker-ProcessMessage").Error(ctx, "failed to decode message envelope", zap.Error(err))
+		_ = msg.Nack(false)
+		return
+	}
+
+	// Worker logs carry the ID of the request that created the payment
+	if env.RequestID != "" {
+		ctx = logger.WithRequestID(ctx, env.RequestID)
+	}
+
+	ctx, span := tracing.StartConsumer(ctx, "process "+messaging.PaymentQueue, env.TraceContext(),
+		attribute.String("messaging.message.id", env.ID),
+		attribute.String("messaging.message.type", env.Type),
+		attribute.String("messaging.message.correlation_id", env.CorrelationID),
+		attribute.String("request_id", env.RequestID),
+	)
+	defer span.End()
+
+	switch env.Type {
+	// Messages published before envelopes were introduced have no type
+	case dto.EventPaymentCreated, "":
+		pw.processPayment(ctx, env, msg)
+	default:
+		pw.logger.Named("PaymentWorker-ProcessMessage").Error(ctx, "unsupported message type", zap.String("type", env.Type), zap.String("message_id", env.ID))
+		_ = msg.Nack(false)
+	}
+}
+
+func (pw *PaymentWorker) processPayment(ctx context.Context, env messaging.Envelope, msg messaging.Message) {
 	var body map[string]string
-	if err := json.Unmarshal(msg.Body, &body); err != nil {
+	if err := json.Unmarshal(env.Data, &body); err != nil {
 		pw.logger.Named("PaymentWorker-ProcessMessage").Error(ctx, "failed to unmarshal message body", zap.Error(err))
-		_ = msg.Nack(false, false)
+		_ = msg.Nack(false)
 		return
 	}
 
 	paymentIDStr, ok := body["payment_id"]
 	if !ok {
 		pw.logger.Named("PaymentWorker-ProcessMessage").Error(ctx, "message body missing payment_id")
-		_ = msg.Nack(false, false)
+		_ = msg.Nack(false)
 		return
 	}
 
 	paymentID, err := uuid.Parse(paymentIDStr)
 	if err != nil {
 		pw.logger.Named("PaymentWorker-ProcessMessage").Error(ctx, "failed to parse payment_id", zap.String("payment_id", paymentIDStr), zap.Error(err))
-		_ = msg.Nack(false, false)
+		_ = msg.Nack(false)
 		return
 	}
 
-	pw.logger.Info(ctx, "Processing payment message", zap.String("payment_id", paymentID.String()))
+	pw.logger.Info(ctx, "Processing payment message", zap.String("payment_id", paymentID.String()), zap.String("message_id", env.ID), zap.String("correlation_id", env.CorrelationID), zap.String("causation_id", env.CausationID))
 
 	// Start Transaction for row-level locking and status check
 	tx, err := pw.paymentStorage.BeginTx(ctx)
 	if err != nil {
 		pw.logger.Named("PaymentWorker-ProcessMessage-BeginTx").Error(ctx, "failed to begin transaction", zap.Error(err))
-		_ = msg.Nack(false, true)
+		pw.retry(ctx, msg, err)
 		return
 	}
 	defer tx.Rollback(ctx)
@@ -87,41 +130,91 @@ func (pw *PaymentWorker) processMessage(ctx context.Context, msg amqp.Delivery)
 	payment, err := pw.paymentStorage.GetPaymentByIDForUpdate(ctx, tx, paymentID)
 	if err != nil {
 		pw.logger.Named("PaymentWorker-ProcessMessage-Lock").Error(ctx, "failed to lock payment for update", zap.String("payment_id", paymentID.String()), zap.Error(err))
-		_ = msg.Nack(false, true) // Retry
+		pw.retry(ctx, msg, err)
 		return
 	}
 
-	// Idempotency check: only process if PENDING
-	if payment.Status != dto.PENDING {
+	// Idempotency check: only process payments that have not been settled yet
+	if payment.Status != dto.PENDING && payment.Status != dto.PROCESSING {
 		pw.logger.Info(ctx, "Payment already processed, skipping", zap.String("payment_id", paymentID.String()), zap.String("current_status", string(payment.Status)))
-		_ = msg.Ack(false)
+		_ = msg.Ack()
 		return
 	}
 
-	// Simulate randomized processing result
-	var status dto.PaymentStatus
-	n, _ := rand.Int(rand.Reader, big.NewInt(100))
-	if n.Int64() < 50 {
-		status = dto.SUCCESS
-	} else {
-		status = dto.FAILED
+	if payment.Status == dto.PENDING {
+		if err := pw.paymentStorage.UpdatePaymentStatusWithTx(ctx, tx, paymentID, dto.PaymentTransition{
+			Status: dto.PROCESSING,
+			Actor:  dto.ActorPaymentWorker,
+			Reason: "picked up for processing",
+		}); err != nil {
+			pw.logger.Named("PaymentWorker-ProcessMessage-UpdateStatus").Error(ctx, "failed to mark payment as processing", zap.String("payment_id", paymentID.String()), zap.Error(err))
+			pw.retry(ctx, msg, err)
+			return
+		}
 	}
 
-	pw.logger.Info(ctx, "Simulated processing result", zap.String("payment_id", paymentID.String()), zap.String("status", string(status)))
-
-	if err := pw.paymentStorage.UpdatePaymentStatusWithTx(ctx, tx, paymentID, status); err != nil {
-		pw.logger.Named("PaymentWorker-ProcessMessage-UpdateStatus").Error(ctx, "failed to update payment status", zap.String("payment_id", paymentID.String()), zap.Error(err))
-		_ = msg.Nack(false, true) // Retry
+	result, err := pw.processor.Process(ctx, processor.Payment{
+		ID:        payment.ID,
+		Reference: payment.Reference,
+		Amount:    payment.Amount,
+		Currency:  string(payment.Currency),
+	})
+	if err != nil {
+		pw.logger.Named("PaymentWorker-ProcessMessage-Process").Error(ctx, "payment processor returned an error", zap.String("payment_id", paymentID.String()), zap.Error(err))
+		pw.retry(ctx, msg, err)
 		return
 	}
 
+	pw.logger.Info(ctx, "Payment processing result", zap.String("payment_id", paymentID.String()), zap.String("outcome", string(result.Outcome)), zap.String("reason_code", result.ReasonCode))
+
+	pending := false
+	switch result.Outcome {
+	case processor.OutcomeSuccess, processor.OutcomeFailed:
+		status := dto.SUCCESS
+		if result.Outcome == processor.OutcomeFailed {
+			status = dto.FAILED
+		}
+
+		if err := pw.paymentStorage.UpdatePaymentStatusWithTx(ctx, tx, paymentID, dto.PaymentTransition{
+			Status: status,
+			Actor:  dto.ActorPaymentWorker,
+			Reason: result.ReasonCode,
+		}); err != nil {
+			pw.logger.Named("PaymentWorker-ProcessMessage-UpdateStatus").Error(ctx, "failed to update payment status", zap.String("payment_id", paymentID.String()), zap.Error(err))
+			pw.retry(ctx, msg, err)
+			return
+		}
+	default:
+		// Still pending at the processor, keep the payment in PROCESSING and retry later
+		pending = true
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		pw.logger.Named("PaymentWorker-ProcessMessage-Commit").Error(ctx, "failed to commit transaction", zap.Error(err))
-		_ = msg.Nack(false, true)
+		pw.retry(ctx, msg, err)
+		return
+	}
+
+	if pending {
+		pw.retry(ctx, msg, nil)
 		return
 	}
 
-	if err := msg.Ack(false); err != nil {
+	if err := msg.Ack(); err != nil {
 		pw.logger.Named("PaymentWorker-ProcessMessage-Ack").Error(ctx, "failed to acknowledge message", zap.String("payment_id", paymentID.String()), zap.Error(err))
 	}
 }
+
+// retry schedules msg for a delayed redelivery. Errors that will not go away
+// on their own, such as an unknown payment or an illegal transition, are
+// dead-lettered straight away.
+func (pw *PaymentWorker) retry(ctx context.Context, msg messaging.Message, cause error) {
+	if cause != nil && (errorx.IsOfType(cause, customErrors.ErrResourceNotFound) || errorx.IsOfType(cause, customErrors.ErrInvalidStatusTransition)) {
+		_ = msg.Nack(false) // Dead-letter
+		return
+	}
+
+	if err := pw.msgClient.RetryPayment(ctx, msg); err != nil {
+		pw.logger.Named("PaymentWorker-Retry").Error(ctx, "failed to schedule retry", zap.Int("attempt", messaging.Attempt(msg.Headers())), zap.Error(err))
+	}
+}
@@ -0,0 +1,95 @@
+package payment_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	paymentModule "github.com/kalom60/cashflow/internal/module/payment"
+	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/kalom60/cashflow/platform/processor"
+	"github.com/kalom60/cashflow/platform/workerpool"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForStatus(t *testing.T, id uuid.UUID, status dto.PaymentStatus) {
+	t.Helper()
+
+	assert.Eventually(t, func() bool {
+		payment, err := store.GetPaymentByID(ctx, id)
+		return err == nil && payment.Status == status
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func publishPayment(t *testing.T, msgClient messaging.MessagingClient, id string) {
+	t.Helper()
+
+	env, err := messaging.NewEnvelope(dto.EventPaymentCreated, map[string]string{"payment_id": id})
+	require.NoError(t, err)
+	require.NoError(t, msgClient.PublishPayment(ctx, env))
+}
+
+func TestPaymentWorkerEndToEnd(t *testing.T) {
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgClient := messaging.NewInMemoryClient(messaging.Options{})
+	defer msgClient.Close()
+
+	pool := workerpool.New(2, 10, workerpool.Options{})
+	pool.Start()
+
+	worker := paymentModule.NewPaymentWorker(log, pool, store, msgClient, processor.NewSimulator())
+	worker.Start(workerCtx)
+
+	succeeded, err := store.CreatePayment(ctx, dto.Payment{
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(120.00),
+		Currency:   dto.USD,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
+	})
+	require.NoError(t, err)
+
+	declined, err := store.CreatePayment(ctx, dto.Payment{
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(120.52),
+		Currency:   dto.USD,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
+	})
+	require.NoError(t, err)
+
+	publishPayment(t, msgClient, succeeded.ID.String())
+	publishPayment(t, msgClient, declined.ID.String())
+
+	waitForStatus(t, succeeded.ID, dto.SUCCESS)
+	waitForStatus(t, declined.ID, dto.FAILED)
+}
+
+func TestPaymentWorkerDeadLettersUnknownPayment(t *testing.T) {
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgClient := messaging.NewInMemoryClient(messaging.Options{})
+	defer msgClient.Close()
+
+	pool := workerpool.New(1, 10, workerpool.Options{})
+	pool.Start()
+
+	worker := paymentModule.NewPaymentWorker(log, pool, store, msgClient, processor.NewSimulator())
+	worker.Start(workerCtx)
+
+	publishPayment(t, msgClient, uuid.NewString())
+
+	assert.Eventually(t, func() bool {
+		deadLetters, err := msgClient.PeekDeadLetters(ctx, messaging.PaymentDeadLetters, 10)
+		return err == nil && len(deadLetters) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+}
@@ -0,0 +1,54 @@
+package refund
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/platform/logger"
+)
+
+type refundModule struct {
+	logger         logger.Logger
+	refundStorage  storage.Refund
+	paymentStorage storage.Payment
+}
+
+func Init(logger logger.Logger, refundStorage storage.Refund, paymentStorage storage.Payment) module.Refund {
+	return &refundModule{
+		logger:         logger,
+		refundStorage:  refundStorage,
+		paymentStorage: paymentStorage,
+	}
+}
+
+func (rm *refundModule) CreateRefund(ctx context.Context, merchantID uuid.UUID, req dto.Refund) (dto.Refund, error) {
+	// A payment never changes merchant, so checking ownership before the
+	// refund transaction is enough
+	if _, err := rm.paymentStorage.GetMerchantPaymentByID(ctx, merchantID, req.PaymentID); err != nil {
+		return dto.Refund{}, err
+	}
+
+	refund, err := rm.refundStorage.CreateRefund(ctx, req)
+	if err != nil {
+		return dto.Refund{}, err
+	}
+
+	return refund, nil
+}
+
+func (rm *refundModule) GetRefundsByPaymentID(ctx context.Context, merchantID, paymentID uuid.UUID) ([]dto.Refund, error) {
+	// Surface a 404 for unknown payments instead of an empty list
+	if _, err := rm.paymentStorage.GetMerchantPaymentByID(ctx, merchantID, paymentID); err != nil {
+		return nil, err
+	}
+
+	refunds, err := rm.refundStorage.GetRefundsByPaymentID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return refunds, nil
+}
@@ -0,0 +1,242 @@
+package refund
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/joomcode/errorx"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/kalom60/cashflow/platform/metrics"
+	"github.com/kalom60/cashflow/platform/processor"
+	"github.com/kalom60/cashflow/platform/tracing"
+	"github.com/kalom60/cashflow/platform/workerpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+type RefundWorker struct {
+	logger         logger.Logger
+	pool           *workerpool.WorkerPool
+	refundStorage  storage.Refund
+	paymentStorage storage.Payment
+	msgClient      messaging.MessagingClient
+	processor      processor.PaymentProcessor
+}
+
+func NewRefundWorker(logger logger.Logger, pool *workerpool.WorkerPool, refundStorage storage.Refund, paymentStorage storage.Payment, msgClient messaging.MessagingClient, paymentProcessor processor.PaymentProcessor) *RefundWorker {
+	return &RefundWorker{
+		logger:         logger,
+		pool:           pool,
+		refundStorage:  refundStorage,
+		paymentStorage: paymentStorage,
+		msgClient:      msgClient,
+		processor:      paymentProcessor,
+	}
+}
+
+func (rw *RefundWorker) Start(ctx context.Context) {
+	rw.logger.Info(ctx, "Starting Refund Consumer...")
+
+	msgs, err := rw.msgClient.ConsumeRefunds(ctx)
+	if err != nil {
+		rw.logger.Named("RefundWorker-Start").Fatal(ctx, "failed to start consuming refunds", zap.Error(err))
+	}
+
+	// Cancelling ctx stops new deliveries, but messages already handed to the
+	// pool are processed to completion
+	taskCtx := context.WithoutCancel(ctx)
+
+	go func() {
+		for msg := range msgs {
+			m := msg
+			rw.pool.SubmitContext(taskCtx, func(ctx context.Context) {
+				start := time.Now()
+				rw.processMessage(ctx, metrics.InstrumentMessage(messaging.RefundQueue, m))
+				metrics.ConsumerProcessingDuration.WithLabelValues(messaging.RefundQueue).Observe(time.Since(start).Seconds())
+			})
+		}
+	}()
+}
+
+func (rw *RefundWorker) processMessage(ctx context.Context, msg messaging.Message) {
+	env, err := messaging.DecodeEnvelope(msg)
+	if err != nil {
+		rw.logger.Named("RefundWorker-ProcessMessage").Error(ctx, "failed to decode message envelope", zap.Error(err))
+		_ = msg.Nack(false)
+		return
+	}
+
+	// Worker logs carry the ID of the request that created the payment
+	if env.RequestID != "" {
+		ctx = logger.WithRequestID(ctx, env.RequestID)
+	}
+
+	ctx, span := tracing.StartConsumer(ctx, "process "+messaging.RefundQueue, env.TraceContext(),
+		attribute.String("messaging.message.id", env.ID),
+		attribute.String("messaging.message.type", env.Type),
+		attribute.String("messaging.message.correlation_id", env.CorrelationID),
+		attribute.String("request_id", env.RequestID),
+	)
+	defer span.End()
+
+	switch env.Type {
+	// Messages published before envelopes were introduced have no type
+	case dto.EventRefundCreated, "":
+		rw.processRefund(ctx, env, msg)
+	default:
+		rw.logger.Named("RefundWorker-ProcessMessage").Error(ctx, "unsupported message type", zap.String("type", env.Type), zap.String("message_id", env.ID))
+		_ = msg.Nack(false)
+	}
+}
+
+func (rw *RefundWorker) processRefund(ctx context.Context, env messaging.Envelope, msg messaging.Message) {
+	var body map[string]string
+	if err := json.Unmarshal(env.Data, &body); err != nil {
+		rw.logger.Named("RefundWorker-ProcessMessage").Error(ctx, "failed to unmarshal message body", zap.Error(err))
+		_ = msg.Nack(false)
+		return
+	}
+
+	refundID, err := uuid.Parse(body["refund_id"])
+	if err != nil {
+		rw.logger.Named("RefundWorker-ProcessMessage").Error(ctx, "failed to parse refund_id", zap.String("refund_id", body["refund_id"]), zap.Error(err))
+		_ = msg.Nack(false)
+		return
+	}
+
+	rw.logger.Info(ctx, "Processing refund message", zap.String("refund_id", refundID.String()), zap.String("message_id", env.ID), zap.String("correlation_id", env.CorrelationID), zap.String("causation_id", env.CausationID))
+
+	tx, err := rw.refundStorage.BeginTx(ctx)
+	if err != nil {
+		rw.logger.Named("RefundWorker-ProcessMessage-BeginTx").Error(ctx, "failed to begin transaction", zap.Error(err))
+		rw.retry(ctx, msg, err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	refund, err := rw.refundStorage.GetRefundByIDForUpdate(ctx, tx, refundID)
+	if err != nil {
+		rw.logger.Named("RefundWorker-ProcessMessage-Lock").Error(ctx, "failed to lock refund for update", zap.String("refund_id", refundID.String()), zap.Error(err))
+		rw.retry(ctx, msg, err)
+		return
+	}
+
+	// Idempotency check: only process if PENDING
+	if refund.Status != dto.RefundStatusPending {
+		rw.logger.Info(ctx, "Refund already processed, skipping", zap.String("refund_id", refundID.String()), zap.String("current_status", string(refund.Status)))
+		_ = msg.Ack()
+		return
+	}
+
+	result, err := rw.processor.Refund(ctx, processor.Refund{
+		ID:        refund.ID,
+		PaymentID: refund.PaymentID,
+		Amount:    refund.Amount,
+		Currency:  string(refund.Currency),
+	})
+	if err != nil {
+		rw.logger.Named("RefundWorker-ProcessMessage-Refund").Error(ctx, "payment processor returned an error", zap.String("refund_id", refundID.String()), zap.Error(err))
+		rw.retry(ctx, msg, err)
+		return
+	}
+
+	rw.logger.Info(ctx, "Refund processing result", zap.String("refund_id", refundID.String()), zap.String("outcome", string(result.Outcome)), zap.String("reason_code", result.ReasonCode))
+
+	switch result.Outcome {
+	case processor.OutcomeSuccess:
+		if err := rw.completeRefund(ctx, tx, refund); err != nil {
+			rw.logger.Named("RefundWorker-ProcessMessage-Complete").Error(ctx, "failed to complete refund", zap.String("refund_id", refundID.String()), zap.Error(err))
+			rw.retry(ctx, msg, err)
+			return
+		}
+	case processor.OutcomeFailed:
+		if err := rw.refundStorage.UpdateRefundStatusWithTx(ctx, tx, refundID, dto.RefundStatusFailed, result.ReasonCode); err != nil {
+			rw.logger.Named("RefundWorker-ProcessMessage-UpdateStatus").Error(ctx, "failed to update refund status", zap.String("refund_id", refundID.String()), zap.Error(err))
+			rw.retry(ctx, msg, err)
+			return
+		}
+	default:
+		// Still pending at the processor, leave the refund untouched and retry
+		// later. On the last attempt the message is about to be dead-lettered,
+		// so fail the refund instead of letting it reserve its amount forever.
+		if messaging.Attempt(msg.Headers()) < rw.msgClient.MaxAttempts() {
+			rw.retry(ctx, msg, nil)
+			return
+		}
+
+		if err := rw.refundStorage.UpdateRefundStatusWithTx(ctx, tx, refundID, dto.RefundStatusFailed, dto.RefundFailureStillPending); err != nil {
+			rw.logger.Named("RefundWorker-ProcessMessage-UpdateStatus").Error(ctx, "failed to fail refund still pending after retries", zap.String("refund_id", refundID.String()), zap.Error(err))
+			rw.retry(ctx, msg, err)
+			return
+		}
+		if err := tx.Commit(ctx); err != nil {
+			rw.logger.Named("RefundWorker-ProcessMessage-Commit").Error(ctx, "failed to commit transaction", zap.Error(err))
+			rw.retry(ctx, msg, err)
+			return
+		}
+
+		rw.logger.Warn(ctx, "Refund still pending after the last attempt, marked as failed", zap.String("refund_id", refundID.String()), zap.Int("attempt", messaging.Attempt(msg.Headers())))
+		_ = msg.Nack(false) // Dead-letter
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		rw.logger.Named("RefundWorker-ProcessMessage-Commit").Error(ctx, "failed to commit transaction", zap.Error(err))
+		rw.retry(ctx, msg, err)
+		return
+	}
+
+	if err := msg.Ack(); err != nil {
+		rw.logger.Named("RefundWorker-ProcessMessage-Ack").Error(ctx, "failed to acknowledge message", zap.String("refund_id", refundID.String()), zap.Error(err))
+	}
+}
+
+// retry schedules msg for a delayed redelivery. Errors that will not go away
+// on their own, such as an unknown refund or an illegal transition, are
+// dead-lettered straight away.
+func (rw *RefundWorker) retry(ctx context.Context, msg messaging.Message, cause error) {
+	if cause != nil && (errorx.IsOfType(cause, customErrors.ErrResourceNotFound) || errorx.IsOfType(cause, customErrors.ErrInvalidStatusTransition)) {
+		_ = msg.Nack(false) // Dead-letter
+		return
+	}
+
+	if err := rw.msgClient.RetryRefund(ctx, msg); err != nil {
+		rw.logger.Named("RefundWorker-Retry").Error(ctx, "failed to schedule retry", zap.Int("attempt", messaging.Attempt(msg.Headers())), zap.Error(err))
+	}
+}
+
+// completeRefund marks the refund as successful and moves the parent payment
+// to REFUNDED or PARTIALLY_REFUNDED depending on how much has been returned.
+func (rw *RefundWorker) completeRefund(ctx context.Context, tx pgx.Tx, refund dto.Refund) error {
+	if err := rw.refundStorage.UpdateRefundStatusWithTx(ctx, tx, refund.ID, dto.RefundStatusSuccess, ""); err != nil {
+		return err
+	}
+
+	payment, err := rw.paymentStorage.GetPaymentByIDForUpdate(ctx, tx, refund.PaymentID)
+	if err != nil {
+		return err
+	}
+
+	refunded, err := rw.refundStorage.GetRefundedAmountWithTx(ctx, tx, refund.PaymentID)
+	if err != nil {
+		return err
+	}
+
+	status := dto.PARTIALLY_REFUNDED
+	if refunded.GreaterThanOrEqual(payment.Amount) {
+		status = dto.REFUNDED
+	}
+
+	return rw.paymentStorage.UpdatePaymentStatusWithTx(ctx, tx, payment.ID, dto.PaymentTransition{
+		Status: status,
+		Actor:  dto.ActorRefundWorker,
+		Reason: "refund " + refund.ID.String() + " succeeded",
+	})
+}
@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/platform/backoff"
+)
+
+const (
+	DefaultBaseBackoff = 30 * time.Second
+	DefaultMaxBackoff  = 6 * time.Hour
+	DefaultRetryWindow = 72 * time.Hour
+)
+
+// RetryPolicy decides when a failed delivery is tried again. Deliveries are
+// retried with exponential backoff until Window has passed since they were
+// enqueued.
+type RetryPolicy struct {
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Window      time.Duration
+}
+
+// NewRetryPolicy builds a RetryPolicy, falling back to the defaults for any
+// value that is not positive.
+func NewRetryPolicy(baseBackoff, maxBackoff, window time.Duration) RetryPolicy {
+	if baseBackoff <= 0 {
+		baseBackoff = DefaultBaseBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	if maxBackoff < baseBackoff {
+		maxBackoff = baseBackoff
+	}
+	if window <= 0 {
+		window = DefaultRetryWindow
+	}
+
+	return RetryPolicy{
+		BaseBackoff: baseBackoff,
+		MaxBackoff:  maxBackoff,
+		Window:      window,
+	}
+}
+
+// Backoff returns the delay before the next attempt once attempts deliveries
+// have failed.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	return backoff.Exponential(p.BaseBackoff, p.MaxBackoff, attempts)
+}
+
+// Result records attempt on delivery. A failed delivery stays PENDING until
+// its next attempt would fall after ExpiresAt, then it is marked FAILED.
+func (p RetryPolicy) Result(delivery dto.WebhookDelivery, attempt dto.WebhookAttempt) dto.WebhookDeliveryResult {
+	if attempt.Succeeded() {
+		return dto.WebhookDeliveryResult{
+			Status:        dto.WebhookDeliverySucceeded,
+			Attempt:       attempt,
+			NextAttemptAt: delivery.NextAttemptAt,
+		}
+	}
+
+	next := attempt.At.Add(p.Backoff(delivery.Attempts + 1))
+	if next.After(delivery.ExpiresAt) {
+		return dto.WebhookDeliveryResult{
+			Status:        dto.WebhookDeliveryFailed,
+			Attempt:       attempt,
+			NextAttemptAt: delivery.NextAttemptAt,
+		}
+	}
+
+	return dto.WebhookDeliveryResult{
+		Status:        dto.WebhookDeliveryPending,
+		Attempt:       attempt,
+		NextAttemptAt: next,
+	}
+}
@@ -0,0 +1,47 @@
+package webhook_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/module/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := webhook.NewRetryPolicy(30*time.Second, 5*time.Minute, time.Hour)
+
+	assert.Equal(t, 30*time.Second, policy.Backoff(1))
+	assert.Equal(t, time.Minute, policy.Backoff(2))
+	assert.Equal(t, 2*time.Minute, policy.Backoff(3))
+	assert.Equal(t, 4*time.Minute, policy.Backoff(4))
+	assert.Equal(t, 5*time.Minute, policy.Backoff(5))
+	assert.Equal(t, 5*time.Minute, policy.Backoff(64))
+}
+
+func TestNewRetryPolicyDefaults(t *testing.T) {
+	policy := webhook.NewRetryPolicy(0, 0, 0)
+
+	assert.Equal(t, webhook.DefaultBaseBackoff, policy.BaseBackoff)
+	assert.Equal(t, webhook.DefaultMaxBackoff, policy.MaxBackoff)
+	assert.Equal(t, webhook.DefaultRetryWindow, policy.Window)
+}
+
+func TestRetryPolicyResult(t *testing.T) {
+	policy := webhook.NewRetryPolicy(time.Minute, time.Hour, 72*time.Hour)
+	now := time.Now()
+	delivery := dto.WebhookDelivery{Attempts: 2, NextAttemptAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	result := policy.Result(delivery, dto.WebhookAttempt{StatusCode: 204, At: now})
+	assert.Equal(t, dto.WebhookDeliverySucceeded, result.Status)
+
+	result = policy.Result(delivery, dto.WebhookAttempt{StatusCode: 500, Error: "endpoint responded with status 500", At: now})
+	assert.Equal(t, dto.WebhookDeliveryPending, result.Status)
+	assert.Equal(t, now.Add(4*time.Minute), result.NextAttemptAt)
+
+	// The next attempt would fall outside the retry window
+	delivery.ExpiresAt = now.Add(time.Minute)
+	result = policy.Result(delivery, dto.WebhookAttempt{Error: "connection refused", At: now})
+	assert.Equal(t, dto.WebhookDeliveryFailed, result.Status)
+}
@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/platform/signature"
+)
+
+const (
+	DefaultTimeout = 10 * time.Second
+
+	userAgent = "cashflow-webhooks/1.0"
+
+	// maxResponseBody is how much of a response is read so the connection
+	// can be reused. The body itself is ignored.
+	maxResponseBody = 4 << 10
+)
+
+var errPrivateAddress = errors.New("webhook endpoint resolves to a private address")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598). It is not
+// covered by net.IP.IsPrivate but is just as unreachable from outside.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Sender posts deliveries to merchant endpoints. Every request is signed
+// with the endpoint's secret the same way merchants sign requests to us.
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender returns a Sender whose requests give up after timeout. Unless
+// allowPrivateNetworks is set, endpoints that resolve to loopback, private or
+// link-local addresses are refused so merchants cannot use webhooks to reach
+// our internal network.
+func NewSender(timeout time.Duration, allowPrivateNetworks bool) *Sender {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		dialer.Control = denyPrivateAddress
+	}
+
+	return &Sender{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConnsPerHost: 4,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// A redirect would send the signed payload somewhere the
+			// merchant did not register
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// Send makes one attempt at delivery to endpoint. Any failure, including a
+// non-2xx response, is reported in the returned attempt.
+func (s *Sender) Send(ctx context.Context, endpoint dto.WebhookEndpoint, delivery dto.WebhookDelivery) dto.WebhookAttempt {
+	at := s.now()
+
+	req, err := s.request(ctx, endpoint, delivery, at)
+	if err != nil {
+		return dto.WebhookAttempt{Error: err.Error(), At: at}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return dto.WebhookAttempt{Error: err.Error(), At: at}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	attempt := dto.WebhookAttempt{StatusCode: resp.StatusCode, At: at}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return attempt
+}
+
+func (s *Sender) request(ctx context.Context, endpoint dto.WebhookEndpoint, delivery dto.WebhookDelivery, at time.Time) (*http.Request, error) {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint url: %w", err)
+	}
+
+	nonce := uuid.NewString()
+	sig := signature.Sign(endpoint.Secret, signature.Payload{
+		Method:    http.MethodPost,
+		Path:      u.RequestURI(),
+		Timestamp: at,
+		Nonce:     nonce,
+		Body:      delivery.Payload,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(dto.WebhookIDHeader, delivery.EventID.String())
+	req.Header.Set(dto.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(dto.TimestampHeader, strconv.FormatInt(at.Unix(), 10))
+	req.Header.Set(dto.NonceHeader, nonce)
+	req.Header.Set(dto.SignatureHeader, sig)
+
+	return req, nil
+}
+
+// denyPrivateAddress refuses connections to addresses that are not on the
+// public internet. It runs after DNS resolution so a public name pointing at
+// a private address is caught too.
+func denyPrivateAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip) {
+		return errPrivateAddress
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/kalom60/cashflow/internal/module/webhook"
+	"github.com/kalom60/cashflow/platform/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "whsec_test"
+
+// receiver is a merchant endpoint that verifies the signature of every
+// delivery it receives and answers with status.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	received []dto.WebhookEvent
+	invalid  int
+}
+
+func newReceiver(t *testing.T, status int) (*receiver, *httptest.Server) {
+	r := &receiver{status: status}
+	srv := httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(srv.Close)
+
+	return r, srv
+}
+
+func (r *receiver) serve(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	unix, _ := strconv.ParseInt(req.Header.Get(dto.TimestampHeader), 10, 64)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	valid := signature.Verify([]string{testSecret}, req.Header.Get(dto.SignatureHeader), signature.Payload{
+		Method:    req.Method,
+		Path:      req.URL.RequestURI(),
+		Timestamp: time.Unix(unix, 0),
+		Nonce:     req.Header.Get(dto.NonceHeader),
+		Body:      body,
+	})
+	if !valid {
+		r.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var event dto.WebhookEvent
+	_ = json.Unmarshal(body, &event)
+	r.received = append(r.received, event)
+
+	w.WriteHeader(r.status)
+}
+
+func (r *receiver) events() []dto.WebhookEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]dto.WebhookEvent(nil), r.received...)
+}
+
+func newDelivery(endpointID uuid.UUID) dto.WebhookDelivery {
+	event := dto.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      dto.EventPaymentSucceeded,
+		CreatedAt: time.Now(),
+		Data:      json.RawMessage(`{"status":"SUCCESS"}`),
+	}
+	payload, _ := json.Marshal(event)
+	now := time.Now()
+
+	return dto.WebhookDelivery{
+		ID:            uuid.New(),
+		EndpointID:    endpointID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		Status:        dto.WebhookDeliveryPending,
+		NextAttemptAt: now,
+		ExpiresAt:     now.Add(72 * time.Hour),
+		CreatedAt:     now,
+	}
+}
+
+func TestSenderSignsDeliveries(t *testing.T) {
+	recv, srv := newReceiver(t, http.StatusOK)
+	endpoint := dto.WebhookEndpoint{ID: uuid.New(), URL: srv.URL + "/hooks?source=cashflow", Secret: testSecret}
+	delivery := newDelivery(endpoint.ID)
+
+	attempt := webhook.NewSender(time.Second, true).Send(t.Context(), endpoint, delivery)
+	assert.True(t, attempt.Succeeded(), attempt.Error)
+	assert.Equal(t, http.StatusOK, attempt.StatusCode)
+
+	events := recv.events()
+	require.Len(t, events, 1)
+	assert.Equal(t, delivery.EventID, events[0].ID)
+	assert.Equal(t, dto.EventPaymentSucceeded, events[0].Type)
+}
+
+func TestSenderRejectedSignature(t *testing.T) {
+	recv, srv := newReceiver(t, http.StatusOK)
+	endpoint := dto.WebhookEndpoint{ID: uuid.New(), URL: srv.URL, Secret: "whsec_other"}
+
+	attempt := webhook.NewSender(time.Second, true).Send(t.Context(), endpoint, newDelivery(endpoint.ID))
+	assert.False(t, attempt.Succeeded())
+	assert.Equal(t, http.StatusUnauthorized, attempt.StatusCode)
+	assert.Equal(t, 1, recv.invalid)
+}
+
+func TestSenderReportsErrorStatus(t *testing.T) {
+	_, srv := newReceiver(t, http.StatusInternalServerError)
+	endpoint := dto.WebhookEndpoint{ID: uuid.New(), URL: srv.URL, Secret: testSecret}
+
+	attempt := webhook.NewSender(time.Second, true).Send(t.Context(), endpoint, newDelivery(endpoint.ID))
+	assert.False(t, attempt.Succeeded())
+	assert.Equal(t, http.StatusInternalServerError, attempt.StatusCode)
+	assert.Contains(t, attempt.Error, "500")
+}
+
+func TestSenderDoesNotFollowRedirects(t *testing.T) {
+	recv, target := newReceiver(t, http.StatusOK)
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	t.Cleanup(srv.Close)
+	endpoint := dto.WebhookEndpoint{ID: uuid.New(), URL: srv.URL, Secret: testSecret}
+
+	attempt := webhook.NewSender(time.Second, true).Send(t.Context(), endpoint, newDelivery(endpoint.ID))
+	assert.False(t, attempt.Succeeded())
+	assert.Equal(t, http.StatusTemporaryRedirect, attempt.StatusCode)
+	assert.Empty(t, recv.events())
+}
+
+func TestSenderRefusesPrivateNetworks(t *testing.T) {
+	recv, srv := newReceiver(t, http.StatusOK)
+	endpoint := dto.WebhookEndpoint{ID: uuid.New(), URL: srv.URL, Secret: testSecret}
+
+	attempt := webhook.NewSender(time.Second, false).Send(t.Context(), endpoint, newDelivery(endpoint.ID))
+	assert.False(t, attempt.Succeeded())
+	assert.Zero(t, attempt.StatusCode)
+	assert.Contains(t, attempt.Error, "private address")
+	assert.Empty(t, recv.events())
+}
+
+func TestSenderRefusesSharedAddressSpace(t *testing.T) {
+	for _, url := range []string{"http://100.64.0.1/hook", "http://100.127.255.254/hook", "http://[::ffff:100.64.0.1]/hook"} {
+		endpoint := dto.WebhookEndpoint{ID: uuid.New(), URL: url, Secret: testSecret}
+
+		attempt := webhook.NewSender(time.Second, false).Send(t.Context(), endpoint, newDelivery(endpoint.ID))
+		assert.Contains(t, attempt.Error, "private address", url)
+	}
+}
@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/module"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/platform/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	secretScheme = "whsec_"
+	secretBytes  = 32
+)
+
+type webhookModule struct {
+	logger         logger.Logger
+	webhookStorage storage.Webhook
+	retryPolicy    RetryPolicy
+}
+
+func Init(logger logger.Logger, webhookStorage storage.Webhook, retryPolicy RetryPolicy) module.Webhook {
+	return &webhookModule{
+		logger:         logger,
+		webhookStorage: webhookStorage,
+		retryPolicy:    retryPolicy,
+	}
+}
+
+// CreateWebhookEndpoint registers endpoint with a new signing secret. The
+// returned endpoint is the only place the secret is handed out.
+func (wm *webhookModule) CreateWebhookEndpoint(ctx context.Context, endpoint dto.WebhookEndpoint) (dto.WebhookEndpoint, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		wm.logger.Named("WebhookModule-CreateWebhookEndpoint").Error(ctx, "failed to generate webhook secret", zap.Error(err))
+		return dto.WebhookEndpoint{}, customErrors.ErrInternalServerError.New("failed to generate webhook secret")
+	}
+	endpoint.Secret = secret
+
+	return wm.webhookStorage.CreateWebhookEndpoint(ctx, endpoint)
+}
+
+func (wm *webhookModule) ListWebhookEndpoints(ctx context.Context, merchantID uuid.UUID) ([]dto.WebhookEndpoint, error) {
+	endpoints, err := wm.webhookStorage.ListWebhookEndpoints(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints, nil
+}
+
+// DeleteWebhookEndpoint removes an endpoint together with its delivery log.
+func (wm *webhookModule) DeleteWebhookEndpoint(ctx context.Context, merchantID, id uuid.UUID) error {
+	return wm.webhookStorage.DeleteWebhookEndpoint(ctx, merchantID, id)
+}
+
+// ListWebhookDeliveries returns the most recent deliveries to one of
+// merchantID's endpoints.
+func (wm *webhookModule) ListWebhookDeliveries(ctx context.Context, merchantID uuid.UUID, filter dto.WebhookDeliveryFilter) ([]dto.WebhookDelivery, error) {
+	if _, err := wm.webhookStorage.GetMerchantWebhookEndpointByID(ctx, merchantID, filter.EndpointID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := wm.webhookStorage.ListWebhookDeliveries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RedeliverWebhookDelivery sends a delivery that succeeded or gave up again
+// on the next tick of the worker, with a fresh retry window. Its attempts
+// start over too, so a failure backs off from BaseBackoff again.
+func (wm *webhookModule) RedeliverWebhookDelivery(ctx context.Context, merchantID, id uuid.UUID) (dto.WebhookDelivery, error) {
+	return wm.webhookStorage.RedeliverWebhookDelivery(ctx, merchantID, id, time.Now().Add(wm.retryPolicy.Window))
+}
+
+// EnqueueWebhookEvent queues event for every endpoint of merchantID that is
+// subscribed to its type. Enqueuing the same event twice is a no-op.
+func (wm *webhookModule) EnqueueWebhookEvent(ctx context.Context, merchantID uuid.UUID, event dto.WebhookEvent) error {
+	count, err := wm.webhookStorage.CreateWebhookDeliveries(ctx, merchantID, event, time.Now().Add(wm.retryPolicy.Window))
+	if err != nil {
+		return err
+	}
+
+	wm.logger.Named("WebhookModule-EnqueueWebhookEvent").Debug(ctx, "queued webhook deliveries", zap.Any("event_id", event.ID), zap.String("event_type", event.Type), zap.Int64("deliveries", count))
+
+	return nil
+}
+
+// generateSecret returns a new random secret for signing deliveries.
+func generateSecret() (string, error) {
+	buf := make([]byte, secretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return secretScheme + hex.EncodeToString(buf), nil
+}
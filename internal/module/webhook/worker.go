@@ -0,0 +1,177 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/metrics"
+	"github.com/kalom60/cashflow/platform/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultInterval  = 5 * time.Second
+	DefaultBatchSize = 50
+)
+
+// Settings are the options of the webhook worker.
+type Settings struct {
+	// Interval is how often the worker looks for due deliveries.
+	Interval time.Duration
+	// BatchSize is the most deliveries sent at once.
+	BatchSize int
+	// Timeout bounds a single delivery attempt.
+	Timeout time.Duration
+}
+
+func (s Settings) withDefaults() Settings {
+	if s.Interval <= 0 {
+		s.Interval = DefaultInterval
+	}
+	if s.BatchSize <= 0 {
+		s.BatchSize = DefaultBatchSize
+	}
+	if s.Timeout <= 0 {
+		s.Timeout = DefaultTimeout
+	}
+
+	return s
+}
+
+// WebhookWorker sends due deliveries to merchant endpoints and records the
+// outcome of every attempt.
+type WebhookWorker struct {
+	logger         logger.Logger
+	webhookStorage storage.Webhook
+	sender         *Sender
+	retryPolicy    RetryPolicy
+	settings       Settings
+	done           chan struct{}
+}
+
+func NewWebhookWorker(logger logger.Logger, webhookStorage storage.Webhook, sender *Sender, retryPolicy RetryPolicy, settings Settings) *WebhookWorker {
+	return &WebhookWorker{
+		logger:         logger,
+		webhookStorage: webhookStorage,
+		sender:         sender,
+		retryPolicy:    retryPolicy,
+		settings:       settings.withDefaults(),
+		done:           make(chan struct{}),
+	}
+}
+
+// Start sends due deliveries until ctx is cancelled. A batch that is already
+// being sent is finished before Start returns.
+func (w *WebhookWorker) Start(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.settings.Interval)
+	defer ticker.Stop()
+
+	batchCtx := context.WithoutCancel(ctx)
+
+	w.logger.Info(ctx, "Starting Webhook Worker...")
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info(ctx, "Stopping Webhook Worker...")
+			return
+		case <-ticker.C:
+			w.processDeliveries(batchCtx)
+		}
+	}
+}
+
+// Done is closed once Start has returned.
+func (w *WebhookWorker) Done() <-chan struct{} {
+	return w.done
+}
+
+// processDeliveries claims the due deliveries and sends them concurrently.
+// Claimed deliveries are leased rather than locked, so no transaction is held
+// open while merchant endpoints respond. A delivery whose attempt is never
+// recorded becomes due again once its lease runs out.
+func (w *WebhookWorker) processDeliveries(ctx context.Context) {
+	now := time.Now()
+	lease := now.Add(w.settings.Timeout + time.Minute)
+
+	deliveries, err := w.webhookStorage.ClaimDueWebhookDeliveries(ctx, now, lease, w.settings.BatchSize)
+	if err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *WebhookWorker) deliver(ctx context.Context, delivery dto.WebhookDelivery) {
+	ctx, span := tracing.Tracer().Start(ctx, "deliver "+delivery.EventType,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("webhook.delivery_id", delivery.ID.String()),
+			attribute.String("webhook.event_id", delivery.EventID.String()),
+			attribute.Int("webhook.attempt", delivery.Attempts+1),
+		),
+	)
+	defer span.End()
+
+	endpoint, err := w.webhookStorage.GetWebhookEndpointByID(ctx, delivery.EndpointID)
+	if err != nil {
+		// The endpoint was deleted after the delivery was claimed and took
+		// the delivery with it
+		if errorx.IsOfType(err, customErrors.ErrResourceNotFound) {
+			return
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to load endpoint")
+		return
+	}
+
+	start := time.Now()
+	attempt := w.sender.Send(ctx, endpoint, delivery)
+	metrics.WebhookDeliveryDuration.WithLabelValues(delivery.EventType).Observe(time.Since(start).Seconds())
+
+	result := w.retryPolicy.Result(delivery, attempt)
+	metrics.WebhookDeliveries.WithLabelValues(delivery.EventType, outcome(result)).Inc()
+
+	if attempt.StatusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", attempt.StatusCode))
+	}
+	if !attempt.Succeeded() {
+		span.SetStatus(codes.Error, attempt.Error)
+		w.logger.Named("WebhookWorker-Deliver").Warn(ctx, "webhook delivery failed", zap.Any("delivery_id", delivery.ID), zap.Any("endpoint_id", delivery.EndpointID), zap.String("event_type", delivery.EventType), zap.Int("attempt", delivery.Attempts+1), zap.String("status", string(result.Status)), zap.String("error", attempt.Error))
+	}
+
+	if _, err := w.webhookStorage.RecordWebhookDeliveryAttempt(ctx, delivery.ID, result); err != nil {
+		span.RecordError(err)
+	}
+}
+
+// outcome labels result for the deliveries counter.
+func outcome(result dto.WebhookDeliveryResult) string {
+	switch result.Status {
+	case dto.WebhookDeliverySucceeded:
+		return "succeeded"
+	case dto.WebhookDeliveryFailed:
+		return "failed"
+	default:
+		return "retry"
+	}
+}
@@ -0,0 +1,172 @@
+package webhook_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/module/webhook"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/tests/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore keeps deliveries in memory. Only the methods the worker uses
+// are implemented.
+type memoryStore struct {
+	storage.Webhook
+
+	mu         sync.Mutex
+	endpoints  map[uuid.UUID]dto.WebhookEndpoint
+	deliveries map[uuid.UUID]dto.WebhookDelivery
+	recorded   chan dto.WebhookDelivery
+}
+
+func newMemoryStore(endpoint dto.WebhookEndpoint, deliveries ...dto.WebhookDelivery) *memoryStore {
+	s := &memoryStore{
+		endpoints:  map[uuid.UUID]dto.WebhookEndpoint{endpoint.ID: endpoint},
+		deliveries: make(map[uuid.UUID]dto.WebhookDelivery),
+		recorded:   make(chan dto.WebhookDelivery, 16),
+	}
+	for _, delivery := range deliveries {
+		s.deliveries[delivery.ID] = delivery
+	}
+
+	return s
+}
+
+func (s *memoryStore) ClaimDueWebhookDeliveries(_ context.Context, now, leaseUntil time.Time, limit int) ([]dto.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []dto.WebhookDelivery
+	for id, delivery := range s.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if delivery.Status != dto.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		delivery.NextAttemptAt = leaseUntil
+		s.deliveries[id] = delivery
+		claimed = append(claimed, delivery)
+	}
+
+	return claimed, nil
+}
+
+func (s *memoryStore) GetWebhookEndpointByID(_ context.Context, id uuid.UUID) (dto.WebhookEndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endpoint, ok := s.endpoints[id]
+	if !ok {
+		return dto.WebhookEndpoint{}, customErrors.ErrResourceNotFound.New("webhook endpoint not found")
+	}
+
+	return endpoint, nil
+}
+
+func (s *memoryStore) RecordWebhookDeliveryAttempt(_ context.Context, id uuid.UUID, result dto.WebhookDeliveryResult) (dto.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery := s.deliveries[id]
+	delivery.Status = result.Status
+	delivery.Attempts++
+	delivery.LastError = result.Attempt.Error
+	delivery.NextAttemptAt = result.NextAttemptAt
+	if result.Attempt.StatusCode != 0 {
+		code := result.Attempt.StatusCode
+		delivery.LastStatusCode = &code
+	}
+	s.deliveries[id] = delivery
+	s.recorded <- delivery
+
+	return delivery, nil
+}
+
+func startWorker(t *testing.T, store storage.Webhook, policy webhook.RetryPolicy) {
+	ctx, cancel := context.WithCancel(t.Context())
+	worker := webhook.NewWebhookWorker(testutils.NewTestLogger(), store, webhook.NewSender(time.Second, true), policy, webhook.Settings{
+		Interval:  10 * time.Millisecond,
+		BatchSize: 10,
+		Timeout:   time.Second,
+	})
+	go worker.Start(ctx)
+
+	t.Cleanup(func() {
+		cancel()
+		<-worker.Done()
+	})
+}
+
+func waitForAttempt(t *testing.T, store *memoryStore) dto.WebhookDelivery {
+	t.Helper()
+
+	select {
+	case delivery := <-store.recorded:
+		return delivery
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery attempt was recorded")
+		return dto.WebhookDelivery{}
+	}
+}
+
+func TestWorkerDeliversToReceiver(t *testing.T) {
+	recv, srv := newReceiver(t, http.StatusOK)
+	endpoint := dto.WebhookEndpoint{ID: uuid.New(), URL: srv.URL, Secret: testSecret}
+	delivery := newDelivery(endpoint.ID)
+	store := newMemoryStore(endpoint, delivery)
+
+	startWorker(t, store, webhook.NewRetryPolicy(time.Minute, time.Hour, 72*time.Hour))
+
+	recorded := waitForAttempt(t, store)
+	assert.Equal(t, dto.WebhookDeliverySucceeded, recorded.Status)
+	assert.Equal(t, 1, recorded.Attempts)
+
+	events := recv.events()
+	require.Len(t, events, 1)
+	assert.Equal(t, delivery.EventID, events[0].ID)
+}
+
+func TestWorkerRetriesFailedDeliveries(t *testing.T) {
+	recv, srv := newReceiver(t, http.StatusServiceUnavailable)
+	endpoint := dto.WebhookEndpoint{ID: uuid.New(), URL: srv.URL, Secret: testSecret}
+	store := newMemoryStore(endpoint, newDelivery(endpoint.ID))
+
+	startWorker(t, store, webhook.NewRetryPolicy(20*time.Millisecond, time.Second, 72*time.Hour))
+
+	first := waitForAttempt(t, store)
+	assert.Equal(t, dto.WebhookDeliveryPending, first.Status)
+	require.NotNil(t, first.LastStatusCode)
+	assert.Equal(t, http.StatusServiceUnavailable, *first.LastStatusCode)
+
+	recv.mu.Lock()
+	recv.status = http.StatusOK
+	recv.mu.Unlock()
+
+	second := waitForAttempt(t, store)
+	assert.Equal(t, dto.WebhookDeliverySucceeded, second.Status)
+	assert.Equal(t, 2, second.Attempts)
+}
+
+func TestWorkerGivesUpAfterRetryWindow(t *testing.T) {
+	_, srv := newReceiver(t, http.StatusInternalServerError)
+	endpoint := dto.WebhookEndpoint{ID: uuid.New(), URL: srv.URL, Secret: testSecret}
+	delivery := newDelivery(endpoint.ID)
+	delivery.ExpiresAt = time.Now().Add(time.Second)
+	store := newMemoryStore(endpoint, delivery)
+
+	startWorker(t, store, webhook.NewRetryPolicy(time.Minute, time.Hour, 72*time.Hour))
+
+	recorded := waitForAttempt(t, store)
+	assert.Equal(t, dto.WebhookDeliveryFailed, recorded.Status)
+	assert.Contains(t, recorded.LastError, "500")
+}
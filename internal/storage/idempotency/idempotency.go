@@ -0,0 +1,134 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/constant/model/db"
+	"github.com/kalom60/cashflow/internal/constant/model/persistencedb"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/platform/logger"
+	"go.uber.org/zap"
+)
+
+type idempotencyStore struct {
+	logger        logger.Logger
+	persistencedb *persistencedb.PersistenceDB
+}
+
+func Init(logger logger.Logger, persistencedb *persistencedb.PersistenceDB) storage.Idempotency {
+	return &idempotencyStore{
+		logger:        logger,
+		persistencedb: persistencedb,
+	}
+}
+
+// CreateIdempotencyKey claims key for a new request. A key created before
+// expiredBefore has expired and is claimed afresh. It returns
+// ErrIdempotencyKeyInUse when the key has already been claimed.
+func (is *idempotencyStore) CreateIdempotencyKey(ctx context.Context, key, requestHash string, expiredBefore time.Time) (dto.IdempotencyKey, error) {
+	row, err := is.persistencedb.Queries.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+		Key:           key,
+		RequestHash:   requestHash,
+		CreatedAt:     time.Now(),
+		ExpiredBefore: expiredBefore,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.IdempotencyKey{}, customErrors.ErrIdempotencyKeyInUse.New("idempotency key already exists")
+		}
+		is.logger.Named("IdempotencyStore-CreateIdempotencyKey").Error(ctx, "failed to insert idempotency key", zap.String("key", key), zap.Error(err))
+		return dto.IdempotencyKey{}, customErrors.ErrUnableToCreate.New("failed to save idempotency key")
+	}
+
+	return toIdempotencyKey(row), nil
+}
+
+func (is *idempotencyStore) GetIdempotencyKey(ctx context.Context, key string) (dto.IdempotencyKey, error) {
+	row, err := is.persistencedb.Queries.GetIdempotencyKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.IdempotencyKey{}, customErrors.ErrResourceNotFound.New("idempotency key not found")
+		}
+		is.logger.Named("IdempotencyStore-GetIdempotencyKey").Error(ctx, "failed to get idempotency key", zap.String("key", key), zap.Error(err))
+		return dto.IdempotencyKey{}, customErrors.ErrUnableToGet.New("failed to get idempotency key")
+	}
+
+	return toIdempotencyKey(row), nil
+}
+
+// ReclaimIdempotencyKey takes over an IN_PROGRESS key whose owner has not
+// touched it since staleBefore, e.g. because the process crashed mid-request.
+func (is *idempotencyStore) ReclaimIdempotencyKey(ctx context.Context, key string, staleBefore time.Time) (dto.IdempotencyKey, error) {
+	row, err := is.persistencedb.Queries.ReclaimIdempotencyKey(ctx, db.ReclaimIdempotencyKeyParams{
+		UpdatedAt:   time.Now(),
+		Key:         key,
+		StaleBefore: staleBefore,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.IdempotencyKey{}, customErrors.ErrIdempotencyKeyInUse.New("a request with this idempotency key is already in progress")
+		}
+		is.logger.Named("IdempotencyStore-ReclaimIdempotencyKey").Error(ctx, "failed to reclaim idempotency key", zap.String("key", key), zap.Error(err))
+		return dto.IdempotencyKey{}, customErrors.ErrUnableToUpdate.New("failed to reclaim idempotency key")
+	}
+
+	return toIdempotencyKey(row), nil
+}
+
+func (is *idempotencyStore) CompleteIdempotencyKey(ctx context.Context, key string, responseCode int, responseBody []byte) error {
+	rowsAffected, err := is.persistencedb.Queries.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		Key:          key,
+		ResponseCode: int32(responseCode),
+		ResponseBody: responseBody,
+		UpdatedAt:    time.Now(),
+	})
+	if err != nil {
+		is.logger.Named("IdempotencyStore-CompleteIdempotencyKey").Error(ctx, "failed to complete idempotency key", zap.String("key", key), zap.Error(err))
+		return customErrors.ErrUnableToUpdate.New("failed to complete idempotency key")
+	}
+
+	if rowsAffected == 0 {
+		return customErrors.ErrResourceNotFound.New("idempotency key not found")
+	}
+
+	return nil
+}
+
+func (is *idempotencyStore) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	if _, err := is.persistencedb.Queries.DeleteIdempotencyKey(ctx, key); err != nil {
+		is.logger.Named("IdempotencyStore-DeleteIdempotencyKey").Error(ctx, "failed to delete idempotency key", zap.String("key", key), zap.Error(err))
+		return customErrors.ErrUnableToUpdate.New("failed to delete idempotency key")
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes the keys created before expiredBefore
+// and returns how many there were.
+func (is *idempotencyStore) DeleteExpiredIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	deleted, err := is.persistencedb.Queries.DeleteExpiredIdempotencyKeys(ctx, expiredBefore)
+	if err != nil {
+		is.logger.Named("IdempotencyStore-DeleteExpiredIdempotencyKeys").Error(ctx, "failed to delete expired idempotency keys", zap.Error(err))
+		return 0, customErrors.ErrUnableToUpdate.New("failed to delete expired idempotency keys")
+	}
+
+	return deleted, nil
+}
+
+func toIdempotencyKey(row db.IdempotencyKey) dto.IdempotencyKey {
+	return dto.IdempotencyKey{
+		Key:          row.Key,
+		RequestHash:  row.RequestHash,
+		Status:       dto.IdempotencyStatus(row.Status),
+		ResponseCode: int(row.ResponseCode),
+		ResponseBody: row.ResponseBody,
+		CreatedAt:    row.CreatedAt,
+		UpdatedAt:    row.UpdatedAt,
+	}
+}
@@ -0,0 +1,226 @@
+package merchant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/constant/model/db"
+	"github.com/kalom60/cashflow/internal/constant/model/persistencedb"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/platform/logger"
+	"go.uber.org/zap"
+)
+
+type merchantStore struct {
+	logger        logger.Logger
+	persistencedb *persistencedb.PersistenceDB
+}
+
+func Init(logger logger.Logger, persistencedb *persistencedb.PersistenceDB) storage.Merchant {
+	return &merchantStore{
+		logger:        logger,
+		persistencedb: persistencedb,
+	}
+}
+
+// CreateMerchant creates merchant together with its first API key, so a
+// merchant never exists without a way to authenticate.
+func (ms *merchantStore) CreateMerchant(ctx context.Context, merchant dto.Merchant, key dto.APIKey) (dto.Merchant, dto.APIKey, error) {
+	tx, err := ms.persistencedb.Pool.Begin(ctx)
+	if err != nil {
+		ms.logger.Named("MerchantStore-CreateMerchant-BeginTx").Error(ctx, "failed to start transaction", zap.Error(err))
+		return dto.Merchant{}, dto.APIKey{}, customErrors.ErrUnableToCreate.New("database transaction failed")
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ms.persistencedb.Queries.WithTx(tx)
+
+	row, err := qtx.CreateMerchant(ctx, db.CreateMerchantParams{
+		Name:      merchant.Name,
+		CreatedAt: merchant.CreatedAt,
+	})
+	if err != nil {
+		ms.logger.Named("MerchantStore-CreateMerchant-InsertMerchant").Error(ctx, "failed to insert merchant", zap.Error(err))
+		return dto.Merchant{}, dto.APIKey{}, customErrors.ErrUnableToCreate.New("failed to save merchant")
+	}
+
+	key.MerchantID = row.ID
+	created, err := ms.createAPIKey(ctx, qtx, key)
+	if err != nil {
+		return dto.Merchant{}, dto.APIKey{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		ms.logger.Named("MerchantStore-CreateMerchant-Commit").Error(ctx, "failed to commit transaction", zap.Error(err))
+		return dto.Merchant{}, dto.APIKey{}, customErrors.ErrUnableToCreate.New("final database commit failed")
+	}
+
+	return dto.Merchant{
+		ID:        row.ID,
+		Name:      row.Name,
+		CreatedAt: persistencedb.LocalTime(row.CreatedAt),
+		UpdatedAt: persistencedb.LocalTime(row.UpdatedAt),
+	}, created, nil
+}
+
+func (ms *merchantStore) CreateAPIKey(ctx context.Context, key dto.APIKey) (dto.APIKey, error) {
+	return ms.createAPIKey(ctx, ms.persistencedb.Queries, key)
+}
+
+func (ms *merchantStore) createAPIKey(ctx context.Context, qtx *db.Queries, key dto.APIKey) (dto.APIKey, error) {
+	row, err := qtx.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		MerchantID: key.MerchantID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		KeyHash:    key.KeyHash,
+		CreatedAt:  key.CreatedAt,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return dto.APIKey{}, customErrors.ErrResourceNotFound.New("merchant not found")
+		}
+		ms.logger.Named("MerchantStore-CreateAPIKey").Error(ctx, "failed to insert api key", zap.Any("merchant_id", key.MerchantID), zap.Error(err))
+		return dto.APIKey{}, customErrors.ErrUnableToCreate.New("failed to save api key")
+	}
+
+	return toAPIKey(row), nil
+}
+
+func (ms *merchantStore) GetAPIKeyByPrefix(ctx context.Context, prefix string) (dto.APIKey, error) {
+	row, err := ms.persistencedb.Queries.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.APIKey{}, customErrors.ErrResourceNotFound.New("api key not found")
+		}
+		ms.logger.Named("MerchantStore-GetAPIKeyByPrefix").Error(ctx, "failed to get api key", zap.String("prefix", prefix), zap.Error(err))
+		return dto.APIKey{}, customErrors.ErrUnableToGet.New("failed to get api key")
+	}
+
+	return toAPIKey(row), nil
+}
+
+func (ms *merchantStore) ListAPIKeys(ctx context.Context, merchantID uuid.UUID) ([]dto.APIKey, error) {
+	rows, err := ms.persistencedb.Queries.ListMerchantAPIKeys(ctx, merchantID)
+	if err != nil {
+		ms.logger.Named("MerchantStore-ListAPIKeys").Error(ctx, "failed to list api keys", zap.Any("merchant_id", merchantID), zap.Error(err))
+		return nil, customErrors.ErrUnableToGet.New("failed to list api keys")
+	}
+
+	keys := make([]dto.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, toAPIKey(row))
+	}
+
+	return keys, nil
+}
+
+// RotateAPIKey issues next in place of the key id and lets the old key keep
+// working for overlap, so clients can switch over without downtime. The old
+// key never outlives an expiry it already had. It returns the old key and
+// the new one.
+func (ms *merchantStore) RotateAPIKey(ctx context.Context, merchantID, id uuid.UUID, next dto.APIKey, overlap time.Duration) (dto.APIKey, dto.APIKey, error) {
+	tx, err := ms.persistencedb.Pool.Begin(ctx)
+	if err != nil {
+		ms.logger.Named("MerchantStore-RotateAPIKey-BeginTx").Error(ctx, "failed to start transaction", zap.Error(err))
+		return dto.APIKey{}, dto.APIKey{}, customErrors.ErrUnableToUpdate.New("database transaction failed")
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ms.persistencedb.Queries.WithTx(tx)
+
+	row, err := qtx.GetMerchantAPIKeyForUpdate(ctx, db.GetMerchantAPIKeyForUpdateParams{
+		ID:         id,
+		MerchantID: merchantID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.APIKey{}, dto.APIKey{}, customErrors.ErrResourceNotFound.New("api key not found")
+		}
+		ms.logger.Named("MerchantStore-RotateAPIKey-GetKey").Error(ctx, "failed to get api key for update", zap.Any("id", id), zap.Error(err))
+		return dto.APIKey{}, dto.APIKey{}, customErrors.ErrUnableToGet.New("failed to get api key")
+	}
+
+	now := time.Now()
+	current := toAPIKey(row)
+	if !current.Active(now) {
+		return dto.APIKey{}, dto.APIKey{}, customErrors.ErrAPIKeyInactive.New("only an active api key can be rotated")
+	}
+
+	expiresAt := now.Add(overlap)
+	if current.ExpiresAt != nil && current.ExpiresAt.Before(expiresAt) {
+		expiresAt = *current.ExpiresAt
+	}
+
+	row, err = qtx.ExpireAPIKey(ctx, db.ExpireAPIKeyParams{
+		ID:        id,
+		ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		ms.logger.Named("MerchantStore-RotateAPIKey-Expire").Error(ctx, "failed to expire api key", zap.Any("id", id), zap.Error(err))
+		return dto.APIKey{}, dto.APIKey{}, customErrors.ErrUnableToUpdate.New("failed to update api key")
+	}
+
+	next.MerchantID = merchantID
+	next.Name = current.Name
+	created, err := ms.createAPIKey(ctx, qtx, next)
+	if err != nil {
+		return dto.APIKey{}, dto.APIKey{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		ms.logger.Named("MerchantStore-RotateAPIKey-Commit").Error(ctx, "failed to commit transaction", zap.Error(err))
+		return dto.APIKey{}, dto.APIKey{}, customErrors.ErrUnableToUpdate.New("final database commit failed")
+	}
+
+	return toAPIKey(row), created, nil
+}
+
+// RevokeAPIKey stops the key from working straight away. Revoking a key
+// twice keeps the original revocation time.
+func (ms *merchantStore) RevokeAPIKey(ctx context.Context, merchantID, id uuid.UUID) (dto.APIKey, error) {
+	row, err := ms.persistencedb.Queries.RevokeAPIKey(ctx, db.RevokeAPIKeyParams{
+		ID:         id,
+		MerchantID: merchantID,
+		RevokedAt:  sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.APIKey{}, customErrors.ErrResourceNotFound.New("api key not found")
+		}
+		ms.logger.Named("MerchantStore-RevokeAPIKey").Error(ctx, "failed to revoke api key", zap.Any("id", id), zap.Error(err))
+		return dto.APIKey{}, customErrors.ErrUnableToUpdate.New("failed to revoke api key")
+	}
+
+	return toAPIKey(row), nil
+}
+
+// api_keys timestamps are local wall-clock times, like payments' created_at,
+// and are compared with time.Now() to tell whether a key is active.
+func toAPIKey(row db.ApiKey) dto.APIKey {
+	key := dto.APIKey{
+		ID:         row.ID,
+		MerchantID: row.MerchantID,
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		KeyHash:    row.KeyHash,
+		CreatedAt:  persistencedb.LocalTime(row.CreatedAt),
+	}
+	if row.ExpiresAt.Valid {
+		expiresAt := persistencedb.LocalTime(row.ExpiresAt.Time)
+		key.ExpiresAt = &expiresAt
+	}
+	if row.RevokedAt.Valid {
+		revokedAt := persistencedb.LocalTime(row.RevokedAt.Time)
+		key.RevokedAt = &revokedAt
+	}
+
+	return key
+}
@@ -0,0 +1,180 @@
+package merchant_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joomcode/errorx"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/internal/storage/merchant"
+	"github.com/kalom60/cashflow/tests/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	ctx   context.Context
+	store storage.Merchant
+
+	merchantID uuid.UUID
+	keyID      uuid.UUID
+)
+
+func TestMain(m *testing.M) {
+	ctx = context.Background()
+	testDB := testutils.SetupTestDB()
+	store = merchant.Init(testutils.NewTestLogger(), &testDB)
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+func newKey(prefix string) dto.APIKey {
+	return dto.APIKey{
+		Name:      "default",
+		Prefix:    prefix,
+		KeyHash:   "hash-" + prefix,
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestCreateMerchant(t *testing.T) {
+	created, key, err := store.CreateMerchant(ctx, dto.Merchant{Name: "acme", CreatedAt: time.Now()}, newKey("aaaaaaaaaaaa"))
+	require.NoError(t, err)
+	assert.Equal(t, "acme", created.Name)
+	assert.Equal(t, created.ID, key.MerchantID)
+	assert.True(t, key.Active(time.Now()))
+
+	merchantID = created.ID
+	keyID = key.ID
+}
+
+func TestGetAPIKeyByPrefix(t *testing.T) {
+	key, err := store.GetAPIKeyByPrefix(ctx, "aaaaaaaaaaaa")
+	require.NoError(t, err)
+	assert.Equal(t, keyID, key.ID)
+	assert.Equal(t, "hash-aaaaaaaaaaaa", key.KeyHash)
+
+	_, err = store.GetAPIKeyByPrefix(ctx, "unknown")
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+}
+
+func TestCreateAPIKeyForUnknownMerchant(t *testing.T) {
+	key := newKey("bbbbbbbbbbbb")
+	key.MerchantID = uuid.New()
+
+	_, err := store.CreateAPIKey(ctx, key)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+}
+
+func TestRotateAPIKeyKeepsOldKeyDuringOverlap(t *testing.T) {
+	previous, current, err := store.RotateAPIKey(ctx, merchantID, keyID, newKey("cccccccccccc"), time.Hour)
+	require.NoError(t, err)
+
+	now := time.Now()
+	assert.Equal(t, keyID, previous.ID)
+	require.NotNil(t, previous.ExpiresAt)
+	assert.WithinDuration(t, now.Add(time.Hour), *previous.ExpiresAt, time.Minute)
+	assert.True(t, previous.Active(now))
+	assert.False(t, previous.Active(now.Add(2*time.Hour)))
+
+	assert.Equal(t, merchantID, current.MerchantID)
+	assert.Equal(t, "default", current.Name)
+	assert.Nil(t, current.ExpiresAt)
+
+	keys, err := store.ListAPIKeys(ctx, merchantID)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+}
+
+func TestRotateAPIKeyNeverExtendsExpiry(t *testing.T) {
+	previous, _, err := store.RotateAPIKey(ctx, merchantID, keyID, newKey("dddddddddddd"), 24*time.Hour)
+	require.NoError(t, err)
+
+	require.NotNil(t, previous.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *previous.ExpiresAt, time.Minute)
+}
+
+func TestRotateAPIKeyOfAnotherMerchant(t *testing.T) {
+	_, _, err := store.RotateAPIKey(ctx, uuid.New(), keyID, newKey("eeeeeeeeeeee"), time.Hour)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	_, err := store.RevokeAPIKey(ctx, uuid.New(), keyID)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+
+	revoked, err := store.RevokeAPIKey(ctx, merchantID, keyID)
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+	assert.False(t, revoked.Active(time.Now()))
+
+	again, err := store.RevokeAPIKey(ctx, merchantID, keyID)
+	require.NoError(t, err)
+	assert.Equal(t, revoked.RevokedAt, again.RevokedAt)
+
+	_, _, err = store.RotateAPIKey(ctx, merchantID, keyID, newKey("ffffffffffff"), time.Hour)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrAPIKeyInactive))
+}
+
+func newSigningSecret(secret string) dto.SigningSecret {
+	return dto.SigningSecret{
+		MerchantID: merchantID,
+		Name:       "partner",
+		Secret:     secret,
+		CreatedAt:  time.Now(),
+	}
+}
+
+func TestSigningSecretLifecycle(t *testing.T) {
+	created, err := store.CreateSigningSecret(ctx, newSigningSecret("secret-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "secret-1", created.Secret)
+
+	active, err := store.ListActiveSigningSecrets(ctx, merchantID, time.Now())
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, created.ID, active[0].ID)
+
+	previous, current, err := store.RotateSigningSecret(ctx, merchantID, created.ID, newSigningSecret("secret-2"), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, previous.ID)
+	assert.Equal(t, "partner", current.Name)
+
+	now := time.Now()
+	active, err = store.ListActiveSigningSecrets(ctx, merchantID, now)
+	require.NoError(t, err)
+	assert.Len(t, active, 2, "both secrets verify during the overlap")
+
+	active, err = store.ListActiveSigningSecrets(ctx, merchantID, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, current.ID, active[0].ID)
+
+	_, err = store.RevokeSigningSecret(ctx, uuid.New(), current.ID)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+
+	revoked, err := store.RevokeSigningSecret(ctx, merchantID, current.ID)
+	require.NoError(t, err)
+	assert.False(t, revoked.Active(now))
+
+	_, _, err = store.RotateSigningSecret(ctx, merchantID, current.ID, newSigningSecret("secret-3"), time.Hour)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrSigningSecretInactive))
+
+	all, err := store.ListSigningSecrets(ctx, merchantID)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+func TestCreateSigningSecretForUnknownMerchant(t *testing.T) {
+	secret := newSigningSecret("secret")
+	secret.MerchantID = uuid.New()
+
+	_, err := store.CreateSigningSecret(ctx, secret)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+}
@@ -0,0 +1,175 @@
+package merchant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/constant/model/db"
+	"github.com/kalom60/cashflow/internal/constant/model/persistencedb"
+	"go.uber.org/zap"
+)
+
+func (ms *merchantStore) CreateSigningSecret(ctx context.Context, secret dto.SigningSecret) (dto.SigningSecret, error) {
+	return ms.createSigningSecret(ctx, ms.persistencedb.Queries, secret)
+}
+
+func (ms *merchantStore) createSigningSecret(ctx context.Context, qtx *db.Queries, secret dto.SigningSecret) (dto.SigningSecret, error) {
+	row, err := qtx.CreateSigningSecret(ctx, db.CreateSigningSecretParams{
+		MerchantID: secret.MerchantID,
+		Name:       secret.Name,
+		Secret:     secret.Secret,
+		CreatedAt:  secret.CreatedAt,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return dto.SigningSecret{}, customErrors.ErrResourceNotFound.New("merchant not found")
+		}
+		ms.logger.Named("MerchantStore-CreateSigningSecret").Error(ctx, "failed to insert signing secret", zap.Any("merchant_id", secret.MerchantID), zap.Error(err))
+		return dto.SigningSecret{}, customErrors.ErrUnableToCreate.New("failed to save signing secret")
+	}
+
+	return toSigningSecret(row), nil
+}
+
+func (ms *merchantStore) ListSigningSecrets(ctx context.Context, merchantID uuid.UUID) ([]dto.SigningSecret, error) {
+	rows, err := ms.persistencedb.Queries.ListMerchantSigningSecrets(ctx, merchantID)
+	if err != nil {
+		ms.logger.Named("MerchantStore-ListSigningSecrets").Error(ctx, "failed to list signing secrets", zap.Any("merchant_id", merchantID), zap.Error(err))
+		return nil, customErrors.ErrUnableToGet.New("failed to list signing secrets")
+	}
+
+	return toSigningSecrets(rows), nil
+}
+
+// ListActiveSigningSecrets returns the secrets of merchantID that still
+// verify signatures at now.
+func (ms *merchantStore) ListActiveSigningSecrets(ctx context.Context, merchantID uuid.UUID, now time.Time) ([]dto.SigningSecret, error) {
+	rows, err := ms.persistencedb.Queries.ListActiveMerchantSigningSecrets(ctx, db.ListActiveMerchantSigningSecretsParams{
+		MerchantID: merchantID,
+		Now:        now,
+	})
+	if err != nil {
+		ms.logger.Named("MerchantStore-ListActiveSigningSecrets").Error(ctx, "failed to list active signing secrets", zap.Any("merchant_id", merchantID), zap.Error(err))
+		return nil, customErrors.ErrUnableToGet.New("failed to list signing secrets")
+	}
+
+	return toSigningSecrets(rows), nil
+}
+
+// RotateSigningSecret stores next in place of the secret id and lets the old
+// secret keep verifying for overlap, the same way RotateAPIKey does. It
+// returns the old secret and the new one.
+func (ms *merchantStore) RotateSigningSecret(ctx context.Context, merchantID, id uuid.UUID, next dto.SigningSecret, overlap time.Duration) (dto.SigningSecret, dto.SigningSecret, error) {
+	tx, err := ms.persistencedb.Pool.Begin(ctx)
+	if err != nil {
+		ms.logger.Named("MerchantStore-RotateSigningSecret-BeginTx").Error(ctx, "failed to start transaction", zap.Error(err))
+		return dto.SigningSecret{}, dto.SigningSecret{}, customErrors.ErrUnableToUpdate.New("database transaction failed")
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ms.persistencedb.Queries.WithTx(tx)
+
+	row, err := qtx.GetMerchantSigningSecretForUpdate(ctx, db.GetMerchantSigningSecretForUpdateParams{
+		ID:         id,
+		MerchantID: merchantID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.SigningSecret{}, dto.SigningSecret{}, customErrors.ErrResourceNotFound.New("signing secret not found")
+		}
+		ms.logger.Named("MerchantStore-RotateSigningSecret-GetSecret").Error(ctx, "failed to get signing secret for update", zap.Any("id", id), zap.Error(err))
+		return dto.SigningSecret{}, dto.SigningSecret{}, customErrors.ErrUnableToGet.New("failed to get signing secret")
+	}
+
+	now := time.Now()
+	current := toSigningSecret(row)
+	if !current.Active(now) {
+		return dto.SigningSecret{}, dto.SigningSecret{}, customErrors.ErrSigningSecretInactive.New("only an active signing secret can be rotated")
+	}
+
+	expiresAt := now.Add(overlap)
+	if current.ExpiresAt != nil && current.ExpiresAt.Before(expiresAt) {
+		expiresAt = *current.ExpiresAt
+	}
+
+	row, err = qtx.ExpireSigningSecret(ctx, db.ExpireSigningSecretParams{
+		ID:        id,
+		ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		ms.logger.Named("MerchantStore-RotateSigningSecret-Expire").Error(ctx, "failed to expire signing secret", zap.Any("id", id), zap.Error(err))
+		return dto.SigningSecret{}, dto.SigningSecret{}, customErrors.ErrUnableToUpdate.New("failed to update signing secret")
+	}
+
+	next.MerchantID = merchantID
+	next.Name = current.Name
+	created, err := ms.createSigningSecret(ctx, qtx, next)
+	if err != nil {
+		return dto.SigningSecret{}, dto.SigningSecret{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		ms.logger.Named("MerchantStore-RotateSigningSecret-Commit").Error(ctx, "failed to commit transaction", zap.Error(err))
+		return dto.SigningSecret{}, dto.SigningSecret{}, customErrors.ErrUnableToUpdate.New("final database commit failed")
+	}
+
+	return toSigningSecret(row), created, nil
+}
+
+// RevokeSigningSecret stops the secret from verifying straight away.
+// Revoking a secret twice keeps the original revocation time.
+func (ms *merchantStore) RevokeSigningSecret(ctx context.Context, merchantID, id uuid.UUID) (dto.SigningSecret, error) {
+	row, err := ms.persistencedb.Queries.RevokeSigningSecret(ctx, db.RevokeSigningSecretParams{
+		ID:         id,
+		MerchantID: merchantID,
+		RevokedAt:  sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.SigningSecret{}, customErrors.ErrResourceNotFound.New("signing secret not found")
+		}
+		ms.logger.Named("MerchantStore-RevokeSigningSecret").Error(ctx, "failed to revoke signing secret", zap.Any("id", id), zap.Error(err))
+		return dto.SigningSecret{}, customErrors.ErrUnableToUpdate.New("failed to revoke signing secret")
+	}
+
+	return toSigningSecret(row), nil
+}
+
+// signing_secrets timestamps are local wall-clock times, like those of
+// api_keys.
+func toSigningSecret(row db.SigningSecret) dto.SigningSecret {
+	secret := dto.SigningSecret{
+		ID:         row.ID,
+		MerchantID: row.MerchantID,
+		Name:       row.Name,
+		Secret:     row.Secret,
+		CreatedAt:  persistencedb.LocalTime(row.CreatedAt),
+	}
+	if row.ExpiresAt.Valid {
+		expiresAt := persistencedb.LocalTime(row.ExpiresAt.Time)
+		secret.ExpiresAt = &expiresAt
+	}
+	if row.RevokedAt.Valid {
+		revokedAt := persistencedb.LocalTime(row.RevokedAt.Time)
+		secret.RevokedAt = &revokedAt
+	}
+
+	return secret
+}
+
+func toSigningSecrets(rows []db.SigningSecret) []dto.SigningSecret {
+	secrets := make([]dto.SigningSecret, 0, len(rows))
+	for _, row := range rows {
+		secrets = append(secrets, toSigningSecret(row))
+	}
+
+	return secrets
+}
@@ -2,8 +2,12 @@ package outboxevent
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
 	"github.com/kalom60/cashflow/internal/constant/dto"
 	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
@@ -11,20 +15,24 @@ import (
 	"github.com/kalom60/cashflow/internal/constant/model/persistencedb"
 	"github.com/kalom60/cashflow/internal/storage"
 	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/kalom60/cashflow/platform/tracing"
 	"go.uber.org/zap"
 )
 
+// NotifyChannel is the channel the outbox_events insert trigger notifies on.
+const NotifyChannel = "outbox_events"
+
+const listenRetryDelay = 5 * time.Second
+
 type outboxEventStore struct {
 	logger        logger.Logger
 	persistencedb *persistencedb.PersistenceDB
-	limit         int
 }
 
-func Init(logger logger.Logger, persistencedb *persistencedb.PersistenceDB, limit int) storage.OutboxEvent {
+func Init(logger logger.Logger, persistencedb *persistencedb.PersistenceDB) storage.OutboxEvent {
 	return &outboxEventStore{
 		logger:        logger,
 		persistencedb: persistencedb,
-		limit:         limit,
 	}
 }
 
@@ -38,7 +46,7 @@ func (oes *outboxEventStore) BeginTx(ctx context.Context) (pgx.Tx, error) {
 	return tx, nil
 }
 
-func (oes *outboxEventStore) GetPendingOutboxEventsForUpdate(ctx context.Context, tx pgx.Tx) ([]dto.OutboxEvent, error) {
+func (oes *outboxEventStore) GetPendingOutboxEventsForUpdate(ctx context.Context, tx pgx.Tx, limit int) ([]dto.OutboxEvent, error) {
 	var qtx *db.Queries
 	if tx != nil {
 		qtx = oes.persistencedb.Queries.WithTx(tx)
@@ -46,7 +54,10 @@ func (oes *outboxEventStore) GetPendingOutboxEventsForUpdate(ctx context.Context
 		qtx = oes.persistencedb.Queries
 	}
 
-	rows, err := qtx.GetPendingOutboxEventsForUpdate(ctx, int32(oes.limit))
+	rows, err := qtx.GetPendingOutboxEventsForUpdate(ctx, db.GetPendingOutboxEventsForUpdateParams{
+		NextAttemptAt: time.Now(),
+		Limit:         int32(limit),
+	})
 	if err != nil {
 		oes.logger.Named("OutboxEventStore-GetPendingOutboxEventsForUpdate").Error(ctx, "failed to get outbox events", zap.Error(err))
 		return nil, customErrors.ErrUnableToGet.New("failed to get outbox events")
@@ -54,13 +65,7 @@ func (oes *outboxEventStore) GetPendingOutboxEventsForUpdate(ctx context.Context
 
 	events := make([]dto.OutboxEvent, 0, len(rows))
 	for _, row := range rows {
-		events = append(events, dto.OutboxEvent{
-			ID:        row.ID,
-			Payload:   row.Payload,
-			Status:    dto.OutboxStatus(row.Status),
-			CreatedAt: row.CreatedAt,
-			UpdatedAt: row.UpdatedAt,
-		})
+		events = append(events, toOutboxEvent(row))
 	}
 
 	return events, nil
@@ -75,8 +80,9 @@ func (oes *outboxEventStore) UpdateOutboxStatus(ctx context.Context, tx pgx.Tx,
 	}
 
 	rowsAffected, err := qtx.UpdateOutboxStatus(ctx, db.UpdateOutboxStatusParams{
-		ID:     id,
-		Status: db.OutboxStatus(status),
+		ID:        id,
+		Status:    db.OutboxStatus(status),
+		UpdatedAt: time.Now(),
 	})
 	if err != nil {
 		oes.logger.Named("OutboxEventStore-UpdateOutboxStatus").Error(ctx, "failed to update outbox event", zap.Any("id", id), zap.Error(err))
@@ -91,6 +97,44 @@ func (oes *outboxEventStore) UpdateOutboxStatus(ctx context.Context, tx pgx.Tx,
 	return nil
 }
 
+func (oes *outboxEventStore) RecordOutboxFailure(ctx context.Context, tx pgx.Tx, id uuid.UUID, failure dto.OutboxFailure) error {
+	var qtx *db.Queries
+	if tx != nil {
+		qtx = oes.persistencedb.Queries.WithTx(tx)
+	} else {
+		qtx = oes.persistencedb.Queries
+	}
+
+	rowsAffected, err := qtx.RecordOutboxFailure(ctx, db.RecordOutboxFailureParams{
+		ID:            id,
+		Status:        db.OutboxStatus(failure.Status),
+		LastError:     sql.NullString{String: failure.LastError, Valid: failure.LastError != ""},
+		NextAttemptAt: failure.NextAttemptAt,
+		UpdatedAt:     time.Now(),
+	})
+	if err != nil {
+		oes.logger.Named("OutboxEventStore-RecordOutboxFailure").Error(ctx, "failed to record outbox failure", zap.Any("id", id), zap.Error(err))
+		return customErrors.ErrUnableToUpdate.New("failed to update outbox event")
+	}
+
+	if rowsAffected == 0 {
+		oes.logger.Named("OutboxEventStore-RecordOutboxFailure").Error(ctx, "no row found", zap.Any("id", id))
+		return customErrors.ErrResourceNotFound.New("event not found")
+	}
+
+	return nil
+}
+
+func (oes *outboxEventStore) CountPendingOutboxEvents(ctx context.Context) (int64, error) {
+	count, err := oes.persistencedb.Queries.CountPendingOutboxEvents(ctx)
+	if err != nil {
+		oes.logger.Named("OutboxEventStore-CountPendingOutboxEvents").Error(ctx, "failed to count outbox events", zap.Error(err))
+		return 0, customErrors.ErrUnableToGet.New("failed to count outbox events")
+	}
+
+	return count, nil
+}
+
 func (oes *outboxEventStore) DeleteOutboxEvent(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
 	var qtx *db.Queries
 	if tx != nil {
@@ -112,3 +156,136 @@ func (oes *outboxEventStore) DeleteOutboxEvent(ctx context.Context, tx pgx.Tx, i
 
 	return nil
 }
+
+// Listen subscribes to NotifyChannel on a dedicated connection and signals
+// on the returned channel whenever a new outbox event is committed. Bursts of
+// notifications are coalesced into a single signal. The connection is
+// re-established if it drops, with a signal sent after each reconnect since
+// notifications may have been missed in between. The channel is closed once
+// ctx is done.
+func (oes *outboxEventStore) Listen(ctx context.Context) <-chan struct{} {
+	wake := make(chan struct{}, 1)
+
+	go func() {
+		defer close(wake)
+
+		for {
+			err := oes.listen(ctx, wake)
+			if ctx.Err() != nil {
+				return
+			}
+
+			oes.logger.Named("OutboxEventStore-Listen").Warn(ctx, "outbox listener disconnected, reconnecting", zap.Error(err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(listenRetryDelay):
+			}
+		}
+	}()
+
+	return wake
+}
+
+func (oes *outboxEventStore) listen(ctx context.Context, wake chan<- struct{}) error {
+	conn, err := pgx.ConnectConfig(ctx, oes.persistencedb.Pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+NotifyChannel); err != nil {
+		return err
+	}
+
+	signal(wake)
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+
+		signal(wake)
+	}
+}
+
+func signal(wake chan<- struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// Insert writes event to the outbox using qtx, which should be bound to the
+// transaction that performs the change the event announces.
+func Insert(ctx context.Context, qtx *db.Queries, event dto.NewOutboxEvent) (dto.OutboxEvent, error) {
+	payloadJson, err := json.Marshal(event.Payload)
+	if err != nil {
+		return dto.OutboxEvent{}, customErrors.ErrUnableToCreate.New("failed to marshal outbox payload")
+	}
+
+	var jsonbPayload pgtype.JSONB
+	if err := jsonbPayload.Set(payloadJson); err != nil {
+		return dto.OutboxEvent{}, customErrors.ErrUnableToCreate.New("failed to set outbox payload")
+	}
+
+	headers := make(map[string]string, len(event.Headers)+2)
+	for key, value := range event.Headers {
+		headers[key] = value
+	}
+
+	// Keep the trace of the request that created the event, so publishing
+	// it later shows up in the same trace
+	tracing.Inject(ctx, headers)
+	if requestID := logger.RequestID(ctx); requestID != "" {
+		headers[dto.HeaderRequestID] = requestID
+	}
+
+	headersJson, err := json.Marshal(headers)
+	if err != nil {
+		return dto.OutboxEvent{}, customErrors.ErrUnableToCreate.New("failed to marshal outbox headers")
+	}
+
+	var jsonbHeaders pgtype.JSONB
+	if err := jsonbHeaders.Set(headersJson); err != nil {
+		return dto.OutboxEvent{}, customErrors.ErrUnableToCreate.New("failed to set outbox headers")
+	}
+
+	row, err := qtx.CreateOutboxEvent(ctx, db.CreateOutboxEventParams{
+		EventType:     event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Payload:       jsonbPayload,
+		Headers:       jsonbHeaders,
+		Status:        db.OutboxStatus(dto.OutboxStatusPending),
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		return dto.OutboxEvent{}, customErrors.ErrUnableToCreate.New("failed to save outbox event")
+	}
+
+	return toOutboxEvent(row), nil
+}
+
+func toOutboxEvent(row db.OutboxEvent) dto.OutboxEvent {
+	headers := map[string]string{}
+	if len(row.Headers.Bytes) > 0 {
+		_ = json.Unmarshal(row.Headers.Bytes, &headers)
+	}
+
+	return dto.OutboxEvent{
+		ID:            row.ID,
+		EventType:     row.EventType,
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		Payload:       row.Payload,
+		Headers:       headers,
+		Status:        dto.OutboxStatus(row.Status),
+		Attempts:      int(row.Attempts),
+		LastError:     row.LastError.String,
+		NextAttemptAt: row.NextAttemptAt,
+		CreatedAt:     row.CreatedAt,
+		UpdatedAt:     row.UpdatedAt,
+	}
+}
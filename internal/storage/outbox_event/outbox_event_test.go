@@ -12,15 +12,17 @@ import (
 	"github.com/kalom60/cashflow/internal/storage"
 	outboxevent "github.com/kalom60/cashflow/internal/storage/outbox_event"
 	"github.com/kalom60/cashflow/internal/storage/payment"
+	"github.com/kalom60/cashflow/platform/logger"
 	"github.com/kalom60/cashflow/tests/testutils"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
-	ctx     context.Context
-	pStore  storage.Payment
-	oeStore storage.OutboxEvent
+	ctx        context.Context
+	merchantID uuid.UUID
+	pStore     storage.Payment
+	oeStore    storage.OutboxEvent
 
 	eventIDETB uuid.UUID
 	eventIDUSD uuid.UUID
@@ -29,8 +31,9 @@ var (
 func TestMain(m *testing.M) {
 	ctx = context.Background()
 	testDB := testutils.SetupTestDB()
+	merchantID = testutils.CreateMerchant(testDB)
 	pStore = payment.Init(testutils.NewTestLogger(), &testDB)
-	oeStore = outboxevent.Init(testutils.NewTestLogger(), &testDB, 100)
+	oeStore = outboxevent.Init(testutils.NewTestLogger(), &testDB)
 
 	code := m.Run()
 	os.Exit(code)
@@ -38,11 +41,12 @@ func TestMain(m *testing.M) {
 
 func TestCreateOutboxEventETB(t *testing.T) {
 	req := dto.Payment{
-		Reference: uuid.New(),
-		Amount:    decimal.NewFromFloat(100000),
-		Currency:  dto.ETB,
-		Status:    dto.PENDING,
-		CreatedAt: time.Now(),
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(100000),
+		Currency:   dto.ETB,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
 	}
 
 	resp, err := pStore.CreatePayment(ctx, req)
@@ -55,11 +59,12 @@ func TestCreateOutboxEventETB(t *testing.T) {
 
 func TestCreateOutboxEventUSD(t *testing.T) {
 	req := dto.Payment{
-		Reference: uuid.New(),
-		Amount:    decimal.NewFromFloat(1000000),
-		Currency:  dto.USD,
-		Status:    dto.PENDING,
-		CreatedAt: time.Now(),
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(1000000),
+		Currency:   dto.USD,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
 	}
 
 	resp, err := pStore.CreatePayment(ctx, req)
@@ -72,11 +77,16 @@ func TestCreateOutboxEventUSD(t *testing.T) {
 
 func TestGetPendingOutboxEventsForUpdate(t *testing.T) {
 	tx, _ := oeStore.BeginTx(ctx)
-	resp, err := oeStore.GetPendingOutboxEventsForUpdate(ctx, tx)
+	resp, err := oeStore.GetPendingOutboxEventsForUpdate(ctx, tx, 100)
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(resp))
 
 	for _, res := range resp {
+		assert.Equal(t, dto.EventPaymentCreated, res.EventType)
+		assert.Equal(t, dto.AggregatePayment, res.AggregateType)
+		assert.NotEqual(t, uuid.Nil, res.AggregateID)
+		assert.NotNil(t, res.Headers)
+
 		var data map[string]any
 		if err := json.Unmarshal(res.Payload.Bytes, &data); err != nil {
 			continue
@@ -133,7 +143,7 @@ func TestUpdateOutboxStatusUSDAfterUpdate(t *testing.T) {
 
 func TestGetPendingOutboxEventsForUpdateAfterUpdate(t *testing.T) {
 	tx, _ := oeStore.BeginTx(ctx)
-	resp, err := oeStore.GetPendingOutboxEventsForUpdate(ctx, tx)
+	resp, err := oeStore.GetPendingOutboxEventsForUpdate(ctx, tx, 100)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(resp))
 }
@@ -149,3 +159,99 @@ func TestDeleteOutboxEventUSDAfterDelete(t *testing.T) {
 	err := oeStore.DeleteOutboxEvent(ctx, tx, eventIDUSD)
 	assert.Error(t, err)
 }
+
+func TestRecordOutboxFailure(t *testing.T) {
+	_, err := pStore.CreatePayment(ctx, dto.Payment{
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(250),
+		Currency:   dto.USD,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
+	})
+	assert.NoError(t, err)
+
+	tx, _ := oeStore.BeginTx(ctx)
+	defer tx.Rollback(ctx)
+
+	resp, err := oeStore.GetPendingOutboxEventsForUpdate(ctx, tx, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(resp))
+	assert.Equal(t, 0, resp[0].Attempts)
+
+	err = oeStore.RecordOutboxFailure(ctx, tx, resp[0].ID, dto.OutboxFailure{
+		Status:        dto.OutboxStatusPending,
+		LastError:     "broker unavailable",
+		NextAttemptAt: time.Now().Add(time.Hour),
+	})
+	assert.NoError(t, err)
+
+	// The event is backing off, so it must not be picked up again yet
+	resp, err = oeStore.GetPendingOutboxEventsForUpdate(ctx, tx, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(resp))
+
+	err = oeStore.RecordOutboxFailure(ctx, tx, uuid.New(), dto.OutboxFailure{Status: dto.OutboxStatusFailed})
+	assert.Error(t, err)
+}
+
+func TestListenNotifiesOnNewEvent(t *testing.T) {
+	listenCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wake := oeStore.Listen(listenCtx)
+
+	// The listener signals once it is subscribed
+	select {
+	case <-wake:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not connect")
+	}
+
+	_, err := pStore.CreatePayment(ctx, dto.Payment{
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(75),
+		Currency:   dto.ETB,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
+	})
+	assert.NoError(t, err)
+
+	select {
+	case <-wake:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received for new outbox event")
+	}
+
+	cancel()
+	_, ok := <-wake
+	assert.False(t, ok)
+}
+
+func TestInsertCarriesRequestID(t *testing.T) {
+	payment, err := pStore.CreatePayment(logger.WithRequestID(ctx, "req-42"), dto.Payment{
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(30),
+		Currency:   dto.USD,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
+	})
+	assert.NoError(t, err)
+
+	tx, _ := oeStore.BeginTx(ctx)
+	defer tx.Rollback(ctx)
+
+	resp, err := oeStore.GetPendingOutboxEventsForUpdate(ctx, tx, 100)
+	assert.NoError(t, err)
+
+	found := false
+	for _, event := range resp {
+		if event.AggregateID == payment.ID {
+			found = true
+			assert.Equal(t, "req-42", event.Headers[dto.HeaderRequestID])
+		}
+	}
+	assert.True(t, found)
+}
@@ -3,19 +3,18 @@ package payment
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgconn"
-	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
 	"github.com/kalom60/cashflow/internal/constant/dto"
 	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
 	"github.com/kalom60/cashflow/internal/constant/model/db"
 	"github.com/kalom60/cashflow/internal/constant/model/persistencedb"
 	"github.com/kalom60/cashflow/internal/storage"
+	outboxevent "github.com/kalom60/cashflow/internal/storage/outbox_event"
 	"github.com/kalom60/cashflow/platform/logger"
 	"go.uber.org/zap"
 )
@@ -43,11 +42,12 @@ func (ps *paymentStore) CreatePayment(ctx context.Context, payment dto.Payment)
 	qtx := ps.persistencedb.Queries.WithTx(tx)
 
 	row, err := qtx.CreatePayment(ctx, db.CreatePaymentParams{
-		Reference: payment.Reference,
-		Amount:    payment.Amount,
-		Currency:  db.PaymentCurrency(payment.Currency),
-		Status:    db.PaymentStatus(payment.Status),
-		CreatedAt: payment.CreatedAt,
+		MerchantID: payment.MerchantID,
+		Reference:  payment.Reference,
+		Amount:     payment.Amount,
+		Currency:   db.PaymentCurrency(payment.Currency),
+		Status:     db.PaymentStatus(payment.Status),
+		CreatedAt:  payment.CreatedAt,
 	})
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -62,26 +62,28 @@ func (ps *paymentStore) CreatePayment(ctx context.Context, payment dto.Payment)
 	payment.CreatedAt = row.CreatedAt
 	payment.UpdatedAt = row.UpdatedAt
 
-	payloadJson, err := json.Marshal(payment)
+	_, err = qtx.CreatePaymentStatusHistory(ctx, db.CreatePaymentStatusHistoryParams{
+		PaymentID: row.ID,
+		ToStatus:  row.Status,
+		Actor:     dto.ActorAPI,
+		Reason:    "payment created",
+		CreatedAt: row.CreatedAt,
+	})
 	if err != nil {
-		ps.logger.Named("PaymentStore-CreatePayment-Marshal").Error(ctx, "failed to marshal outbox payload", zap.Error(err))
-		return dto.Payment{}, err
-	}
-
-	var jsonbPayload pgtype.JSONB
-	if err := jsonbPayload.Set(payloadJson); err != nil {
-		ps.logger.Named("PaymentStore-CreatePayment-SetJSONB").Error(ctx, "failed to set jsonb payload", zap.Error(err))
-		return dto.Payment{}, err
+		ps.logger.Named("PaymentStore-CreatePayment-InsertHistory").Error(ctx, "failed to insert payment status history", zap.Error(err))
+		return dto.Payment{}, customErrors.ErrUnableToCreate.New("failed to save payment status history")
 	}
 
-	_, err = qtx.CreateOutboxEvent(ctx, db.CreateOutboxEventParams{
-		Payload:   jsonbPayload,
-		Status:    db.OutboxStatus(dto.OutboxStatusPending),
-		CreatedAt: time.Now(),
+	_, err = outboxevent.Insert(ctx, qtx, dto.NewOutboxEvent{
+		EventType:     dto.EventPaymentCreated,
+		AggregateType: dto.AggregatePayment,
+		AggregateID:   payment.ID,
+		Payload:       payment,
+		Headers:       map[string]string{dto.HeaderCorrelationID: payment.ID.String()},
 	})
 	if err != nil {
 		ps.logger.Named("PaymentStore-CreatePayment-InsertOutbox").Error(ctx, "failed to insert outbox event", zap.Error(err))
-		return dto.Payment{}, customErrors.ErrUnableToCreate.New("failed to save outbox event")
+		return dto.Payment{}, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -104,28 +106,25 @@ func (ps *paymentStore) GetPaymentByID(ctx context.Context, id uuid.UUID) (dto.P
 
 	}
 
-	return dto.Payment{
-		ID:        row.ID,
-		Reference: row.Reference,
-		Amount:    row.Amount,
-		Currency:  dto.PaymentCurrency(row.Currency),
-		Status:    dto.PaymentStatus(row.Status),
-		CreatedAt: row.CreatedAt,
-	}, nil
+	return toPayment(row), nil
 }
 
-func (ps *paymentStore) UpdatePaymentStatus(ctx context.Context, id uuid.UUID, status dto.PaymentStatus) error {
-	_, err := ps.persistencedb.Queries.UpdatePaymentStatus(ctx, db.UpdatePaymentStatusParams{
-		ID:     id,
-		Status: db.PaymentStatus(status),
+// GetMerchantPaymentByID only finds the payment if it belongs to merchantID,
+// payments of other merchants are reported as not found.
+func (ps *paymentStore) GetMerchantPaymentByID(ctx context.Context, merchantID, id uuid.UUID) (dto.Payment, error) {
+	row, err := ps.persistencedb.Queries.GetMerchantPaymentByID(ctx, db.GetMerchantPaymentByIDParams{
+		ID:         id,
+		MerchantID: merchantID,
 	})
 	if err != nil {
-		ps.logger.Named("PaymentStore-UpdatePaymentStatus").Error(ctx, "failed to update payment status", zap.Any("id", id), zap.Error(err))
-		return customErrors.ErrUnableToUpdate.New("failed to update payment")
-
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.Payment{}, customErrors.ErrResourceNotFound.New("payment not found")
+		}
+		ps.logger.Named("PaymentStore-GetMerchantPaymentByID").Error(ctx, "failed to get payment by id", zap.Any("id", id), zap.Any("merchant_id", merchantID), zap.Error(err))
+		return dto.Payment{}, customErrors.ErrUnableToGet.New("failed to get payment")
 	}
 
-	return nil
+	return toPayment(row), nil
 }
 
 func (ps *paymentStore) BeginTx(ctx context.Context) (pgx.Tx, error) {
@@ -142,24 +141,149 @@ func (ps *paymentStore) GetPaymentByIDForUpdate(ctx context.Context, tx pgx.Tx,
 		return dto.Payment{}, customErrors.ErrUnableToGet.New("failed to get payment for update")
 	}
 
-	return dto.Payment{
-		ID:        row.ID,
-		Reference: row.Reference,
-		Amount:    row.Amount,
-		Currency:  dto.PaymentCurrency(row.Currency),
-		Status:    dto.PaymentStatus(row.Status),
-		CreatedAt: row.CreatedAt,
-	}, nil
+	return toPayment(row), nil
 }
 
-func (ps *paymentStore) UpdatePaymentStatusWithTx(ctx context.Context, tx pgx.Tx, id uuid.UUID, status dto.PaymentStatus) error {
+// UpdatePaymentStatusWithTx moves a payment to transition.Status if the state
+// machine allows it and records the change in the status history. It locks
+// the payment row, so callers may but need not lock it beforehand.
+func (ps *paymentStore) UpdatePaymentStatusWithTx(ctx context.Context, tx pgx.Tx, id uuid.UUID, transition dto.PaymentTransition) error {
 	qtx := ps.persistencedb.Queries.WithTx(tx)
-	_, err := qtx.UpdatePaymentStatus(ctx, db.UpdatePaymentStatusParams{
-		ID:     id,
-		Status: db.PaymentStatus(status),
+
+	current, err := qtx.GetPaymentByIDForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return customErrors.ErrResourceNotFound.New("payment not found")
+		}
+		return customErrors.ErrUnableToGet.New("failed to get payment for update")
+	}
+
+	from := dto.PaymentStatus(current.Status)
+	if !from.CanTransitionTo(transition.Status) {
+		return customErrors.ErrInvalidStatusTransition.New("payment cannot move from %s to %s", from, transition.Status)
+	}
+
+	now := time.Now()
+	updated, err := qtx.UpdatePaymentStatus(ctx, db.UpdatePaymentStatusParams{
+		ID:        id,
+		Status:    db.PaymentStatus(transition.Status),
+		UpdatedAt: now,
 	})
 	if err != nil {
 		return customErrors.ErrUnableToUpdate.New("failed to update payment status in tx")
 	}
+
+	_, err = qtx.CreatePaymentStatusHistory(ctx, db.CreatePaymentStatusHistoryParams{
+		PaymentID:  id,
+		FromStatus: db.NullPaymentStatus{PaymentStatus: current.Status, Valid: true},
+		ToStatus:   db.PaymentStatus(transition.Status),
+		Actor:      transition.Actor,
+		Reason:     transition.Reason,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return customErrors.ErrUnableToCreate.New("failed to save payment status history in tx")
+	}
+
+	// Merchants hear about the outcome through their webhooks
+	if eventType, ok := dto.PaymentStatusEvent(transition.Status); ok {
+		_, err = outboxevent.Insert(ctx, qtx, dto.NewOutboxEvent{
+			EventType:     eventType,
+			AggregateType: dto.AggregatePayment,
+			AggregateID:   id,
+			Payload:       toPayment(updated),
+			Headers:       map[string]string{dto.HeaderCorrelationID: id.String()},
+		})
+		if err != nil {
+			ps.logger.Named("PaymentStore-UpdatePaymentStatusWithTx-InsertOutbox").Error(ctx, "failed to insert outbox event", zap.Any("id", id), zap.Error(err))
+			return err
+		}
+	}
+
 	return nil
 }
+
+func (ps *paymentStore) GetPaymentStatusHistory(ctx context.Context, paymentID uuid.UUID) ([]dto.PaymentStatusHistory, error) {
+	rows, err := ps.persistencedb.Queries.GetPaymentStatusHistory(ctx, paymentID)
+	if err != nil {
+		ps.logger.Named("PaymentStore-GetPaymentStatusHistory").Error(ctx, "failed to get payment status history", zap.Any("payment_id", paymentID), zap.Error(err))
+		return nil, customErrors.ErrUnableToGet.New("failed to get payment status history")
+	}
+
+	history := make([]dto.PaymentStatusHistory, 0, len(rows))
+	for _, row := range rows {
+		entry := dto.PaymentStatusHistory{
+			ID:        row.ID,
+			PaymentID: row.PaymentID,
+			ToStatus:  dto.PaymentStatus(row.ToStatus),
+			Actor:     row.Actor,
+			Reason:    row.Reason,
+			CreatedAt: row.CreatedAt,
+		}
+		if row.FromStatus.Valid {
+			from := dto.PaymentStatus(row.FromStatus.PaymentStatus)
+			entry.FromStatus = &from
+		}
+		history = append(history, entry)
+	}
+
+	return history, nil
+}
+
+// ListPayments returns at most filter.Limit payments ordered newest first,
+// starting strictly after filter.Cursor when one is given.
+func (ps *paymentStore) ListPayments(ctx context.Context, filter dto.PaymentFilter) ([]dto.Payment, error) {
+	params := db.ListPaymentsParams{
+		MerchantID: filter.MerchantID,
+		MinAmount:  filter.MinAmount,
+		MaxAmount:  filter.MaxAmount,
+		PageLimit:  int32(filter.Limit),
+	}
+
+	if filter.Status != nil {
+		params.Status = db.NullPaymentStatus{PaymentStatus: db.PaymentStatus(*filter.Status), Valid: true}
+	}
+	if filter.Currency != nil {
+		params.Currency = db.NullPaymentCurrency{PaymentCurrency: db.PaymentCurrency(*filter.Currency), Valid: true}
+	}
+	// created_at is stored as a local wall-clock timestamp, compare in the same zone
+	if filter.CreatedFrom != nil {
+		params.CreatedFrom = sql.NullTime{Time: filter.CreatedFrom.Local(), Valid: true}
+	}
+	if filter.CreatedTo != nil {
+		params.CreatedTo = sql.NullTime{Time: filter.CreatedTo.Local(), Valid: true}
+	}
+	if filter.Reference != nil {
+		params.Reference = uuid.NullUUID{UUID: *filter.Reference, Valid: true}
+	}
+	if filter.Cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: filter.Cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: filter.Cursor.ID, Valid: true}
+	}
+
+	rows, err := ps.persistencedb.Queries.ListPayments(ctx, params)
+	if err != nil {
+		ps.logger.Named("PaymentStore-ListPayments").Error(ctx, "failed to list payments", zap.Error(err))
+		return nil, customErrors.ErrUnableToGet.New("failed to list payments")
+	}
+
+	payments := make([]dto.Payment, 0, len(rows))
+	for _, row := range rows {
+		payments = append(payments, toPayment(row))
+	}
+
+	return payments, nil
+}
+
+func toPayment(row db.Payment) dto.Payment {
+	return dto.Payment{
+		ID:         row.ID,
+		MerchantID: row.MerchantID,
+		Reference:  row.Reference,
+		Amount:     row.Amount,
+		Currency:   dto.PaymentCurrency(row.Currency),
+		Status:     dto.PaymentStatus(row.Status),
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/joomcode/errorx"
 	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
 	"github.com/kalom60/cashflow/internal/storage"
 	"github.com/kalom60/cashflow/internal/storage/payment"
 	"github.com/kalom60/cashflow/tests/testutils"
@@ -16,8 +18,9 @@ import (
 )
 
 var (
-	ctx   context.Context
-	store storage.Payment
+	ctx        context.Context
+	merchantID uuid.UUID
+	store      storage.Payment
 
 	paymentIDETB uuid.UUID
 	paymentIDUSD uuid.UUID
@@ -26,6 +29,7 @@ var (
 func TestMain(m *testing.M) {
 	ctx = context.Background()
 	testDB := testutils.SetupTestDB()
+	merchantID = testutils.CreateMerchant(testDB)
 	store = payment.Init(testutils.NewTestLogger(), &testDB)
 
 	code := m.Run()
@@ -34,11 +38,12 @@ func TestMain(m *testing.M) {
 
 func TestCreatePaymentETB(t *testing.T) {
 	req := dto.Payment{
-		Reference: uuid.New(),
-		Amount:    decimal.NewFromFloat(100000),
-		Currency:  dto.ETB,
-		Status:    dto.PENDING,
-		CreatedAt: time.Now(),
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(100000),
+		Currency:   dto.ETB,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
 	}
 
 	resp, err := store.CreatePayment(ctx, req)
@@ -53,11 +58,12 @@ func TestCreatePaymentETB(t *testing.T) {
 
 func TestCreatePaymentUSD(t *testing.T) {
 	req := dto.Payment{
-		Reference: uuid.New(),
-		Amount:    decimal.NewFromFloat(1000000),
-		Currency:  dto.USD,
-		Status:    dto.PENDING,
-		CreatedAt: time.Now(),
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(1000000),
+		Currency:   dto.USD,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
 	}
 
 	resp, err := store.CreatePayment(ctx, req)
@@ -89,10 +95,18 @@ func TestGetPaymentByNewID(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestUpdatePaymentETBToProcessing(t *testing.T) {
+	tx, _ := store.BeginTx(ctx)
+	err := store.UpdatePaymentStatusWithTx(ctx, tx, paymentIDETB, dto.PaymentTransition{Status: dto.PROCESSING, Actor: dto.ActorPaymentWorker})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit(ctx))
+}
+
 func TestUpdatePaymentETBToSuccess(t *testing.T) {
 	tx, _ := store.BeginTx(ctx)
-	err := store.UpdatePaymentStatusWithTx(ctx, tx, paymentIDETB, dto.SUCCESS)
+	err := store.UpdatePaymentStatusWithTx(ctx, tx, paymentIDETB, dto.PaymentTransition{Status: dto.SUCCESS, Actor: dto.ActorPaymentWorker})
 	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit(ctx))
 }
 
 func TestGetPaymentByIDETBAfterSuccessUpdate(t *testing.T) {
@@ -102,41 +116,54 @@ func TestGetPaymentByIDETBAfterSuccessUpdate(t *testing.T) {
 	assert.Equal(t, dto.SUCCESS, resp.Status)
 }
 
-func TestUpdatePaymentUSDToSuccess(t *testing.T) {
+func TestUpdatePaymentUSDPendingToSuccess(t *testing.T) {
 	tx, _ := store.BeginTx(ctx)
-	err := store.UpdatePaymentStatusWithTx(ctx, tx, paymentIDUSD, dto.SUCCESS)
+	defer tx.Rollback(ctx)
+	err := store.UpdatePaymentStatusWithTx(ctx, tx, paymentIDUSD, dto.PaymentTransition{Status: dto.SUCCESS, Actor: dto.ActorPaymentWorker})
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrInvalidStatusTransition))
+}
+
+func TestUpdatePaymentUSDToFailed(t *testing.T) {
+	tx, _ := store.BeginTx(ctx)
+	err := store.UpdatePaymentStatusWithTx(ctx, tx, paymentIDUSD, dto.PaymentTransition{Status: dto.FAILED, Actor: dto.ActorPaymentWorker, Reason: "card_declined"})
 	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit(ctx))
 }
 
-func TestGetPaymentByIDUSDAfterSuccessUpdate(t *testing.T) {
+func TestGetPaymentByIDUSDAfterFailedUpdate(t *testing.T) {
 	resp, err := store.GetPaymentByID(ctx, paymentIDUSD)
 	assert.NoError(t, err)
 	assert.Equal(t, dto.USD, resp.Currency)
-	assert.Equal(t, dto.SUCCESS, resp.Status)
+	assert.Equal(t, dto.FAILED, resp.Status)
 }
 
-func TestUpdatePaymentETBToFailed(t *testing.T) {
+func TestUpdatePaymentETBSuccessToFailed(t *testing.T) {
 	tx, _ := store.BeginTx(ctx)
-	err := store.UpdatePaymentStatusWithTx(ctx, tx, paymentIDETB, dto.FAILED)
-	assert.NoError(t, err)
+	defer tx.Rollback(ctx)
+	err := store.UpdatePaymentStatusWithTx(ctx, tx, paymentIDETB, dto.PaymentTransition{Status: dto.FAILED, Actor: dto.ActorPaymentWorker})
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrInvalidStatusTransition))
 }
 
-func TestGetPaymentByIDETBAfterFailedUpdate(t *testing.T) {
-	resp, err := store.GetPaymentByID(ctx, paymentIDETB)
+func TestGetPaymentStatusHistoryETB(t *testing.T) {
+	history, err := store.GetPaymentStatusHistory(ctx, paymentIDETB)
 	assert.NoError(t, err)
-	assert.Equal(t, dto.ETB, resp.Currency)
-	assert.Equal(t, dto.FAILED, resp.Status)
-}
+	assert.Equal(t, 3, len(history))
 
-func TestUpdatePaymentUSDToFailed(t *testing.T) {
-	tx, _ := store.BeginTx(ctx)
-	err := store.UpdatePaymentStatusWithTx(ctx, tx, paymentIDUSD, dto.FAILED)
-	assert.NoError(t, err)
+	assert.Nil(t, history[0].FromStatus)
+	assert.Equal(t, dto.PENDING, history[0].ToStatus)
+	assert.Equal(t, dto.ActorAPI, history[0].Actor)
+
+	assert.Equal(t, dto.PENDING, *history[1].FromStatus)
+	assert.Equal(t, dto.PROCESSING, history[1].ToStatus)
+
+	assert.Equal(t, dto.PROCESSING, *history[2].FromStatus)
+	assert.Equal(t, dto.SUCCESS, history[2].ToStatus)
 }
 
-func TestGetPaymentByIDUSDAfterFailedUpdate(t *testing.T) {
-	resp, err := store.GetPaymentByID(ctx, paymentIDUSD)
+func TestGetPaymentStatusHistoryUSD(t *testing.T) {
+	history, err := store.GetPaymentStatusHistory(ctx, paymentIDUSD)
 	assert.NoError(t, err)
-	assert.Equal(t, dto.USD, resp.Currency)
-	assert.Equal(t, dto.FAILED, resp.Status)
+	assert.Equal(t, 2, len(history))
+	assert.Equal(t, dto.FAILED, history[1].ToStatus)
+	assert.Equal(t, "card_declined", history[1].Reason)
 }
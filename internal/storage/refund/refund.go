@@ -0,0 +1,196 @@
+package refund
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/constant/model/db"
+	"github.com/kalom60/cashflow/internal/constant/model/persistencedb"
+	"github.com/kalom60/cashflow/internal/storage"
+	outboxevent "github.com/kalom60/cashflow/internal/storage/outbox_event"
+	"github.com/kalom60/cashflow/platform/logger"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+type refundStore struct {
+	logger        logger.Logger
+	persistencedb *persistencedb.PersistenceDB
+}
+
+func Init(logger logger.Logger, persistencedb *persistencedb.PersistenceDB) storage.Refund {
+	return &refundStore{
+		logger:        logger,
+		persistencedb: persistencedb,
+	}
+}
+
+func (rs *refundStore) BeginTx(ctx context.Context) (pgx.Tx, error) {
+	return rs.persistencedb.Pool.Begin(ctx)
+}
+
+// CreateRefund locks the parent payment, checks the requested amount against
+// what is still refundable and stores the refund together with its outbox
+// event. A zero Amount is a request to refund everything that is left.
+func (rs *refundStore) CreateRefund(ctx context.Context, refund dto.Refund) (dto.Refund, error) {
+	tx, err := rs.persistencedb.Pool.Begin(ctx)
+	if err != nil {
+		rs.logger.Named("RefundStore-CreateRefund-BeginTx").Error(ctx, "failed to start transaction", zap.Error(err))
+		return dto.Refund{}, customErrors.ErrUnableToCreate.New("database transaction failed")
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := rs.persistencedb.Queries.WithTx(tx)
+
+	payment, err := qtx.GetPaymentByIDForUpdate(ctx, refund.PaymentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.Refund{}, customErrors.ErrResourceNotFound.New("payment not found")
+		}
+		rs.logger.Named("RefundStore-CreateRefund-LockPayment").Error(ctx, "failed to lock payment", zap.Any("payment_id", refund.PaymentID), zap.Error(err))
+		return dto.Refund{}, customErrors.ErrUnableToGet.New("failed to get payment")
+	}
+
+	if payment.Status != db.PaymentStatusSUCCESS && payment.Status != db.PaymentStatusPARTIALLYREFUNDED {
+		return dto.Refund{}, customErrors.ErrRefundNotAllowed.New("only successful payments can be refunded")
+	}
+
+	totals, err := qtx.GetRefundTotalsByPaymentID(ctx, refund.PaymentID)
+	if err != nil {
+		rs.logger.Named("RefundStore-CreateRefund-Totals").Error(ctx, "failed to get refund totals", zap.Any("payment_id", refund.PaymentID), zap.Error(err))
+		return dto.Refund{}, customErrors.ErrUnableToGet.New("failed to get refundable amount")
+	}
+
+	refundable := payment.Amount.Sub(totals.Reserved)
+	if refundable.LessThanOrEqual(decimal.Zero) {
+		return dto.Refund{}, customErrors.ErrRefundAmountExceeded.New("payment has nothing left to refund")
+	}
+
+	if refund.Amount.IsZero() {
+		refund.Amount = refundable
+	}
+
+	if refund.Amount.GreaterThan(refundable) {
+		return dto.Refund{}, customErrors.ErrRefundAmountExceeded.New("refund amount exceeds refundable amount of %s", refundable.StringFixed(2))
+	}
+
+	row, err := qtx.CreateRefund(ctx, db.CreateRefundParams{
+		PaymentID: refund.PaymentID,
+		Amount:    refund.Amount,
+		Currency:  payment.Currency,
+		Reason:    refund.Reason,
+		Status:    db.RefundStatus(dto.RefundStatusPending),
+		CreatedAt: refund.CreatedAt,
+	})
+	if err != nil {
+		rs.logger.Named("RefundStore-CreateRefund-InsertRefund").Error(ctx, "failed to insert refund record", zap.Error(err))
+		return dto.Refund{}, customErrors.ErrUnableToCreate.New("failed to save refund to storage")
+	}
+
+	created := toRefund(row)
+
+	_, err = outboxevent.Insert(ctx, qtx, dto.NewOutboxEvent{
+		EventType:     dto.EventRefundCreated,
+		AggregateType: dto.AggregateRefund,
+		AggregateID:   created.ID,
+		Payload:       created,
+		// Refunds share the correlation ID of the payment they belong to
+		Headers: map[string]string{dto.HeaderCorrelationID: created.PaymentID.String()},
+	})
+	if err != nil {
+		rs.logger.Named("RefundStore-CreateRefund-InsertOutbox").Error(ctx, "failed to insert outbox event", zap.Error(err))
+		return dto.Refund{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		rs.logger.Named("RefundStore-CreateRefund-Commit").Error(ctx, "failed to commit transaction", zap.Error(err))
+		return dto.Refund{}, customErrors.ErrUnableToCreate.New("final database commit failed")
+	}
+
+	return created, nil
+}
+
+func (rs *refundStore) GetRefundByID(ctx context.Context, id uuid.UUID) (dto.Refund, error) {
+	row, err := rs.persistencedb.Queries.GetRefundByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.Refund{}, customErrors.ErrResourceNotFound.New("refund not found")
+		}
+		rs.logger.Named("RefundStore-GetRefundByID").Error(ctx, "failed to get refund by id", zap.Any("id", id), zap.Error(err))
+		return dto.Refund{}, customErrors.ErrUnableToGet.New("failed to get refund")
+	}
+
+	return toRefund(row), nil
+}
+
+func (rs *refundStore) GetRefundsByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]dto.Refund, error) {
+	rows, err := rs.persistencedb.Queries.GetRefundsByPaymentID(ctx, paymentID)
+	if err != nil {
+		rs.logger.Named("RefundStore-GetRefundsByPaymentID").Error(ctx, "failed to get refunds", zap.Any("payment_id", paymentID), zap.Error(err))
+		return nil, customErrors.ErrUnableToGet.New("failed to get refunds")
+	}
+
+	refunds := make([]dto.Refund, 0, len(rows))
+	for _, row := range rows {
+		refunds = append(refunds, toRefund(row))
+	}
+
+	return refunds, nil
+}
+
+func (rs *refundStore) GetRefundByIDForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (dto.Refund, error) {
+	qtx := rs.persistencedb.Queries.WithTx(tx)
+	row, err := qtx.GetRefundByIDForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.Refund{}, customErrors.ErrResourceNotFound.New("refund not found")
+		}
+		return dto.Refund{}, customErrors.ErrUnableToGet.New("failed to get refund for update")
+	}
+
+	return toRefund(row), nil
+}
+
+func (rs *refundStore) GetRefundedAmountWithTx(ctx context.Context, tx pgx.Tx, paymentID uuid.UUID) (decimal.Decimal, error) {
+	qtx := rs.persistencedb.Queries.WithTx(tx)
+	totals, err := qtx.GetRefundTotalsByPaymentID(ctx, paymentID)
+	if err != nil {
+		return decimal.Zero, customErrors.ErrUnableToGet.New("failed to get refunded amount")
+	}
+
+	return totals.Refunded, nil
+}
+
+func (rs *refundStore) UpdateRefundStatusWithTx(ctx context.Context, tx pgx.Tx, id uuid.UUID, status dto.RefundStatus, failureReason string) error {
+	qtx := rs.persistencedb.Queries.WithTx(tx)
+	_, err := qtx.UpdateRefundStatus(ctx, db.UpdateRefundStatusParams{
+		ID:            id,
+		Status:        db.RefundStatus(status),
+		FailureReason: failureReason,
+		UpdatedAt:     time.Now(),
+	})
+	if err != nil {
+		return customErrors.ErrUnableToUpdate.New("failed to update refund status in tx")
+	}
+	return nil
+}
+
+func toRefund(row db.Refund) dto.Refund {
+	return dto.Refund{
+		ID:            row.ID,
+		PaymentID:     row.PaymentID,
+		Amount:        row.Amount,
+		Currency:      dto.PaymentCurrency(row.Currency),
+		Reason:        row.Reason,
+		Status:        dto.RefundStatus(row.Status),
+		FailureReason: row.FailureReason,
+		CreatedAt:     row.CreatedAt,
+		UpdatedAt:     row.UpdatedAt,
+	}
+}
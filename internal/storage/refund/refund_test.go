@@ -0,0 +1,153 @@
+package refund_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joomcode/errorx"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/internal/storage/payment"
+	"github.com/kalom60/cashflow/internal/storage/refund"
+	"github.com/kalom60/cashflow/tests/testutils"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ctx        context.Context
+	merchantID uuid.UUID
+	pStore     storage.Payment
+	rStore     storage.Refund
+
+	paymentID uuid.UUID
+	refundID  uuid.UUID
+)
+
+func TestMain(m *testing.M) {
+	ctx = context.Background()
+	testDB := testutils.SetupTestDB()
+	merchantID = testutils.CreateMerchant(testDB)
+	pStore = payment.Init(testutils.NewTestLogger(), &testDB)
+	rStore = refund.Init(testutils.NewTestLogger(), &testDB)
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestCreatePayment(t *testing.T) {
+	resp, err := pStore.CreatePayment(ctx, dto.Payment{
+		MerchantID: merchantID,
+		Reference:  uuid.New(),
+		Amount:     decimal.NewFromFloat(100),
+		Currency:   dto.ETB,
+		Status:     dto.PENDING,
+		CreatedAt:  time.Now(),
+	})
+	assert.NoError(t, err)
+
+	paymentID = resp.ID
+}
+
+func TestRefundPendingPayment(t *testing.T) {
+	_, err := rStore.CreateRefund(ctx, dto.Refund{
+		PaymentID: paymentID,
+		Amount:    decimal.NewFromFloat(10),
+		CreatedAt: time.Now(),
+	})
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrRefundNotAllowed))
+}
+
+func TestRefundUnknownPayment(t *testing.T) {
+	_, err := rStore.CreateRefund(ctx, dto.Refund{
+		PaymentID: uuid.New(),
+		Amount:    decimal.NewFromFloat(10),
+		CreatedAt: time.Now(),
+	})
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+}
+
+func TestMarkPaymentSuccess(t *testing.T) {
+	tx, _ := pStore.BeginTx(ctx)
+	err := pStore.UpdatePaymentStatusWithTx(ctx, tx, paymentID, dto.PaymentTransition{Status: dto.PROCESSING, Actor: dto.ActorPaymentWorker})
+	assert.NoError(t, err)
+	err = pStore.UpdatePaymentStatusWithTx(ctx, tx, paymentID, dto.PaymentTransition{Status: dto.SUCCESS, Actor: dto.ActorPaymentWorker})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit(ctx))
+}
+
+func TestCreatePartialRefund(t *testing.T) {
+	resp, err := rStore.CreateRefund(ctx, dto.Refund{
+		PaymentID: paymentID,
+		Amount:    decimal.NewFromFloat(40),
+		Reason:    "damaged item",
+		CreatedAt: time.Now(),
+	})
+	assert.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(40).Equal(resp.Amount))
+	assert.Equal(t, dto.ETB, resp.Currency)
+	assert.Equal(t, dto.RefundStatusPending, resp.Status)
+
+	refundID = resp.ID
+}
+
+func TestCreateRefundExceedingRefundable(t *testing.T) {
+	_, err := rStore.CreateRefund(ctx, dto.Refund{
+		PaymentID: paymentID,
+		Amount:    decimal.NewFromFloat(60.01),
+		CreatedAt: time.Now(),
+	})
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrRefundAmountExceeded))
+}
+
+func TestCreateFullRefundOfRemainder(t *testing.T) {
+	resp, err := rStore.CreateRefund(ctx, dto.Refund{
+		PaymentID: paymentID,
+		CreatedAt: time.Now(),
+	})
+	assert.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(60).Equal(resp.Amount))
+}
+
+func TestCreateRefundWhenNothingLeft(t *testing.T) {
+	_, err := rStore.CreateRefund(ctx, dto.Refund{
+		PaymentID: paymentID,
+		CreatedAt: time.Now(),
+	})
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrRefundAmountExceeded))
+}
+
+func TestGetRefundsByPaymentID(t *testing.T) {
+	resp, err := rStore.GetRefundsByPaymentID(ctx, paymentID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(resp))
+}
+
+func TestRefundedAmountAfterSuccess(t *testing.T) {
+	tx, _ := rStore.BeginTx(ctx)
+	defer tx.Rollback(ctx)
+
+	err := rStore.UpdateRefundStatusWithTx(ctx, tx, refundID, dto.RefundStatusSuccess, "")
+	assert.NoError(t, err)
+
+	refunded, err := rStore.GetRefundedAmountWithTx(ctx, tx, paymentID)
+	assert.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(40).Equal(refunded))
+}
+
+func TestFailedRefundRecordsReason(t *testing.T) {
+	tx, _ := rStore.BeginTx(ctx)
+	defer tx.Rollback(ctx)
+
+	err := rStore.UpdateRefundStatusWithTx(ctx, tx, refundID, dto.RefundStatusFailed, dto.RefundFailureStillPending)
+	assert.NoError(t, err)
+
+	refund, err := rStore.GetRefundByIDForUpdate(ctx, tx, refundID)
+	assert.NoError(t, err)
+	assert.Equal(t, dto.RefundStatusFailed, refund.Status)
+	assert.Equal(t, dto.RefundFailureStillPending, refund.FailureReason)
+}
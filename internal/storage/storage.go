@@ -2,24 +2,81 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4"
 	"github.com/kalom60/cashflow/internal/constant/dto"
+	"github.com/shopspring/decimal"
 )
 
 type Payment interface {
 	BeginTx(ctx context.Context) (pgx.Tx, error)
 	CreatePayment(ctx context.Context, payment dto.Payment) (dto.Payment, error)
 	GetPaymentByID(ctx context.Context, id uuid.UUID) (dto.Payment, error)
+	GetMerchantPaymentByID(ctx context.Context, merchantID, id uuid.UUID) (dto.Payment, error)
 	GetPaymentByIDForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (dto.Payment, error)
-	UpdatePaymentStatusWithTx(ctx context.Context, tx pgx.Tx, id uuid.UUID, status dto.PaymentStatus) error
+	UpdatePaymentStatusWithTx(ctx context.Context, tx pgx.Tx, id uuid.UUID, transition dto.PaymentTransition) error
+	ListPayments(ctx context.Context, filter dto.PaymentFilter) ([]dto.Payment, error)
+	GetPaymentStatusHistory(ctx context.Context, paymentID uuid.UUID) ([]dto.PaymentStatusHistory, error)
 }
 
 type OutboxEvent interface {
 	BeginTx(ctx context.Context) (pgx.Tx, error)
 
-	GetPendingOutboxEventsForUpdate(ctx context.Context, tx pgx.Tx) ([]dto.OutboxEvent, error)
+	GetPendingOutboxEventsForUpdate(ctx context.Context, tx pgx.Tx, limit int) ([]dto.OutboxEvent, error)
 	UpdateOutboxStatus(ctx context.Context, tx pgx.Tx, id uuid.UUID, status dto.OutboxStatus) error
+	RecordOutboxFailure(ctx context.Context, tx pgx.Tx, id uuid.UUID, failure dto.OutboxFailure) error
 	DeleteOutboxEvent(ctx context.Context, tx pgx.Tx, id uuid.UUID) error
+	CountPendingOutboxEvents(ctx context.Context) (int64, error)
+	Listen(ctx context.Context) <-chan struct{}
+}
+
+type Refund interface {
+	BeginTx(ctx context.Context) (pgx.Tx, error)
+	CreateRefund(ctx context.Context, refund dto.Refund) (dto.Refund, error)
+	GetRefundByID(ctx context.Context, id uuid.UUID) (dto.Refund, error)
+	GetRefundsByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]dto.Refund, error)
+	GetRefundByIDForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (dto.Refund, error)
+	GetRefundedAmountWithTx(ctx context.Context, tx pgx.Tx, paymentID uuid.UUID) (decimal.Decimal, error)
+	UpdateRefundStatusWithTx(ctx context.Context, tx pgx.Tx, id uuid.UUID, status dto.RefundStatus, failureReason string) error
+}
+
+type Idempotency interface {
+	CreateIdempotencyKey(ctx context.Context, key, requestHash string, expiredBefore time.Time) (dto.IdempotencyKey, error)
+	GetIdempotencyKey(ctx context.Context, key string) (dto.IdempotencyKey, error)
+	ReclaimIdempotencyKey(ctx context.Context, key string, staleBefore time.Time) (dto.IdempotencyKey, error)
+	CompleteIdempotencyKey(ctx context.Context, key string, responseCode int, responseBody []byte) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error)
+}
+
+type Merchant interface {
+	CreateMerchant(ctx context.Context, merchant dto.Merchant, key dto.APIKey) (dto.Merchant, dto.APIKey, error)
+	CreateAPIKey(ctx context.Context, key dto.APIKey) (dto.APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (dto.APIKey, error)
+	ListAPIKeys(ctx context.Context, merchantID uuid.UUID) ([]dto.APIKey, error)
+	RotateAPIKey(ctx context.Context, merchantID, id uuid.UUID, next dto.APIKey, overlap time.Duration) (dto.APIKey, dto.APIKey, error)
+	RevokeAPIKey(ctx context.Context, merchantID, id uuid.UUID) (dto.APIKey, error)
+
+	CreateSigningSecret(ctx context.Context, secret dto.SigningSecret) (dto.SigningSecret, error)
+	ListSigningSecrets(ctx context.Context, merchantID uuid.UUID) ([]dto.SigningSecret, error)
+	ListActiveSigningSecrets(ctx context.Context, merchantID uuid.UUID, now time.Time) ([]dto.SigningSecret, error)
+	RotateSigningSecret(ctx context.Context, merchantID, id uuid.UUID, next dto.SigningSecret, overlap time.Duration) (dto.SigningSecret, dto.SigningSecret, error)
+	RevokeSigningSecret(ctx context.Context, merchantID, id uuid.UUID) (dto.SigningSecret, error)
+}
+
+type Webhook interface {
+	CreateWebhookEndpoint(ctx context.Context, endpoint dto.WebhookEndpoint) (dto.WebhookEndpoint, error)
+	GetWebhookEndpointByID(ctx context.Context, id uuid.UUID) (dto.WebhookEndpoint, error)
+	GetMerchantWebhookEndpointByID(ctx context.Context, merchantID, id uuid.UUID) (dto.WebhookEndpoint, error)
+	ListWebhookEndpoints(ctx context.Context, merchantID uuid.UUID) ([]dto.WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, merchantID, id uuid.UUID) error
+
+	CreateWebhookDeliveries(ctx context.Context, merchantID uuid.UUID, event dto.WebhookEvent, expiresAt time.Time) (int64, error)
+	ClaimDueWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]dto.WebhookDelivery, error)
+	RecordWebhookDeliveryAttempt(ctx context.Context, id uuid.UUID, result dto.WebhookDeliveryResult) (dto.WebhookDelivery, error)
+	GetMerchantWebhookDeliveryByID(ctx context.Context, merchantID, id uuid.UUID) (dto.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, filter dto.WebhookDeliveryFilter) ([]dto.WebhookDelivery, error)
+	RedeliverWebhookDelivery(ctx context.Context, merchantID, id uuid.UUID, expiresAt time.Time) (dto.WebhookDelivery, error)
 }
@@ -0,0 +1,308 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	"github.com/kalom60/cashflow/internal/constant/model/db"
+	"github.com/kalom60/cashflow/internal/constant/model/persistencedb"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/platform/logger"
+	"go.uber.org/zap"
+)
+
+type webhookStore struct {
+	logger        logger.Logger
+	persistencedb *persistencedb.PersistenceDB
+}
+
+func Init(logger logger.Logger, persistencedb *persistencedb.PersistenceDB) storage.Webhook {
+	return &webhookStore{
+		logger:        logger,
+		persistencedb: persistencedb,
+	}
+}
+
+func (ws *webhookStore) CreateWebhookEndpoint(ctx context.Context, endpoint dto.WebhookEndpoint) (dto.WebhookEndpoint, error) {
+	row, err := ws.persistencedb.Queries.CreateWebhookEndpoint(ctx, db.CreateWebhookEndpointParams{
+		MerchantID: endpoint.MerchantID,
+		Url:        endpoint.URL,
+		EventTypes: endpoint.EventTypes,
+		Secret:     endpoint.Secret,
+		CreatedAt:  endpoint.CreatedAt,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return dto.WebhookEndpoint{}, customErrors.ErrResourceNotFound.New("merchant not found")
+		}
+		ws.logger.Named("WebhookStore-CreateWebhookEndpoint").Error(ctx, "failed to insert webhook endpoint", zap.Any("merchant_id", endpoint.MerchantID), zap.Error(err))
+		return dto.WebhookEndpoint{}, customErrors.ErrUnableToCreate.New("failed to save webhook endpoint")
+	}
+
+	return toWebhookEndpoint(row), nil
+}
+
+func (ws *webhookStore) GetWebhookEndpointByID(ctx context.Context, id uuid.UUID) (dto.WebhookEndpoint, error) {
+	row, err := ws.persistencedb.Queries.GetWebhookEndpointByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.WebhookEndpoint{}, customErrors.ErrResourceNotFound.New("webhook endpoint not found")
+		}
+		ws.logger.Named("WebhookStore-GetWebhookEndpointByID").Error(ctx, "failed to get webhook endpoint", zap.Any("id", id), zap.Error(err))
+		return dto.WebhookEndpoint{}, customErrors.ErrUnableToGet.New("failed to get webhook endpoint")
+	}
+
+	return toWebhookEndpoint(row), nil
+}
+
+// GetMerchantWebhookEndpointByID only finds the endpoint if it belongs to
+// merchantID, endpoints of other merchants are reported as not found.
+func (ws *webhookStore) GetMerchantWebhookEndpointByID(ctx context.Context, merchantID, id uuid.UUID) (dto.WebhookEndpoint, error) {
+	row, err := ws.persistencedb.Queries.GetMerchantWebhookEndpointByID(ctx, db.GetMerchantWebhookEndpointByIDParams{
+		ID:         id,
+		MerchantID: merchantID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.WebhookEndpoint{}, customErrors.ErrResourceNotFound.New("webhook endpoint not found")
+		}
+		ws.logger.Named("WebhookStore-GetMerchantWebhookEndpointByID").Error(ctx, "failed to get webhook endpoint", zap.Any("id", id), zap.Any("merchant_id", merchantID), zap.Error(err))
+		return dto.WebhookEndpoint{}, customErrors.ErrUnableToGet.New("failed to get webhook endpoint")
+	}
+
+	return toWebhookEndpoint(row), nil
+}
+
+func (ws *webhookStore) ListWebhookEndpoints(ctx context.Context, merchantID uuid.UUID) ([]dto.WebhookEndpoint, error) {
+	rows, err := ws.persistencedb.Queries.ListMerchantWebhookEndpoints(ctx, merchantID)
+	if err != nil {
+		ws.logger.Named("WebhookStore-ListWebhookEndpoints").Error(ctx, "failed to list webhook endpoints", zap.Any("merchant_id", merchantID), zap.Error(err))
+		return nil, customErrors.ErrUnableToGet.New("failed to list webhook endpoints")
+	}
+
+	endpoints := make([]dto.WebhookEndpoint, 0, len(rows))
+	for _, row := range rows {
+		endpoints = append(endpoints, toWebhookEndpoint(row))
+	}
+
+	return endpoints, nil
+}
+
+// DeleteWebhookEndpoint removes the endpoint together with its delivery log.
+func (ws *webhookStore) DeleteWebhookEndpoint(ctx context.Context, merchantID, id uuid.UUID) error {
+	rowsAffected, err := ws.persistencedb.Queries.DeleteWebhookEndpoint(ctx, db.DeleteWebhookEndpointParams{
+		ID:         id,
+		MerchantID: merchantID,
+	})
+	if err != nil {
+		ws.logger.Named("WebhookStore-DeleteWebhookEndpoint").Error(ctx, "failed to delete webhook endpoint", zap.Any("id", id), zap.Error(err))
+		return customErrors.ErrUnableToUpdate.New("failed to delete webhook endpoint")
+	}
+
+	if rowsAffected == 0 {
+		return customErrors.ErrResourceNotFound.New("webhook endpoint not found")
+	}
+
+	return nil
+}
+
+// CreateWebhookDeliveries queues event for every endpoint of merchantID that
+// subscribed to its type and returns how many deliveries were queued. An
+// endpoint never gets the same event twice, so handing an event over again
+// is harmless.
+func (ws *webhookStore) CreateWebhookDeliveries(ctx context.Context, merchantID uuid.UUID, event dto.WebhookEvent, expiresAt time.Time) (int64, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, customErrors.ErrUnableToCreate.New("failed to marshal webhook event")
+	}
+
+	var payload pgtype.JSONB
+	if err := payload.Set(body); err != nil {
+		return 0, customErrors.ErrUnableToCreate.New("failed to set webhook payload")
+	}
+
+	created, err := ws.persistencedb.Queries.CreateWebhookDeliveriesForEvent(ctx, db.CreateWebhookDeliveriesForEventParams{
+		EventID:    event.ID,
+		EventType:  event.Type,
+		Payload:    payload,
+		CreatedAt:  time.Now(),
+		ExpiresAt:  expiresAt,
+		MerchantID: merchantID,
+	})
+	if err != nil {
+		ws.logger.Named("WebhookStore-CreateWebhookDeliveries").Error(ctx, "failed to insert webhook deliveries", zap.Any("event_id", event.ID), zap.Error(err))
+		return 0, customErrors.ErrUnableToCreate.New("failed to save webhook deliveries")
+	}
+
+	return created, nil
+}
+
+// ClaimDueWebhookDeliveries returns up to limit deliveries that are due at
+// now and pushes their next attempt back to leaseUntil, so no other worker
+// picks them up while they are being sent. A delivery whose worker dies is
+// retried once the lease runs out.
+func (ws *webhookStore) ClaimDueWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]dto.WebhookDelivery, error) {
+	rows, err := ws.persistencedb.Queries.ClaimDueWebhookDeliveries(ctx, db.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil: leaseUntil,
+		Now:        now,
+		BatchSize:  int32(limit),
+	})
+	if err != nil {
+		ws.logger.Named("WebhookStore-ClaimDueWebhookDeliveries").Error(ctx, "failed to claim webhook deliveries", zap.Error(err))
+		return nil, customErrors.ErrUnableToGet.New("failed to claim webhook deliveries")
+	}
+
+	return toWebhookDeliveries(rows), nil
+}
+
+func (ws *webhookStore) RecordWebhookDeliveryAttempt(ctx context.Context, id uuid.UUID, result dto.WebhookDeliveryResult) (dto.WebhookDelivery, error) {
+	params := db.RecordWebhookDeliveryAttemptParams{
+		ID:            id,
+		Status:        db.WebhookDeliveryStatus(result.Status),
+		LastError:     result.Attempt.Error,
+		LastAttemptAt: sql.NullTime{Time: result.Attempt.At, Valid: true},
+		NextAttemptAt: result.NextAttemptAt,
+	}
+	if result.Attempt.StatusCode != 0 {
+		params.LastStatusCode = sql.NullInt32{Int32: int32(result.Attempt.StatusCode), Valid: true}
+	}
+	if result.Status == dto.WebhookDeliverySucceeded {
+		params.DeliveredAt = sql.NullTime{Time: result.Attempt.At, Valid: true}
+	}
+
+	row, err := ws.persistencedb.Queries.RecordWebhookDeliveryAttempt(ctx, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.WebhookDelivery{}, customErrors.ErrResourceNotFound.New("webhook delivery not found")
+		}
+		ws.logger.Named("WebhookStore-RecordWebhookDeliveryAttempt").Error(ctx, "failed to record webhook delivery attempt", zap.Any("id", id), zap.Error(err))
+		return dto.WebhookDelivery{}, customErrors.ErrUnableToUpdate.New("failed to record webhook delivery attempt")
+	}
+
+	return toWebhookDelivery(row), nil
+}
+
+func (ws *webhookStore) GetMerchantWebhookDeliveryByID(ctx context.Context, merchantID, id uuid.UUID) (dto.WebhookDelivery, error) {
+	row, err := ws.persistencedb.Queries.GetMerchantWebhookDeliveryByID(ctx, db.GetMerchantWebhookDeliveryByIDParams{
+		ID:         id,
+		MerchantID: merchantID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return dto.WebhookDelivery{}, customErrors.ErrResourceNotFound.New("webhook delivery not found")
+		}
+		ws.logger.Named("WebhookStore-GetMerchantWebhookDeliveryByID").Error(ctx, "failed to get webhook delivery", zap.Any("id", id), zap.Error(err))
+		return dto.WebhookDelivery{}, customErrors.ErrUnableToGet.New("failed to get webhook delivery")
+	}
+
+	return toWebhookDelivery(row), nil
+}
+
+// ListWebhookDeliveries returns the delivery log of filter.EndpointID, newest
+// first.
+func (ws *webhookStore) ListWebhookDeliveries(ctx context.Context, filter dto.WebhookDeliveryFilter) ([]dto.WebhookDelivery, error) {
+	params := db.ListEndpointWebhookDeliveriesParams{
+		EndpointID: filter.EndpointID,
+		Limit:      int32(filter.Limit),
+	}
+	if filter.Status != nil {
+		params.Status = db.NullWebhookDeliveryStatus{WebhookDeliveryStatus: db.WebhookDeliveryStatus(*filter.Status), Valid: true}
+	}
+
+	rows, err := ws.persistencedb.Queries.ListEndpointWebhookDeliveries(ctx, params)
+	if err != nil {
+		ws.logger.Named("WebhookStore-ListWebhookDeliveries").Error(ctx, "failed to list webhook deliveries", zap.Any("endpoint_id", filter.EndpointID), zap.Error(err))
+		return nil, customErrors.ErrUnableToGet.New("failed to list webhook deliveries")
+	}
+
+	return toWebhookDeliveries(rows), nil
+}
+
+// RedeliverWebhookDelivery schedules a finished delivery to be sent again
+// straight away, retrying until expiresAt. A delivery that is still PENDING
+// is already scheduled and is left alone.
+func (ws *webhookStore) RedeliverWebhookDelivery(ctx context.Context, merchantID, id uuid.UUID, expiresAt time.Time) (dto.WebhookDelivery, error) {
+	row, err := ws.persistencedb.Queries.RedeliverWebhookDelivery(ctx, db.RedeliverWebhookDeliveryParams{
+		Now:        time.Now(),
+		ExpiresAt:  expiresAt,
+		ID:         id,
+		MerchantID: merchantID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			if _, err := ws.GetMerchantWebhookDeliveryByID(ctx, merchantID, id); err != nil {
+				return dto.WebhookDelivery{}, err
+			}
+			return dto.WebhookDelivery{}, customErrors.ErrWebhookDeliveryPending.New("webhook delivery is still being retried")
+		}
+		ws.logger.Named("WebhookStore-RedeliverWebhookDelivery").Error(ctx, "failed to reschedule webhook delivery", zap.Any("id", id), zap.Error(err))
+		return dto.WebhookDelivery{}, customErrors.ErrUnableToUpdate.New("failed to reschedule webhook delivery")
+	}
+
+	return toWebhookDelivery(row), nil
+}
+
+func toWebhookEndpoint(row db.WebhookEndpoint) dto.WebhookEndpoint {
+	return dto.WebhookEndpoint{
+		ID:         row.ID,
+		MerchantID: row.MerchantID,
+		URL:        row.Url,
+		EventTypes: row.EventTypes,
+		Secret:     row.Secret,
+		CreatedAt:  persistencedb.LocalTime(row.CreatedAt),
+		UpdatedAt:  persistencedb.LocalTime(row.UpdatedAt),
+	}
+}
+
+// webhook_deliveries timestamps are local wall-clock times, like payments'
+// created_at, and are compared with time.Now() by the retry policy.
+func toWebhookDelivery(row db.WebhookDelivery) dto.WebhookDelivery {
+	delivery := dto.WebhookDelivery{
+		ID:            row.ID,
+		EndpointID:    row.EndpointID,
+		MerchantID:    row.MerchantID,
+		EventID:       row.EventID,
+		EventType:     row.EventType,
+		Payload:       row.Payload.Bytes,
+		Status:        dto.WebhookDeliveryStatus(row.Status),
+		Attempts:      int(row.Attempts),
+		LastError:     row.LastError,
+		NextAttemptAt: persistencedb.LocalTime(row.NextAttemptAt),
+		ExpiresAt:     persistencedb.LocalTime(row.ExpiresAt),
+		CreatedAt:     persistencedb.LocalTime(row.CreatedAt),
+		UpdatedAt:     persistencedb.LocalTime(row.UpdatedAt),
+	}
+	if row.LastStatusCode.Valid {
+		code := int(row.LastStatusCode.Int32)
+		delivery.LastStatusCode = &code
+	}
+	if row.LastAttemptAt.Valid {
+		lastAttemptAt := persistencedb.LocalTime(row.LastAttemptAt.Time)
+		delivery.LastAttemptAt = &lastAttemptAt
+	}
+	if row.DeliveredAt.Valid {
+		deliveredAt := persistencedb.LocalTime(row.DeliveredAt.Time)
+		delivery.DeliveredAt = &deliveredAt
+	}
+
+	return delivery
+}
+
+func toWebhookDeliveries(rows []db.WebhookDelivery) []dto.WebhookDelivery {
+	deliveries := make([]dto.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, toWebhookDelivery(row))
+	}
+
+	return deliveries
+}
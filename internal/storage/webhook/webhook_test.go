@@ -0,0 +1,178 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joomcode/errorx"
+	"github.com/kalom60/cashflow/internal/constant/dto"
+	customErrors "github.com/kalom60/cashflow/internal/constant/errors"
+	webhookModule "github.com/kalom60/cashflow/internal/module/webhook"
+	"github.com/kalom60/cashflow/internal/storage"
+	"github.com/kalom60/cashflow/internal/storage/webhook"
+	"github.com/kalom60/cashflow/tests/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	ctx        context.Context
+	store      storage.Webhook
+	merchantID uuid.UUID
+)
+
+func TestMain(m *testing.M) {
+	ctx = context.Background()
+	testDB := testutils.SetupTestDB()
+	merchantID = testutils.CreateMerchant(testDB)
+	store = webhook.Init(testutils.NewTestLogger(), &testDB)
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+func createEndpoint(t *testing.T, eventTypes ...string) dto.WebhookEndpoint {
+	t.Helper()
+
+	endpoint, err := store.CreateWebhookEndpoint(ctx, dto.WebhookEndpoint{
+		MerchantID: merchantID,
+		URL:        "https://example.com/webhooks",
+		EventTypes: eventTypes,
+		Secret:     "whsec_test",
+		CreatedAt:  time.Now(),
+	})
+	require.NoError(t, err)
+
+	return endpoint
+}
+
+func newEvent(eventType string) dto.WebhookEvent {
+	return dto.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now(),
+		Data:      json.RawMessage(`{"status":"SUCCESS"}`),
+	}
+}
+
+func TestCreateWebhookEndpoint(t *testing.T) {
+	endpoint := createEndpoint(t, dto.EventPaymentSucceeded)
+	assert.Equal(t, merchantID, endpoint.MerchantID)
+	assert.Equal(t, []string{dto.EventPaymentSucceeded}, endpoint.EventTypes)
+	assert.Equal(t, "whsec_test", endpoint.Secret)
+
+	found, err := store.GetMerchantWebhookEndpointByID(ctx, merchantID, endpoint.ID)
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.URL, found.URL)
+
+	_, err = store.GetMerchantWebhookEndpointByID(ctx, uuid.New(), endpoint.ID)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+}
+
+func TestCreateWebhookEndpointForUnknownMerchant(t *testing.T) {
+	_, err := store.CreateWebhookEndpoint(ctx, dto.WebhookEndpoint{
+		MerchantID: uuid.New(),
+		URL:        "https://example.com/webhooks",
+		EventTypes: []string{dto.EventPaymentSucceeded},
+		Secret:     "whsec_test",
+		CreatedAt:  time.Now(),
+	})
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+}
+
+func TestCreateWebhookDeliveriesOnlyForSubscribedEndpoints(t *testing.T) {
+	succeeded := createEndpoint(t, dto.EventPaymentSucceeded)
+	failed := createEndpoint(t, dto.EventPaymentFailed)
+
+	event := newEvent(dto.EventPaymentFailed)
+	count, err := store.CreateWebhookDeliveries(ctx, merchantID, event, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// The same event is only queued once per endpoint
+	count, err = store.CreateWebhookDeliveries(ctx, merchantID, event, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	deliveries, err := store.ListWebhookDeliveries(ctx, dto.WebhookDeliveryFilter{EndpointID: failed.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, event.ID, deliveries[0].EventID)
+	assert.Equal(t, dto.WebhookDeliveryPending, deliveries[0].Status)
+
+	deliveries, err = store.ListWebhookDeliveries(ctx, dto.WebhookDeliveryFilter{EndpointID: succeeded.ID, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func TestClaimRecordAndRedeliver(t *testing.T) {
+	endpoint := createEndpoint(t, dto.EventPaymentSucceeded)
+	_, err := store.CreateWebhookDeliveries(ctx, merchantID, newEvent(dto.EventPaymentSucceeded), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	now := time.Now().Add(time.Second)
+	claimed, err := store.ClaimDueWebhookDeliveries(ctx, now, now.Add(time.Minute), 100)
+	require.NoError(t, err)
+
+	var delivery dto.WebhookDelivery
+	for _, d := range claimed {
+		if d.EndpointID == endpoint.ID {
+			delivery = d
+		}
+	}
+	require.Equal(t, endpoint.ID, delivery.EndpointID)
+
+	// A leased delivery is not handed out again
+	again, err := store.ClaimDueWebhookDeliveries(ctx, now, now.Add(time.Minute), 100)
+	require.NoError(t, err)
+	for _, d := range again {
+		assert.NotEqual(t, delivery.ID, d.ID)
+	}
+
+	_, err = store.RedeliverWebhookDelivery(ctx, merchantID, delivery.ID, time.Now().Add(time.Hour))
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrWebhookDeliveryPending))
+
+	recorded, err := store.RecordWebhookDeliveryAttempt(ctx, delivery.ID, dto.WebhookDeliveryResult{
+		Status:        dto.WebhookDeliverySucceeded,
+		Attempt:       dto.WebhookAttempt{StatusCode: 200, At: now},
+		NextAttemptAt: delivery.NextAttemptAt,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, dto.WebhookDeliverySucceeded, recorded.Status)
+	assert.Equal(t, 1, recorded.Attempts)
+	require.NotNil(t, recorded.LastStatusCode)
+	assert.Equal(t, 200, *recorded.LastStatusCode)
+	assert.NotNil(t, recorded.DeliveredAt)
+
+	redelivered, err := store.RedeliverWebhookDelivery(ctx, merchantID, delivery.ID, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, dto.WebhookDeliveryPending, redelivered.Status)
+	assert.Zero(t, redelivered.Attempts)
+
+	// A redelivery that fails backs off from the start, not from where the
+	// first run of attempts left off
+	policy := webhookModule.NewRetryPolicy(30*time.Second, 6*time.Hour, time.Hour)
+	failedAt := time.Now()
+	result := policy.Result(redelivered, dto.WebhookAttempt{StatusCode: 500, Error: "endpoint responded with status 500", At: failedAt})
+	assert.Equal(t, dto.WebhookDeliveryPending, result.Status)
+	assert.Equal(t, failedAt.Add(30*time.Second), result.NextAttemptAt)
+
+	_, err = store.RedeliverWebhookDelivery(ctx, uuid.New(), delivery.ID, time.Now().Add(time.Hour))
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+}
+
+func TestDeleteWebhookEndpoint(t *testing.T) {
+	endpoint := createEndpoint(t, dto.EventPaymentSucceeded)
+
+	err := store.DeleteWebhookEndpoint(ctx, uuid.New(), endpoint.ID)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+
+	require.NoError(t, store.DeleteWebhookEndpoint(ctx, merchantID, endpoint.ID))
+
+	_, err = store.GetWebhookEndpointByID(ctx, endpoint.ID)
+	assert.True(t, errorx.IsOfType(err, customErrors.ErrResourceNotFound))
+}
@@ -0,0 +1,21 @@
+package backoff
+
+import "time"
+
+// Exponential returns the delay before the next attempt once attempts tries
+// have failed: base, doubling with every failure, capped at max.
+func Exponential(base, max time.Duration, attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+
+	return min(delay, max)
+}
@@ -0,0 +1,33 @@
+package backoff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalom60/cashflow/platform/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponential(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{name: "no failure yet", attempts: 0, want: time.Second},
+		{name: "first failure", attempts: 1, want: time.Second},
+		{name: "doubles", attempts: 3, want: 4 * time.Second},
+		{name: "capped", attempts: 5, want: 10 * time.Second},
+		{name: "does not overflow", attempts: 200, want: 10 * time.Second},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, backoff.Exponential(time.Second, 10*time.Second, tc.attempts))
+		})
+	}
+}
+
+func TestExponentialBaseAboveMax(t *testing.T) {
+	assert.Equal(t, time.Second, backoff.Exponential(time.Minute, time.Second, 1))
+}
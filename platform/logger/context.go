@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"context"
+	"time"
+)
+
+// contextKey is unexported so no other package can read or overwrite these
+// values by accident with a plain string key.
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	userKey
+	requestStartKey
+	wsRequestIDKey
+)
+
+// WithRequestID returns ctx carrying the ID of the request being served.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithUser returns ctx carrying the ID of the caller.
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey, userID)
+}
+
+// User returns the caller ID carried by ctx, or "" if there is none.
+func User(ctx context.Context) string {
+	userID, _ := ctx.Value(userKey).(string)
+	return userID
+}
+
+// WithRequestStart returns ctx carrying the time the request arrived.
+func WithRequestStart(ctx context.Context, start time.Time) context.Context {
+	return context.WithValue(ctx, requestStartKey, start)
+}
+
+// RequestStart returns the time the request arrived, if ctx carries it.
+func RequestStart(ctx context.Context) (time.Time, bool) {
+	start, ok := ctx.Value(requestStartKey).(time.Time)
+	return start, ok
+}
+
+// WithWSRequestID returns ctx carrying the request ID of a websocket client.
+func WithWSRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, wsRequestIDKey, requestID)
+}
+
+// WSRequestID returns the websocket request ID carried by ctx, or "".
+func WSRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(wsRequestIDKey).(string)
+	return requestID
+}
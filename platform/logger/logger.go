@@ -94,19 +94,19 @@ func (l *logger) extract(ctx context.Context) []zap.Field {
 	var fields []zap.Field
 	fields = append(fields, zap.String("time", time.Now().Format(time.RFC3339)))
 
-	if reqID, ok := ctx.Value("x-request-id").(string); ok {
+	if reqID := RequestID(ctx); reqID != "" {
 		fields = append(fields, zap.String("x-request-id", reqID))
 	}
 
-	if userID, ok := ctx.Value("x-user").(string); ok {
+	if userID := User(ctx); userID != "" {
 		fields = append(fields, zap.String("x-user-id", userID))
 	}
 
-	if hitTime, ok := ctx.Value("request-start-time").(time.Time); ok {
+	if hitTime, ok := RequestStart(ctx); ok {
 		fields = append(fields, zap.Float64("time-since-request", float64(time.Since(hitTime).Milliseconds())))
 	}
 
-	if socketClientReqID, ok := ctx.Value("x-ws-request-id").(string); ok {
+	if socketClientReqID := WSRequestID(ctx); socketClientReqID != "" {
 		fields = append(fields, zap.String("x-ws-request-id", socketClientReqID))
 	}
 
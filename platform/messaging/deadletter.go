@@ -0,0 +1,147 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DeadLetterQueue is a dead-letter queue and the work queue its messages are
+// replayed onto.
+type DeadLetterQueue struct {
+	Name  string
+	Queue string
+}
+
+var (
+	PaymentDeadLetters = DeadLetterQueue{Name: PaymentDLQ, Queue: PaymentQueue}
+	RefundDeadLetters  = DeadLetterQueue{Name: RefundDLQ, Queue: RefundQueue}
+)
+
+// DeadLetter is a message sitting in a dead-letter queue, along with the
+// death information the broker recorded when it was rejected.
+type DeadLetter struct {
+	MessageID      string
+	Body           []byte
+	Queue          string
+	Reason         string
+	Count          int64
+	DeadLetteredAt time.Time
+}
+
+func newDeadLetter(delivery amqp.Delivery) DeadLetter {
+	deadLetter := DeadLetter{
+		MessageID: delivery.MessageId,
+		Body:      delivery.Body,
+	}
+
+	deaths, _ := delivery.Headers["x-death"].([]any)
+	if len(deaths) == 0 {
+		return deadLetter
+	}
+
+	// The broker keeps the most recent death first
+	death, _ := deaths[0].(amqp.Table)
+	deadLetter.Queue, _ = death["queue"].(string)
+	deadLetter.Reason, _ = death["reason"].(string)
+	deadLetter.Count, _ = death["count"].(int64)
+	deadLetter.DeadLetteredAt, _ = death["time"].(time.Time)
+
+	return deadLetter
+}
+
+// adminChannel opens a short-lived channel for inspecting a dead-letter
+// queue. Messages fetched on it and not acked are returned to the queue when
+// it is closed.
+func (r *rabbitMQClient) adminChannel() (*amqp.Channel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.state != StateConnected {
+		return nil, ErrNotConnected
+	}
+
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	return ch, nil
+}
+
+// PeekDeadLetters returns up to limit messages from queue without removing
+// them.
+func (r *rabbitMQClient) PeekDeadLetters(ctx context.Context, queue DeadLetterQueue, limit int) ([]DeadLetter, error) {
+	ch, err := r.adminChannel()
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	deadLetters := make([]DeadLetter, 0, limit)
+	for len(deadLetters) < limit {
+		delivery, ok, err := ch.Get(queue.Name, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if !ok {
+			break
+		}
+
+		deadLetters = append(deadLetters, newDeadLetter(delivery))
+	}
+
+	return deadLetters, nil
+}
+
+// ReplayDeadLetters moves up to limit messages from queue back onto its work
+// queue and returns how many were moved. A message is only removed from the
+// dead-letter queue once its republish has been confirmed.
+func (r *rabbitMQClient) ReplayDeadLetters(ctx context.Context, queue DeadLetterQueue, limit int) (int, error) {
+	ch, err := r.adminChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	replayed := 0
+	for replayed < limit {
+		delivery, ok, err := ch.Get(queue.Name, false)
+		if err != nil {
+			return replayed, fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if !ok {
+			break
+		}
+
+		if err := r.publish(ctx, queue.Queue, delivery.Body, copyHeaders(delivery.Headers)); err != nil {
+			return replayed, err
+		}
+
+		if err := delivery.Ack(false); err != nil {
+			return replayed, fmt.Errorf("failed to acknowledge dead letter: %w", err)
+		}
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+// PurgeDeadLetters drops every message in queue and returns how many were
+// dropped.
+func (r *rabbitMQClient) PurgeDeadLetters(ctx context.Context, queue DeadLetterQueue) (int, error) {
+	ch, err := r.adminChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	purged, err := ch.QueuePurge(queue.Name, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge %s: %w", queue.Name, err)
+	}
+
+	return purged, nil
+}
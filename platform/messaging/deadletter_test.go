@@ -0,0 +1,43 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDeadLetterReadsDeathHeader(t *testing.T) {
+	diedAt := time.Date(2026, 1, 12, 10, 0, 0, 0, time.UTC)
+	delivery := amqp.Delivery{
+		MessageId: "msg-1",
+		Body:      []byte(`{"payment_id":"abc"}`),
+		Headers: amqp.Table{
+			"x-death": []any{
+				amqp.Table{
+					"queue":  PaymentQueue,
+					"reason": "rejected",
+					"count":  int64(2),
+					"time":   diedAt,
+				},
+			},
+		},
+	}
+
+	deadLetter := newDeadLetter(delivery)
+	assert.Equal(t, "msg-1", deadLetter.MessageID)
+	assert.Equal(t, delivery.Body, deadLetter.Body)
+	assert.Equal(t, PaymentQueue, deadLetter.Queue)
+	assert.Equal(t, "rejected", deadLetter.Reason)
+	assert.Equal(t, int64(2), deadLetter.Count)
+	assert.Equal(t, diedAt, deadLetter.DeadLetteredAt)
+}
+
+func TestNewDeadLetterWithoutDeathHeader(t *testing.T) {
+	deadLetter := newDeadLetter(amqp.Delivery{Body: []byte("not json")})
+	assert.Equal(t, []byte("not json"), deadLetter.Body)
+	assert.Empty(t, deadLetter.Queue)
+	assert.Zero(t, deadLetter.Count)
+	assert.True(t, deadLetter.DeadLetteredAt.IsZero())
+}
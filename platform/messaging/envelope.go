@@ -0,0 +1,185 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// SpecVersion is the envelope version this service produces and accepts.
+	SpecVersion = "1.0"
+	// Source identifies this service as the producer of a message.
+	Source = "cashflow"
+
+	// Envelope attributes travel as AMQP headers using the CloudEvents AMQP
+	// binding prefix, the body carries only the data.
+	headerPrefix = "cloudEvents:"
+)
+
+var (
+	// ErrUnsupportedSpecVersion is returned for messages produced with an
+	// envelope version this consumer does not understand.
+	ErrUnsupportedSpecVersion = errors.New("unsupported envelope spec version")
+)
+
+// Envelope wraps every published message, modeled on CloudEvents.
+// CorrelationID ties together every message that belongs to the same flow,
+// such as a payment and its refunds. CausationID is the ID of whatever
+// directly caused this message to be sent. RequestID is the HTTP request that
+// started the flow, if any. TraceParent and TraceState follow
+// the CloudEvents distributed tracing extension and carry the W3C trace
+// context of the publisher.
+type Envelope struct {
+	ID              string
+	Type            string
+	Source          string
+	SpecVersion     string
+	Time            time.Time
+	DataContentType string
+	CorrelationID   string
+	CausationID     string
+	RequestID       string
+	TraceParent     string
+	TraceState      string
+	Data            json.RawMessage
+}
+
+// NewEnvelope builds an envelope of the given type around data, which is
+// marshalled to JSON.
+func NewEnvelope(eventType string, data any) (Envelope, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to marshal envelope data: %w", err)
+	}
+
+	return Envelope{
+		ID:              uuid.NewString(),
+		Type:            eventType,
+		Source:          Source,
+		SpecVersion:     SpecVersion,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            body,
+	}, nil
+}
+
+// Headers returns the envelope attributes as message headers.
+func (e Envelope) Headers() map[string]any {
+	headers := map[string]any{
+		headerPrefix + "id":              e.ID,
+		headerPrefix + "type":            e.Type,
+		headerPrefix + "source":          e.Source,
+		headerPrefix + "specversion":     e.SpecVersion,
+		headerPrefix + "time":            e.Time.Format(time.RFC3339Nano),
+		headerPrefix + "datacontenttype": e.DataContentType,
+	}
+	if e.CorrelationID != "" {
+		headers[headerPrefix+"correlation_id"] = e.CorrelationID
+	}
+	if e.CausationID != "" {
+		headers[headerPrefix+"causation_id"] = e.CausationID
+	}
+	if e.RequestID != "" {
+		headers[headerPrefix+"request_id"] = e.RequestID
+	}
+	if e.TraceParent != "" {
+		headers[headerPrefix+"traceparent"] = e.TraceParent
+	}
+	if e.TraceState != "" {
+		headers[headerPrefix+"tracestate"] = e.TraceState
+	}
+
+	return headers
+}
+
+// DecodeEnvelope reads the envelope of msg. Messages published before
+// envelopes were introduced carry no attributes; they are returned with an
+// empty SpecVersion and Type so consumers can fall back to the queue's
+// default type.
+func DecodeEnvelope(msg Message) (Envelope, error) {
+	headers := msg.Headers()
+	header := func(name string) string {
+		value, _ := headers[headerPrefix+name].(string)
+		return value
+	}
+
+	env := Envelope{
+		ID:              header("id"),
+		Type:            header("type"),
+		Source:          header("source"),
+		SpecVersion:     header("specversion"),
+		DataContentType: header("datacontenttype"),
+		CorrelationID:   header("correlation_id"),
+		CausationID:     header("causation_id"),
+		RequestID:       header("request_id"),
+		TraceParent:     header("traceparent"),
+		TraceState:      header("tracestate"),
+		Data:            msg.Body(),
+	}
+
+	if env.SpecVersion == "" {
+		return env, nil
+	}
+
+	// Minor versions are backwards compatible, a new major is not
+	major, _, _ := strings.Cut(env.SpecVersion, ".")
+	supported, _, _ := strings.Cut(SpecVersion, ".")
+	if major != supported {
+		return env, fmt.Errorf("%w: %s", ErrUnsupportedSpecVersion, env.SpecVersion)
+	}
+
+	if t := header("time"); t != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return env, fmt.Errorf("invalid envelope time: %w", err)
+		}
+		env.Time = parsed
+	}
+
+	return env, nil
+}
+
+// SetTraceContext records the trace context of ctx on the envelope.
+func (e *Envelope) SetTraceContext(ctx context.Context) {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	e.TraceParent = carrier.Get("traceparent")
+	e.TraceState = carrier.Get("tracestate")
+}
+
+// TraceContext returns the span context of the publisher, which is invalid
+// when the envelope carries none.
+func (e Envelope) TraceContext() trace.SpanContext {
+	carrier := propagation.MapCarrier{"traceparent": e.TraceParent}
+	if e.TraceState != "" {
+		carrier["tracestate"] = e.TraceState
+	}
+
+	ctx := propagation.TraceContext{}.Extract(context.Background(), carrier)
+	return trace.SpanContextFromContext(ctx)
+}
+
+// copyHeaders returns the headers of a message that is being published
+// again, without the broker's bookkeeping.
+func copyHeaders(headers map[string]any) map[string]any {
+	copied := make(map[string]any, len(headers))
+	for key, value := range headers {
+		switch {
+		case key == AttemptHeader, strings.HasPrefix(key, "x-death"), strings.HasPrefix(key, "x-first-death"), strings.HasPrefix(key, "x-last-death"):
+			continue
+		}
+		copied[key] = value
+	}
+
+	return copied
+}
@@ -0,0 +1,110 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type testMessage struct {
+	body    []byte
+	headers map[string]any
+}
+
+func (m testMessage) Body() []byte            { return m.body }
+func (m testMessage) Headers() map[string]any { return m.headers }
+func (m testMessage) Ack() error              { return nil }
+func (m testMessage) Nack(requeue bool) error { return nil }
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	env, err := NewEnvelope("payment.created", map[string]string{"payment_id": "abc"})
+	require.NoError(t, err)
+	env.CorrelationID = "abc"
+	env.CausationID = "event-1"
+
+	decoded, err := DecodeEnvelope(testMessage{body: env.Data, headers: env.Headers()})
+	require.NoError(t, err)
+	assert.Equal(t, env.ID, decoded.ID)
+	assert.Equal(t, env.Type, decoded.Type)
+	assert.Equal(t, Source, decoded.Source)
+	assert.Equal(t, SpecVersion, decoded.SpecVersion)
+	assert.True(t, env.Time.Equal(decoded.Time))
+	assert.Equal(t, "application/json", decoded.DataContentType)
+	assert.Equal(t, "abc", decoded.CorrelationID)
+	assert.Equal(t, "event-1", decoded.CausationID)
+	assert.JSONEq(t, `{"payment_id":"abc"}`, string(decoded.Data))
+}
+
+func TestDecodeEnvelopeLegacyMessage(t *testing.T) {
+	decoded, err := DecodeEnvelope(testMessage{body: []byte(`{"payment_id":"abc"}`)})
+	require.NoError(t, err)
+	assert.Empty(t, decoded.SpecVersion)
+	assert.Empty(t, decoded.Type)
+	assert.JSONEq(t, `{"payment_id":"abc"}`, string(decoded.Data))
+}
+
+func TestDecodeEnvelopeSpecVersion(t *testing.T) {
+	env, err := NewEnvelope("payment.created", nil)
+	require.NoError(t, err)
+
+	headers := env.Headers()
+	headers[headerPrefix+"specversion"] = "1.3"
+	_, err = DecodeEnvelope(testMessage{headers: headers})
+	assert.NoError(t, err)
+
+	headers[headerPrefix+"specversion"] = "2.0"
+	_, err = DecodeEnvelope(testMessage{headers: headers})
+	assert.ErrorIs(t, err, ErrUnsupportedSpecVersion)
+}
+
+func TestCopyHeadersDropsBrokerBookkeeping(t *testing.T) {
+	copied := copyHeaders(map[string]any{
+		headerPrefix + "id":   "abc",
+		AttemptHeader:         int64(3),
+		"x-death":             []any{},
+		"x-first-death-queue": PaymentQueue,
+	})
+
+	assert.Equal(t, map[string]any{headerPrefix + "id": "abc"}, copied)
+}
+
+func TestEnvelopeTraceContextRoundTrip(t *testing.T) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "publish")
+	defer span.End()
+
+	env, err := NewEnvelope("payment.created", map[string]string{"payment_id": "payment-1"})
+	require.NoError(t, err)
+	env.SetTraceContext(ctx)
+	assert.NotEmpty(t, env.TraceParent)
+
+	decoded, err := DecodeEnvelope(testMessage{body: env.Data, headers: env.Headers()})
+	require.NoError(t, err)
+	assert.Equal(t, span.SpanContext().TraceID(), decoded.TraceContext().TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), decoded.TraceContext().SpanID())
+
+	// Messages without trace headers have no publisher span to link to
+	legacy, err := DecodeEnvelope(testMessage{body: env.Data})
+	require.NoError(t, err)
+	assert.False(t, legacy.TraceContext().IsValid())
+}
+
+func TestEnvelopeRequestID(t *testing.T) {
+	env, err := NewEnvelope("payment.created", map[string]string{"payment_id": "payment-1"})
+	require.NoError(t, err)
+	env.RequestID = "req-42"
+
+	decoded, err := DecodeEnvelope(testMessage{body: env.Data, headers: env.Headers()})
+	require.NoError(t, err)
+	assert.Equal(t, "req-42", decoded.RequestID)
+
+	// The request ID survives a retry
+	assert.Equal(t, "req-42", copyHeaders(env.Headers())["cloudEvents:request_id"])
+}
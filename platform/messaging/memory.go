@@ -0,0 +1,413 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// memoryQueue is an unbounded FIFO shared by every consumer of a queue.
+type memoryQueue struct {
+	mu       sync.Mutex
+	messages []*memoryMessage
+	ready    chan struct{}
+}
+
+func newMemoryQueue() *memoryQueue {
+	return &memoryQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *memoryQueue) push(msg *memoryMessage) {
+	q.mu.Lock()
+	q.messages = append(q.messages, msg)
+	q.mu.Unlock()
+
+	q.signal()
+}
+
+// pushFront puts msg back at the head of the queue, which is where RabbitMQ
+// requeues a message that was delivered but not settled.
+func (q *memoryQueue) pushFront(msg *memoryMessage) {
+	q.mu.Lock()
+	q.messages = append([]*memoryMessage{msg}, q.messages...)
+	q.mu.Unlock()
+
+	q.signal()
+}
+
+func (q *memoryQueue) pop() (*memoryMessage, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.messages) == 0 {
+		return nil, false
+	}
+
+	msg := q.messages[0]
+	q.messages = q.messages[1:]
+
+	// Wake the next consumer if there is more work
+	if len(q.messages) > 0 {
+		q.signal()
+	}
+
+	return msg, true
+}
+
+func (q *memoryQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (q *memoryQueue) purge() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	purged := len(q.messages)
+	q.messages = nil
+
+	return purged
+}
+
+// memoryMessage is a Message delivered by the in-memory client.
+type memoryMessage struct {
+	client  *memoryClient
+	queue   string
+	id      string
+	body    []byte
+	headers map[string]any
+
+	// release frees the consumer's prefetch slot once the message is settled
+	release func()
+	settled sync.Once
+}
+
+func (m *memoryMessage) Body() []byte {
+	return m.body
+}
+
+func (m *memoryMessage) Headers() map[string]any {
+	return m.headers
+}
+
+func (m *memoryMessage) Ack() error {
+	m.settled.Do(m.settle)
+	return nil
+}
+
+func (m *memoryMessage) Nack(requeue bool) error {
+	m.settled.Do(func() {
+		m.settle()
+
+		if requeue {
+			m.client.queues[m.queue].pushFront(m.redeliver())
+			return
+		}
+
+		for _, work := range workQueues {
+			if m.queue == work.name {
+				m.client.deadLetter(m, work.dlq)
+			}
+		}
+	})
+
+	return nil
+}
+
+func (m *memoryMessage) settle() {
+	if m.release != nil {
+		m.release()
+	}
+}
+
+// redeliver returns an unsettled copy of m for putting back on its queue.
+func (m *memoryMessage) redeliver() *memoryMessage {
+	return &memoryMessage{
+		client:  m.client,
+		queue:   m.queue,
+		id:      m.id,
+		body:    m.body,
+		headers: m.headers,
+	}
+}
+
+type memoryClient struct {
+	opts     Options
+	queues   map[string]*memoryQueue
+	inFlight atomic.Int64
+
+	mu              sync.RWMutex
+	state           ConnectionState
+	prefetchChanged chan struct{}
+	done            chan struct{}
+}
+
+// NewInMemoryClient returns a MessagingClient that keeps every queue in
+// process. It mirrors the RabbitMQ client's semantics, including delayed
+// retries and the dead-letter queues, but nothing survives a restart.
+// It is meant for tests and for running the service without a broker.
+func NewInMemoryClient(opts Options) MessagingClient {
+	return &memoryClient{
+		opts: opts.withDefaults(),
+		queues: map[string]*memoryQueue{
+			PaymentQueue: newMemoryQueue(),
+			RefundQueue:  newMemoryQueue(),
+			PaymentDLQ:   newMemoryQueue(),
+			RefundDLQ:    newMemoryQueue(),
+		},
+		state:           StateConnected,
+		prefetchChanged: make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+func (c *memoryClient) publish(queue string, body []byte, headers map[string]any) error {
+	if c.State() != StateConnected {
+		return ErrNotConnected
+	}
+
+	c.queues[queue].push(&memoryMessage{
+		client:  c,
+		queue:   queue,
+		id:      uuid.NewString(),
+		body:    body,
+		headers: headers,
+	})
+
+	return nil
+}
+
+func (c *memoryClient) consume(ctx context.Context, queue string) (<-chan Message, error) {
+	if c.State() != StateConnected {
+		return nil, ErrNotConnected
+	}
+
+	q := c.queues[queue]
+	out := make(chan Message)
+
+	// Like basic.qos, hold back deliveries while Prefetch messages are
+	// unsettled. released is signalled whenever one of them is settled.
+	var unsettled atomic.Int64
+	released := make(chan struct{}, 1)
+
+	go func() {
+		defer close(out)
+
+		for {
+			prefetch, changed := c.prefetch()
+			if unsettled.Load() >= int64(prefetch) {
+				select {
+				case <-released:
+				case <-changed:
+				case <-ctx.Done():
+					return
+				case <-c.done:
+					return
+				}
+				continue
+			}
+
+			msg, ok := q.pop()
+			if !ok {
+				select {
+				case <-ctx.Done():
+					return
+				case <-c.done:
+					return
+				case <-q.ready:
+				}
+				continue
+			}
+
+			unsettled.Add(1)
+			c.inFlight.Add(1)
+			msg.release = func() {
+				c.inFlight.Add(-1)
+				unsettled.Add(-1)
+
+				select {
+				case released <- struct{}{}:
+				default:
+				}
+			}
+
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				c.inFlight.Add(-1)
+				q.pushFront(msg.redeliver())
+				return
+			case <-c.done:
+				c.inFlight.Add(-1)
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// prefetch returns the current prefetch and a channel that is closed on the
+// next SetPrefetch.
+func (c *memoryClient) prefetch() (int, <-chan struct{}) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.opts.Prefetch, c.prefetchChanged
+}
+
+func (c *memoryClient) SetPrefetch(prefetch int) error {
+	if prefetch <= 0 {
+		return fmt.Errorf("prefetch must be positive, got %d", prefetch)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.opts.Prefetch = prefetch
+	close(c.prefetchChanged)
+	c.prefetchChanged = make(chan struct{})
+
+	return nil
+}
+
+// deadLetter parks msg in dlq with an x-death header shaped like the one
+// RabbitMQ adds.
+func (c *memoryClient) deadLetter(msg *memoryMessage, dlq string) {
+	headers := make(map[string]any, len(msg.headers)+1)
+	for key, value := range msg.headers {
+		headers[key] = value
+	}
+	headers["x-death"] = []any{amqp.Table{
+		"queue":  msg.queue,
+		"reason": "rejected",
+		"count":  int64(1),
+		"time":   time.Now(),
+	}}
+
+	c.queues[dlq].push(&memoryMessage{
+		client:  c,
+		queue:   dlq,
+		id:      msg.id,
+		body:    msg.body,
+		headers: headers,
+	})
+}
+
+func (c *memoryClient) PublishPayment(ctx context.Context, env Envelope) error {
+	return c.publish(PaymentQueue, env.Data, env.Headers())
+}
+
+func (c *memoryClient) ConsumePayments(ctx context.Context) (<-chan Message, error) {
+	return c.consume(ctx, PaymentQueue)
+}
+
+func (c *memoryClient) PublishRefund(ctx context.Context, env Envelope) error {
+	return c.publish(RefundQueue, env.Data, env.Headers())
+}
+
+func (c *memoryClient) ConsumeRefunds(ctx context.Context) (<-chan Message, error) {
+	return c.consume(ctx, RefundQueue)
+}
+
+func (c *memoryClient) RetryPayment(ctx context.Context, msg Message) error {
+	return c.retry(PaymentQueue, msg)
+}
+
+func (c *memoryClient) RetryRefund(ctx context.Context, msg Message) error {
+	return c.retry(RefundQueue, msg)
+}
+
+func (c *memoryClient) MaxAttempts() int {
+	return c.opts.MaxAttempts
+}
+
+// retry redelivers msg on queue after the delay of its retry tier, or
+// dead-letters it once MaxAttempts is reached.
+func (c *memoryClient) retry(queue string, msg Message) error {
+	attempt := Attempt(msg.Headers())
+	if attempt >= c.opts.MaxAttempts {
+		return msg.Nack(false)
+	}
+
+	body := msg.Body()
+	headers := copyHeaders(msg.Headers())
+	headers[AttemptHeader] = int64(attempt + 1)
+	time.AfterFunc(retryDelay(c.opts.RetryDelays, attempt), func() {
+		_ = c.publish(queue, body, headers)
+	})
+
+	return msg.Ack()
+}
+
+func (c *memoryClient) PeekDeadLetters(ctx context.Context, queue DeadLetterQueue, limit int) ([]DeadLetter, error) {
+	q := c.queues[queue.Name]
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	deadLetters := make([]DeadLetter, 0, min(limit, len(q.messages)))
+	for _, msg := range q.messages[:min(limit, len(q.messages))] {
+		deadLetters = append(deadLetters, newDeadLetter(amqp.Delivery{
+			MessageId: msg.id,
+			Body:      msg.body,
+			Headers:   msg.headers,
+		}))
+	}
+
+	return deadLetters, nil
+}
+
+func (c *memoryClient) ReplayDeadLetters(ctx context.Context, queue DeadLetterQueue, limit int) (int, error) {
+	q := c.queues[queue.Name]
+
+	replayed := 0
+	for replayed < limit {
+		msg, ok := q.pop()
+		if !ok {
+			break
+		}
+
+		if err := c.publish(queue.Queue, msg.body, copyHeaders(msg.headers)); err != nil {
+			q.push(msg.redeliver())
+			return replayed, err
+		}
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+func (c *memoryClient) PurgeDeadLetters(ctx context.Context, queue DeadLetterQueue) (int, error) {
+	return c.queues[queue.Name].purge(), nil
+}
+
+func (c *memoryClient) InFlight() int {
+	return int(c.inFlight.Load())
+}
+
+func (c *memoryClient) State() ConnectionState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.state
+}
+
+func (c *memoryClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state != StateClosed {
+		c.state = StateClosed
+		close(c.done)
+	}
+
+	return nil
+}
@@ -0,0 +1,308 @@
+package messaging_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, msgs <-chan messaging.Message) messaging.Message {
+	t.Helper()
+
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+func envelope(t *testing.T, eventType string, data map[string]string) messaging.Envelope {
+	t.Helper()
+
+	env, err := messaging.NewEnvelope(eventType, data)
+	require.NoError(t, err)
+	return env
+}
+
+func TestInMemoryPublishConsume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := messaging.NewInMemoryClient(messaging.Options{})
+	defer client.Close()
+
+	msgs, err := client.ConsumePayments(ctx)
+	require.NoError(t, err)
+
+	sent := envelope(t, "payment.created", map[string]string{"payment_id": "payment-1"})
+	sent.CorrelationID = "payment-1"
+	require.NoError(t, client.PublishPayment(ctx, sent))
+
+	msg := receive(t, msgs)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(msg.Body(), &body))
+	assert.Equal(t, "payment-1", body["payment_id"])
+	assert.Equal(t, 1, messaging.Attempt(msg.Headers()))
+
+	received, err := messaging.DecodeEnvelope(msg)
+	require.NoError(t, err)
+	assert.Equal(t, sent.ID, received.ID)
+	assert.Equal(t, "payment.created", received.Type)
+	assert.Equal(t, "payment-1", received.CorrelationID)
+	assert.NoError(t, msg.Ack())
+}
+
+func TestInMemoryNackRequeue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := messaging.NewInMemoryClient(messaging.Options{})
+	defer client.Close()
+
+	msgs, err := client.ConsumeRefunds(ctx)
+	require.NoError(t, err)
+	require.NoError(t, client.PublishRefund(ctx, envelope(t, "refund.created", map[string]string{"refund_id": "refund-1"})))
+
+	msg := receive(t, msgs)
+	require.NoError(t, msg.Nack(true))
+
+	redelivered := receive(t, msgs)
+	assert.Equal(t, msg.Body(), redelivered.Body())
+	assert.NoError(t, redelivered.Ack())
+}
+
+func refundID(t *testing.T, msg messaging.Message) string {
+	t.Helper()
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(msg.Body(), &body))
+	return body["refund_id"]
+}
+
+func TestInMemoryNackRequeueKeepsOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := messaging.NewInMemoryClient(messaging.Options{Prefetch: 1})
+	defer client.Close()
+
+	msgs, err := client.ConsumeRefunds(ctx)
+	require.NoError(t, err)
+	for _, id := range []string{"refund-1", "refund-2", "refund-3"} {
+		require.NoError(t, client.PublishRefund(ctx, envelope(t, "refund.created", map[string]string{"refund_id": id})))
+	}
+
+	require.NoError(t, receive(t, msgs).Nack(true))
+
+	// The requeued message goes back to the head, ahead of the later ones
+	for _, id := range []string{"refund-1", "refund-2", "refund-3"} {
+		msg := receive(t, msgs)
+		assert.Equal(t, id, refundID(t, msg))
+		require.NoError(t, msg.Ack())
+	}
+}
+
+func TestInMemoryCancelledConsumerKeepsOrder(t *testing.T) {
+	client := messaging.NewInMemoryClient(messaging.Options{})
+	defer client.Close()
+
+	consumerCtx, cancelConsumer := context.WithCancel(context.Background())
+	msgs, err := client.ConsumeRefunds(consumerCtx)
+	require.NoError(t, err)
+	for _, id := range []string{"refund-1", "refund-2", "refund-3"} {
+		require.NoError(t, client.PublishRefund(context.Background(), envelope(t, "refund.created", map[string]string{"refund_id": id})))
+	}
+
+	first := receive(t, msgs)
+	assert.Equal(t, "refund-1", refundID(t, first))
+	require.NoError(t, first.Ack())
+
+	// Cancel while the consumer holds refund-2, it has to put it back at the
+	// head of the queue
+	assert.Eventually(t, func() bool { return client.InFlight() == 1 }, 2*time.Second, 10*time.Millisecond)
+	cancelConsumer()
+	assert.Eventually(t, func() bool { return client.InFlight() == 0 }, 2*time.Second, 10*time.Millisecond)
+	for range msgs {
+		t.Fatal("delivered a message after the consumer was cancelled")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgs, err = client.ConsumeRefunds(ctx)
+	require.NoError(t, err)
+	for _, id := range []string{"refund-2", "refund-3"} {
+		msg := receive(t, msgs)
+		assert.Equal(t, id, refundID(t, msg))
+		require.NoError(t, msg.Ack())
+	}
+	assert.Equal(t, 0, client.InFlight())
+}
+
+func TestInMemoryPrefetch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := messaging.NewInMemoryClient(messaging.Options{Prefetch: 2})
+	defer client.Close()
+
+	msgs, err := client.ConsumeRefunds(ctx)
+	require.NoError(t, err)
+	for _, id := range []string{"refund-1", "refund-2", "refund-3"} {
+		require.NoError(t, client.PublishRefund(ctx, envelope(t, "refund.created", map[string]string{"refund_id": id})))
+	}
+
+	first := receive(t, msgs)
+	second := receive(t, msgs)
+	assert.Equal(t, 2, client.InFlight())
+
+	// The third message is held back until one of the first two is settled
+	select {
+	case <-msgs:
+		t.Fatal("delivered more than the prefetch limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, first.Ack())
+	third := receive(t, msgs)
+	assert.Equal(t, 2, client.InFlight())
+
+	require.NoError(t, second.Ack())
+	require.NoError(t, third.Nack(false))
+	assert.Equal(t, 0, client.InFlight())
+}
+
+func TestInMemorySetPrefetch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := messaging.NewInMemoryClient(messaging.Options{Prefetch: 1})
+	defer client.Close()
+
+	msgs, err := client.ConsumeRefunds(ctx)
+	require.NoError(t, err)
+	for _, id := range []string{"refund-1", "refund-2", "refund-3"} {
+		require.NoError(t, client.PublishRefund(ctx, envelope(t, "refund.created", map[string]string{"refund_id": id})))
+	}
+
+	first := receive(t, msgs)
+
+	// A running consumer picks up a raised prefetch without a settle
+	require.NoError(t, client.SetPrefetch(2))
+	second := receive(t, msgs)
+	assert.Equal(t, 2, client.InFlight())
+
+	select {
+	case <-msgs:
+		t.Fatal("delivered more than the prefetch limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.Error(t, client.SetPrefetch(0))
+	require.NoError(t, first.Ack())
+	require.NoError(t, second.Ack())
+	require.NoError(t, receive(t, msgs).Ack())
+}
+
+func TestInMemoryRetryThenDeadLetter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := messaging.NewInMemoryClient(messaging.Options{
+		MaxAttempts: 2,
+		RetryDelays: []time.Duration{10 * time.Millisecond},
+	})
+	defer client.Close()
+
+	msgs, err := client.ConsumePayments(ctx)
+	require.NoError(t, err)
+
+	sent := envelope(t, "payment.created", map[string]string{"payment_id": "payment-1"})
+	require.NoError(t, client.PublishPayment(ctx, sent))
+
+	first := receive(t, msgs)
+	require.NoError(t, client.RetryPayment(ctx, first))
+
+	second := receive(t, msgs)
+	assert.Equal(t, 2, messaging.Attempt(second.Headers()))
+
+	// Retries keep the envelope
+	retried, err := messaging.DecodeEnvelope(second)
+	require.NoError(t, err)
+	assert.Equal(t, sent.ID, retried.ID)
+
+	// Attempts are used up, so this retry dead-letters the message
+	require.NoError(t, client.RetryPayment(ctx, second))
+
+	deadLetters, err := client.PeekDeadLetters(ctx, messaging.PaymentDeadLetters, 10)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, messaging.PaymentQueue, deadLetters[0].Queue)
+	assert.Equal(t, "rejected", deadLetters[0].Reason)
+
+	replayed, err := client.ReplayDeadLetters(ctx, messaging.PaymentDeadLetters, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+
+	replay := receive(t, msgs)
+	assert.Equal(t, 1, messaging.Attempt(replay.Headers()))
+	assert.NoError(t, replay.Ack())
+
+	purged, err := client.PurgeDeadLetters(ctx, messaging.PaymentDeadLetters)
+	require.NoError(t, err)
+	assert.Equal(t, 0, purged)
+}
+
+func TestInMemoryRefundRetryThenDeadLetter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := messaging.NewInMemoryClient(messaging.Options{
+		MaxAttempts: 2,
+		RetryDelays: []time.Duration{10 * time.Millisecond},
+	})
+	defer client.Close()
+
+	msgs, err := client.ConsumeRefunds(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, client.PublishRefund(ctx, envelope(t, "refund.created", map[string]string{"refund_id": "refund-1"})))
+
+	first := receive(t, msgs)
+	require.NoError(t, client.RetryRefund(ctx, first))
+
+	second := receive(t, msgs)
+	assert.Equal(t, 2, messaging.Attempt(second.Headers()))
+	require.NoError(t, client.RetryRefund(ctx, second))
+
+	deadLetters, err := client.PeekDeadLetters(ctx, messaging.RefundDeadLetters, 10)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, messaging.RefundQueue, deadLetters[0].Queue)
+
+	// Refunds never end up with the payments
+	deadLetters, err = client.PeekDeadLetters(ctx, messaging.PaymentDeadLetters, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deadLetters)
+}
+
+func TestInMemoryClose(t *testing.T) {
+	client := messaging.NewInMemoryClient(messaging.Options{})
+
+	msgs, err := client.ConsumePayments(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, client.Close())
+	assert.Equal(t, messaging.StateClosed, client.State())
+	assert.ErrorIs(t, client.PublishPayment(context.Background(), envelope(t, "payment.created", nil)), messaging.ErrNotConnected)
+
+	_, ok := <-msgs
+	assert.False(t, ok)
+}
@@ -0,0 +1,45 @@
+package messaging
+
+import (
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Message is a delivery handed to a consumer. Every message must be settled
+// exactly once with Ack or Nack.
+type Message interface {
+	Body() []byte
+	Headers() map[string]any
+	// Ack confirms the message was handled and removes it from the queue.
+	Ack() error
+	// Nack rejects the message. With requeue it is redelivered, otherwise it
+	// is dead-lettered if the queue has a dead-letter queue, or dropped.
+	Nack(requeue bool) error
+}
+
+// amqpMessage adapts a RabbitMQ delivery to Message. settled runs once, the
+// first time the message is acked or nacked.
+type amqpMessage struct {
+	delivery amqp.Delivery
+	settled  func()
+	once     sync.Once
+}
+
+func (m *amqpMessage) Body() []byte {
+	return m.delivery.Body
+}
+
+func (m *amqpMessage) Headers() map[string]any {
+	return m.delivery.Headers
+}
+
+func (m *amqpMessage) Ack() error {
+	m.once.Do(m.settled)
+	return m.delivery.Ack(false)
+}
+
+func (m *amqpMessage) Nack(requeue bool) error {
+	m.once.Do(m.settled)
+	return m.delivery.Nack(false, requeue)
+}
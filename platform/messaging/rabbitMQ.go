@@ -2,84 +2,486 @@ package messaging
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 const (
-	PaymentQueue = "payments"
+	// The work queues are versioned because a queue's arguments cannot change
+	// once it is declared, and v2 added dead-lettering.
+	PaymentQueue = "payments.v2"
+	RefundQueue  = "refunds.v2"
+
+	// The work queues from before dead-lettering. Messages left on them, or
+	// published to them by older instances during a rolling upgrade, are
+	// moved onto the v2 queues.
+	LegacyPaymentQueue = "payments"
+	LegacyRefundQueue  = "refunds"
+
+	// Messages rejected by a consumer are dead-lettered through the queue's
+	// DLX into its DLQ.
+	PaymentDLX = "payments.dlx"
+	PaymentDLQ = "payments.dlq"
+	RefundDLX  = "refunds.dlx"
+	RefundDLQ  = "refunds.dlq"
+
+	DefaultConfirmTimeout = 5 * time.Second
+	DefaultMaxAttempts    = 5
+	DefaultPrefetch       = 10
+
+	reconnectBaseDelay = time.Second
+	reconnectMaxDelay  = 30 * time.Second
+)
+
+var (
+	// ErrPublishNacked is returned when the broker refuses to take ownership
+	// of a published message.
+	ErrPublishNacked = errors.New("message was nacked by the broker")
+	// ErrPublishReturned is returned when a mandatory message could not be
+	// routed to any queue.
+	ErrPublishReturned = errors.New("message was returned as unroutable")
+	// ErrNotConnected is returned when publishing while the client is
+	// reconnecting or closed.
+	ErrNotConnected = errors.New("not connected to RabbitMQ")
+)
+
+// DefaultRetryDelays are the retry tiers of every work queue. A message's n-th retry
+// waits in the n-th tier, the last tier is reused for any further retries.
+var DefaultRetryDelays = []time.Duration{5 * time.Second, 30 * time.Second, 5 * time.Minute}
+
+// Options tunes the RabbitMQ client. Zero values fall back to the defaults.
+type Options struct {
+	// ConfirmTimeout is how long a publish waits for the broker to confirm it.
+	ConfirmTimeout time.Duration
+	// MaxAttempts is how many times a message is delivered before it is
+	// dead-lettered.
+	MaxAttempts int
+	// RetryDelays are the delays of the retry tiers.
+	RetryDelays []time.Duration
+	// Prefetch caps how many unsettled messages each consumer holds, so the
+	// payment and refund consumers together hold up to twice as many. The
+	// broker stops delivering until some are acked or nacked.
+	Prefetch int
+}
+
+func (o Options) withDefaults() Options {
+	if o.ConfirmTimeout <= 0 {
+		o.ConfirmTimeout = DefaultConfirmTimeout
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = DefaultMaxAttempts
+	}
+	if len(o.RetryDelays) == 0 {
+		o.RetryDelays = DefaultRetryDelays
+	}
+	if o.Prefetch <= 0 {
+		o.Prefetch = DefaultPrefetch
+	}
+
+	return o
+}
+
+// ConnectionState describes the client's connection to the broker.
+type ConnectionState string
+
+const (
+	StateConnected    ConnectionState = "connected"
+	StateReconnecting ConnectionState = "reconnecting"
+	StateClosed       ConnectionState = "closed"
 )
 
 type MessagingClient interface {
-	PublishPayment(ctx context.Context, paymentID string) error
-	ConsumePayments(ctx context.Context) (<-chan amqp.Delivery, error)
+	PublishPayment(ctx context.Context, env Envelope) error
+	ConsumePayments(ctx context.Context) (<-chan Message, error)
+	PublishRefund(ctx context.Context, env Envelope) error
+	ConsumeRefunds(ctx context.Context) (<-chan Message, error)
+	RetryPayment(ctx context.Context, msg Message) error
+	RetryRefund(ctx context.Context, msg Message) error
+	// MaxAttempts is how many deliveries a message gets before a retry
+	// dead-letters it instead.
+	MaxAttempts() int
+	PeekDeadLetters(ctx context.Context, queue DeadLetterQueue, limit int) ([]DeadLetter, error)
+	ReplayDeadLetters(ctx context.Context, queue DeadLetterQueue, limit int) (int, error)
+	PurgeDeadLetters(ctx context.Context, queue DeadLetterQueue) (int, error)
+	// SetPrefetch changes how many unsettled messages each consumer may
+	// hold, including consumers that are already running.
+	SetPrefetch(prefetch int) error
+	// InFlight is the number of delivered messages not yet acked or nacked.
+	InFlight() int
+	State() ConnectionState
 	Close() error
 }
 
+// consumer is a subscription that outlives the AMQP channel it was first
+// opened on. Deliveries are forwarded to out, which stays open across
+// reconnects and is only closed when ctx is done or the client is closed.
+type consumer struct {
+	ctx          context.Context
+	queue        string
+	tag          string
+	out          chan Message
+	resubscribed chan (<-chan amqp.Delivery)
+}
+
 type rabbitMQClient struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
+	url  string
+	opts Options
+
+	mu             sync.RWMutex
+	state          ConnectionState
+	conn           *amqp.Connection
+	channel        *amqp.Channel
+	publishChannel *amqp.Channel
+	returns        chan amqp.Return
+	connClosed     chan *amqp.Error
+	consumers      []*consumer
+
+	// Publishes go through their own channel in confirm mode. They are
+	// serialized so that a basic.return can be matched to the publish it
+	// belongs to: the broker always sends it before the ack.
+	publishMu sync.Mutex
+
+	inFlight atomic.Int64
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-func NewRabbitMQClient(url string) (MessagingClient, error) {
-	conn, err := amqp.Dial(url)
+// NewRabbitMQClient connects to RabbitMQ and declares the work queues.
+// Publishes wait up to opts.ConfirmTimeout for the broker to confirm them. If
+// the connection drops, the client reconnects with backoff and re-subscribes
+// every active consumer.
+func NewRabbitMQClient(url string, opts Options) (MessagingClient, error) {
+	r := &rabbitMQClient{
+		url:  url,
+		opts: opts.withDefaults(),
+		done: make(chan struct{}),
+	}
+
+	if err := r.connect(); err != nil {
+		return nil, err
+	}
+
+	go r.supervise()
+
+	for from, to := range map[string]string{LegacyPaymentQueue: PaymentQueue, LegacyRefundQueue: RefundQueue} {
+		if err := r.migrate(from, to); err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// connect dials the broker, opens the consume and publish channels and
+// declares the topology.
+func (r *rabbitMQClient) connect() error {
+	conn, err := amqp.Dial(r.url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
 
 	ch, err := conn.Channel()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open a channel: %w", err)
+		conn.Close()
+		return fmt.Errorf("failed to open a channel: %w", err)
 	}
 
-	_, err = ch.QueueDeclare(
-		PaymentQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+	r.mu.RLock()
+	prefetch := r.opts.Prefetch
+	r.mu.RUnlock()
+
+	// Applies to every consumer started on the channel from here on
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to set prefetch: %w", err)
+	}
+
+	publishCh, err := conn.Channel()
 	if err != nil {
-		return nil, fmt.Errorf("failed to declare a queue: %w", err)
+		conn.Close()
+		return fmt.Errorf("failed to open a publish channel: %w", err)
+	}
+
+	if err := publishCh.Confirm(false); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to put channel in confirm mode: %w", err)
+	}
+
+	if err := declareTopology(ch, r.opts.RetryDelays); err != nil {
+		conn.Close()
+		return err
+	}
+
+	r.mu.Lock()
+	r.conn = conn
+	r.channel = ch
+	r.publishChannel = publishCh
+	r.returns = publishCh.NotifyReturn(make(chan amqp.Return, 1))
+	r.connClosed = conn.NotifyClose(make(chan *amqp.Error, 1))
+	r.state = StateConnected
+	r.mu.Unlock()
+
+	return nil
+}
+
+type queueSpec struct {
+	name string
+	args amqp.Table
+}
+
+// workQueue is a queue consumers read from, with the exchange and queue its
+// rejected messages are dead-lettered to.
+type workQueue struct {
+	name string
+	dlx  string
+	dlq  string
+}
+
+var workQueues = []workQueue{
+	{name: PaymentQueue, dlx: PaymentDLX, dlq: PaymentDLQ},
+	{name: RefundQueue, dlx: RefundDLX, dlq: RefundDLQ},
+}
+
+func declareTopology(ch *amqp.Channel, retryDelays []time.Duration) error {
+	for _, work := range workQueues {
+		if err := declareWorkQueue(ch, work, retryDelays); err != nil {
+			return err
+		}
 	}
 
-	return &rabbitMQClient{
-		conn:    conn,
-		channel: ch,
-	}, nil
+	return nil
 }
 
-func (r *rabbitMQClient) PublishPayment(ctx context.Context, paymentID string) error {
-	body, err := json.Marshal(map[string]string{"payment_id": paymentID})
-	if err != nil {
-		return fmt.Errorf("failed to marshal payment ID: %w", err)
+func declareWorkQueue(ch *amqp.Channel, work workQueue, retryDelays []time.Duration) error {
+	if err := ch.ExchangeDeclare(work.dlx, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", work.dlx, err)
 	}
 
-	err = r.channel.PublishWithContext(ctx,
-		"",
-		PaymentQueue,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
+	queues := []queueSpec{
+		{name: work.name, args: amqp.Table{"x-dead-letter-exchange": work.dlx}},
+		{name: work.dlq},
+	}
+
+	// Retry tiers hold a message for their TTL, then dead-letter it back
+	// onto the work queue through the default exchange
+	for _, delay := range retryDelays {
+		queues = append(queues, queueSpec{
+			name: retryQueueName(work.name, delay),
+			args: amqp.Table{
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": work.name,
+			},
 		})
+	}
+
+	for _, queue := range queues {
+		_, err := ch.QueueDeclare(
+			queue.name,
+			true,
+			false,
+			false,
+			false,
+			queue.args,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare queue %s: %w", queue.name, err)
+		}
+	}
+
+	// Dead-lettered messages keep their original routing key
+	if err := ch.QueueBind(work.dlq, work.name, work.dlx, false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue %s: %w", work.dlq, err)
+	}
+
+	return nil
+}
+
+// migrate moves every message on the legacy queue from onto queue to, for as
+// long as the client runs. It does nothing when from was never declared, so
+// fresh installs do not get the legacy queue. from is only looked up
+// passively, since an older deployment may have declared it with arguments
+// this version does not know about.
+func (r *rabbitMQClient) migrate(from, to string) error {
+	ch, err := r.adminChannel()
 	if err != nil {
-		return fmt.Errorf("failed to publish message: %w", err)
+		return err
+	}
+	// A missing queue closes the channel, so it is only used for the lookup
+	_, err = ch.QueueDeclarePassive(from, true, false, false, false, nil)
+	ch.Close()
+	if err != nil {
+		var amqpErr *amqp.Error
+		if errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to look up queue %s: %w", from, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	msgs, err := r.consume(ctx, from)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	go func() {
+		<-r.done
+		cancel()
+	}()
+
+	go func() {
+		for msg := range msgs {
+			if err := r.publish(ctx, to, msg.Body(), copyHeaders(msg.Headers())); err != nil {
+				log.Printf("failed to move message from %s to %s: %v", from, to, err)
+				// Do not spin on the message while the broker refuses it
+				time.Sleep(reconnectBaseDelay)
+				_ = msg.Nack(true)
+				continue
+			}
+			_ = msg.Ack()
+		}
+	}()
+
+	log.Printf("Moving messages from legacy queue %s to %s", from, to)
+	return nil
+}
+
+// supervise waits for the connection to drop and brings it back, retrying
+// with exponential backoff until it succeeds or the client is closed.
+func (r *rabbitMQClient) supervise() {
+	for {
+		r.mu.RLock()
+		connClosed := r.connClosed
+		r.mu.RUnlock()
+
+		select {
+		case <-r.done:
+			return
+		case amqpErr := <-connClosed:
+			select {
+			case <-r.done:
+				return
+			default:
+			}
+			log.Printf("RabbitMQ connection lost: %v", amqpErr)
+		}
+
+		r.setState(StateReconnecting)
+
+		delay := reconnectBaseDelay
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-time.After(delay):
+			}
+
+			if err := r.connect(); err != nil {
+				delay = min(delay*2, reconnectMaxDelay)
+				log.Printf("RabbitMQ reconnect failed, retrying in %s: %v", delay, err)
+				continue
+			}
+
+			break
+		}
+
+		log.Printf("RabbitMQ connection re-established")
+		r.resubscribe()
+	}
+}
+
+// resubscribe opens a fresh broker subscription for every consumer that is
+// still active and hands it to the consumer's forwarder.
+func (r *rabbitMQClient) resubscribe() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	active := r.consumers[:0]
+	for _, c := range r.consumers {
+		if c.ctx.Err() != nil {
+			continue
+		}
+		active = append(active, c)
+
+		deliveries, err := r.subscribe(c.queue, c.tag)
+		if err != nil {
+			log.Printf("failed to re-subscribe to %s: %v", c.queue, err)
+			continue
+		}
+
+		c.handOver(deliveries)
+	}
+	r.consumers = active
+}
+
+// handOver passes a new broker subscription to the consumer's forwarder,
+// which switches to it once the current one is drained.
+func (c *consumer) handOver(deliveries <-chan amqp.Delivery) {
+	// Replace a subscription the forwarder has not picked up yet
+	select {
+	case <-c.resubscribed:
+	default:
+	}
+	c.resubscribed <- deliveries
+}
+
+// SetPrefetch applies a new basic.qos to the consume channel. The broker
+// only applies it to consumers started afterwards, so every active consumer
+// is cancelled and subscribed again. Messages it already holds stay unacked
+// and can still be settled. While disconnected, the value is kept for the
+// next connect.
+func (r *rabbitMQClient) SetPrefetch(prefetch int) error {
+	if prefetch <= 0 {
+		return fmt.Errorf("prefetch must be positive, got %d", prefetch)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.opts.Prefetch = prefetch
+	if r.state != StateConnected {
+		return nil
+	}
+
+	if err := r.channel.Qos(prefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %w", err)
+	}
+
+	for _, c := range r.consumers {
+		if c.ctx.Err() != nil {
+			continue
+		}
+
+		if err := r.channel.Cancel(c.tag, false); err != nil {
+			return fmt.Errorf("failed to cancel consumer %s: %w", c.tag, err)
+		}
+
+		deliveries, err := r.subscribe(c.queue, c.tag)
+		if err != nil {
+			return err
+		}
+
+		c.handOver(deliveries)
 	}
 
-	log.Printf("Published payment message: %s", paymentID)
 	return nil
 }
 
-func (r *rabbitMQClient) ConsumePayments(ctx context.Context) (<-chan amqp.Delivery, error) {
+// subscribe must be called with r.mu held.
+func (r *rabbitMQClient) subscribe(queue, tag string) (<-chan amqp.Delivery, error) {
 	msgs, err := r.channel.Consume(
-		PaymentQueue,
-		"",
+		queue,
+		tag,
 		false,
 		false,
 		false,
@@ -89,12 +491,229 @@ func (r *rabbitMQClient) ConsumePayments(ctx context.Context) (<-chan amqp.Deliv
 	if err != nil {
 		return nil, fmt.Errorf("failed to consume messages: %w", err)
 	}
+
 	return msgs, nil
 }
 
-func (r *rabbitMQClient) Close() error {
-	if err := r.channel.Close(); err != nil {
+func (r *rabbitMQClient) consume(ctx context.Context, queue string) (<-chan Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.state != StateConnected {
+		return nil, ErrNotConnected
+	}
+
+	tag := queue + "-" + uuid.NewString()
+	deliveries, err := r.subscribe(queue, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &consumer{
+		ctx:          ctx,
+		queue:        queue,
+		tag:          tag,
+		out:          make(chan Message),
+		resubscribed: make(chan (<-chan amqp.Delivery), 1),
+	}
+	r.consumers = append(r.consumers, c)
+
+	go r.forward(c, deliveries)
+
+	return c.out, nil
+}
+
+// forward copies deliveries to the consumer's output channel. When the
+// broker subscription ends it waits for the supervisor to hand over a new
+// one instead of closing the output.
+func (r *rabbitMQClient) forward(c *consumer, deliveries <-chan amqp.Delivery) {
+	defer close(c.out)
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			r.cancel(c)
+			return
+		case <-r.done:
+			return
+		case delivery, ok := <-deliveries:
+			if !ok {
+				select {
+				case <-c.ctx.Done():
+					r.cancel(c)
+					return
+				case <-r.done:
+					return
+				case deliveries = <-c.resubscribed:
+				}
+				continue
+			}
+
+			select {
+			case c.out <- r.track(delivery):
+			case <-c.ctx.Done():
+				r.cancel(c)
+				return
+			case <-r.done:
+				return
+			}
+		}
+	}
+}
+
+// cancel stops the broker from delivering to c. Messages it has already
+// pushed but nobody received stay unacked and are requeued when the channel
+// closes.
+func (r *rabbitMQClient) cancel(c *consumer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.state != StateConnected {
+		return
+	}
+
+	if err := r.channel.Cancel(c.tag, false); err != nil {
+		log.Printf("failed to cancel consumer %s: %v", c.tag, err)
+	}
+}
+
+// track counts delivery as in flight until it is settled.
+func (r *rabbitMQClient) track(delivery amqp.Delivery) Message {
+	r.inFlight.Add(1)
+
+	return &amqpMessage{
+		delivery: delivery,
+		settled:  func() { r.inFlight.Add(-1) },
+	}
+}
+
+func (r *rabbitMQClient) InFlight() int {
+	return int(r.inFlight.Load())
+}
+
+func (r *rabbitMQClient) setState(state ConnectionState) {
+	r.mu.Lock()
+	r.state = state
+	r.mu.Unlock()
+}
+
+func (r *rabbitMQClient) State() ConnectionState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.state
+}
+
+// publish sends body to queue as a mandatory message and waits for the
+// broker to confirm it. It only returns nil once the broker has taken
+// ownership of the message.
+func (r *rabbitMQClient) publish(ctx context.Context, queue string, body []byte, headers amqp.Table) error {
+	r.publishMu.Lock()
+	defer r.publishMu.Unlock()
+
+	r.mu.RLock()
+	state, publishChannel, returns := r.state, r.publishChannel, r.returns
+	r.mu.RUnlock()
+
+	if state != StateConnected {
+		return ErrNotConnected
+	}
+
+	messageID := uuid.NewString()
+	confirmation, err := publishChannel.PublishWithDeferredConfirmWithContext(ctx,
+		"",
+		queue,
+		true,
+		false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    messageID,
+			Headers:      headers,
+			Body:         body,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	confirmCtx, cancel := context.WithTimeout(ctx, r.opts.ConfirmTimeout)
+	defer cancel()
+
+	acked, err := confirmation.WaitContext(confirmCtx)
+	if err != nil {
+		return fmt.Errorf("failed to confirm message: %w", err)
+	}
+
+	// A return for this publish is dispatched before its ack. Anything else
+	// left in the channel belongs to a publish that timed out earlier.
+drain:
+	for {
+		select {
+		case ret := <-returns:
+			if ret.MessageId == messageID {
+				return fmt.Errorf("%w: %s (%d)", ErrPublishReturned, ret.ReplyText, ret.ReplyCode)
+			}
+		default:
+			break drain
+		}
+	}
+
+	if !acked {
+		return ErrPublishNacked
+	}
+
+	return nil
+}
+
+func (r *rabbitMQClient) PublishPayment(ctx context.Context, env Envelope) error {
+	if err := r.publish(ctx, PaymentQueue, env.Data, env.Headers()); err != nil {
 		return err
 	}
-	return r.conn.Close()
+
+	log.Printf("Published payment message: %s (type=%s correlation_id=%s)", env.ID, env.Type, env.CorrelationID)
+	return nil
+}
+
+func (r *rabbitMQClient) ConsumePayments(ctx context.Context) (<-chan Message, error) {
+	return r.consume(ctx, PaymentQueue)
+}
+
+func (r *rabbitMQClient) PublishRefund(ctx context.Context, env Envelope) error {
+	if err := r.publish(ctx, RefundQueue, env.Data, env.Headers()); err != nil {
+		return err
+	}
+
+	log.Printf("Published refund message: %s (type=%s correlation_id=%s)", env.ID, env.Type, env.CorrelationID)
+	return nil
+}
+
+func (r *rabbitMQClient) ConsumeRefunds(ctx context.Context) (<-chan Message, error) {
+	return r.consume(ctx, RefundQueue)
+}
+
+func (r *rabbitMQClient) Close() error {
+	var err error
+	r.closeOnce.Do(func() {
+		close(r.done)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.state = StateClosed
+		if r.conn == nil || r.conn.IsClosed() {
+			return
+		}
+
+		if closeErr := r.publishChannel.Close(); closeErr != nil {
+			err = closeErr
+			return
+		}
+		if closeErr := r.channel.Close(); closeErr != nil {
+			err = closeErr
+			return
+		}
+		err = r.conn.Close()
+	})
+
+	return err
 }
@@ -0,0 +1,87 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AttemptHeader counts how many times a message has been delivered to its
+// worker. It is absent on the first delivery.
+const AttemptHeader = "x-attempt"
+
+// RetryPayment schedules a payment message for another delivery, see retry.
+func (r *rabbitMQClient) RetryPayment(ctx context.Context, msg Message) error {
+	return r.retry(ctx, PaymentQueue, msg)
+}
+
+// RetryRefund schedules a refund message for another delivery, see retry.
+func (r *rabbitMQClient) RetryRefund(ctx context.Context, msg Message) error {
+	return r.retry(ctx, RefundQueue, msg)
+}
+
+func (r *rabbitMQClient) MaxAttempts() int {
+	return r.opts.MaxAttempts
+}
+
+// retry schedules msg for another delivery on queue after the delay of its
+// retry tier and acks the original. Once the message has been delivered
+// MaxAttempts times it is rejected instead, which dead-letters it to the
+// queue's DLQ. If the retry cannot be published the message is requeued.
+func (r *rabbitMQClient) retry(ctx context.Context, queue string, msg Message) error {
+	attempt := Attempt(msg.Headers())
+	if attempt >= r.opts.MaxAttempts {
+		if err := msg.Nack(false); err != nil {
+			return fmt.Errorf("failed to dead-letter message: %w", err)
+		}
+		return nil
+	}
+
+	headers := copyHeaders(msg.Headers())
+	headers[AttemptHeader] = int64(attempt + 1)
+
+	tier := retryQueueName(queue, retryDelay(r.opts.RetryDelays, attempt))
+	if err := r.publish(ctx, tier, msg.Body(), headers); err != nil {
+		_ = msg.Nack(true)
+		return fmt.Errorf("failed to schedule retry: %w", err)
+	}
+
+	if err := msg.Ack(); err != nil {
+		return fmt.Errorf("failed to acknowledge message: %w", err)
+	}
+
+	return nil
+}
+
+// Attempt returns which delivery attempt headers belong to, starting at 1.
+func Attempt(headers map[string]any) int {
+	switch attempt := headers[AttemptHeader].(type) {
+	case int64:
+		return int(attempt)
+	case int32:
+		return int(attempt)
+	case int:
+		return attempt
+	default:
+		return 1
+	}
+}
+
+// retryDelay returns the delay before the retry that follows attempt.
+func retryDelay(delays []time.Duration, attempt int) time.Duration {
+	tier := min(max(attempt-1, 0), len(delays)-1)
+	return delays[tier]
+}
+
+func retryQueueName(queue string, delay time.Duration) string {
+	switch {
+	case delay%time.Hour == 0:
+		return fmt.Sprintf("%s.retry.%dh", queue, delay/time.Hour)
+	case delay%time.Minute == 0:
+		return fmt.Sprintf("%s.retry.%dm", queue, delay/time.Minute)
+	case delay%time.Second == 0:
+		return fmt.Sprintf("%s.retry.%ds", queue, delay/time.Second)
+	default:
+		return fmt.Sprintf("%s.retry.%dms", queue, delay.Milliseconds())
+	}
+}
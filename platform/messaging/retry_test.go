@@ -0,0 +1,34 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttempt(t *testing.T) {
+	assert.Equal(t, 1, Attempt(nil))
+	assert.Equal(t, 1, Attempt(amqp.Table{}))
+	assert.Equal(t, 3, Attempt(amqp.Table{AttemptHeader: int64(3)}))
+	assert.Equal(t, 2, Attempt(amqp.Table{AttemptHeader: int32(2)}))
+}
+
+func TestRetryDelayTiers(t *testing.T) {
+	delays := []time.Duration{5 * time.Second, 30 * time.Second, 5 * time.Minute}
+
+	assert.Equal(t, 5*time.Second, retryDelay(delays, 1))
+	assert.Equal(t, 30*time.Second, retryDelay(delays, 2))
+	assert.Equal(t, 5*time.Minute, retryDelay(delays, 3))
+	assert.Equal(t, 5*time.Minute, retryDelay(delays, 10))
+}
+
+func TestRetryQueueName(t *testing.T) {
+	assert.Equal(t, "payments.v2.retry.5s", retryQueueName(PaymentQueue, 5*time.Second))
+	assert.Equal(t, "payments.v2.retry.30s", retryQueueName(PaymentQueue, 30*time.Second))
+	assert.Equal(t, "payments.v2.retry.5m", retryQueueName(PaymentQueue, 5*time.Minute))
+	assert.Equal(t, "payments.v2.retry.1h", retryQueueName(PaymentQueue, time.Hour))
+	assert.Equal(t, "payments.v2.retry.1500ms", retryQueueName(PaymentQueue, 1500*time.Millisecond))
+	assert.Equal(t, "refunds.v2.retry.5s", retryQueueName(RefundQueue, 5*time.Second))
+}
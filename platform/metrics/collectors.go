@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kalom60/cashflow/platform/workerpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterWorkerPool exposes the queue depth, busy workers and size of pool.
+func RegisterWorkerPool(pool *workerpool.WorkerPool) {
+	Registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "workerpool",
+			Name:      "queue_depth",
+			Help:      "Tasks waiting for a worker.",
+		}, func() float64 { return float64(pool.QueueDepth()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "workerpool",
+			Name:      "busy_workers",
+			Help:      "Workers currently running a task.",
+		}, func() float64 { return float64(pool.Busy()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "workerpool",
+			Name:      "workers",
+			Help:      "Number of workers the pool is scaled to.",
+		}, func() float64 { return float64(pool.Size()) }),
+	)
+}
+
+// RegisterDBPool exposes the connection statistics of pool.
+func RegisterDBPool(pool *pgxpool.Pool) {
+	Registry.MustRegister(&dbPoolCollector{pool: pool})
+}
+
+var (
+	dbAcquiredConns = prometheus.NewDesc(namespace+"_db_pool_acquired_connections", "Connections currently in use.", nil, nil)
+	dbIdleConns     = prometheus.NewDesc(namespace+"_db_pool_idle_connections", "Idle connections in the pool.", nil, nil)
+	dbTotalConns    = prometheus.NewDesc(namespace+"_db_pool_total_connections", "Connections open in the pool.", nil, nil)
+	dbMaxConns      = prometheus.NewDesc(namespace+"_db_pool_max_connections", "Maximum size of the pool.", nil, nil)
+	dbAcquires      = prometheus.NewDesc(namespace+"_db_pool_acquires_total", "Connections acquired from the pool.", nil, nil)
+	dbEmptyAcquires = prometheus.NewDesc(namespace+"_db_pool_empty_acquires_total", "Acquires that had to wait because the pool was empty.", nil, nil)
+	dbCanceled      = prometheus.NewDesc(namespace+"_db_pool_canceled_acquires_total", "Acquires canceled by their context.", nil, nil)
+	dbAcquireWait   = prometheus.NewDesc(namespace+"_db_pool_acquire_wait_seconds_total", "Time spent waiting for a connection.", nil, nil)
+)
+
+// dbPoolCollector reads pgxpool statistics on every scrape.
+type dbPoolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbAcquiredConns
+	ch <- dbIdleConns
+	ch <- dbTotalConns
+	ch <- dbMaxConns
+	ch <- dbAcquires
+	ch <- dbEmptyAcquires
+	ch <- dbCanceled
+	ch <- dbAcquireWait
+}
+
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(dbAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(dbIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(dbTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(dbMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(dbAcquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(dbEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(dbCanceled, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(dbAcquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import "github.com/kalom60/cashflow/platform/messaging"
+
+const (
+	OutcomeAck     = "ack"
+	OutcomeNack    = "nack"
+	OutcomeRequeue = "requeue"
+)
+
+// instrumentedMessage counts how a message is settled.
+type instrumentedMessage struct {
+	messaging.Message
+	queue string
+}
+
+// InstrumentMessage wraps msg so that acking or nacking it is counted in
+// ConsumerMessages under queue.
+func InstrumentMessage(queue string, msg messaging.Message) messaging.Message {
+	return &instrumentedMessage{Message: msg, queue: queue}
+}
+
+func (m *instrumentedMessage) Ack() error {
+	ConsumerMessages.WithLabelValues(m.queue, OutcomeAck).Inc()
+	return m.Message.Ack()
+}
+
+func (m *instrumentedMessage) Nack(requeue bool) error {
+	outcome := OutcomeNack
+	if requeue {
+		outcome = OutcomeRequeue
+	}
+	ConsumerMessages.WithLabelValues(m.queue, outcome).Inc()
+
+	return m.Message.Nack(requeue)
+}
@@ -0,0 +1,39 @@
+package metrics_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kalom60/cashflow/platform/messaging"
+	"github.com/kalom60/cashflow/platform/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentMessageCountsOutcomes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := messaging.NewInMemoryClient(messaging.Options{})
+	defer client.Close()
+
+	msgs, err := client.ConsumeRefunds(ctx)
+	require.NoError(t, err)
+
+	env, err := messaging.NewEnvelope("refund.created", map[string]string{"refund_id": "refund-1"})
+	require.NoError(t, err)
+	require.NoError(t, client.PublishRefund(ctx, env))
+
+	ack := metrics.ConsumerMessages.WithLabelValues(messaging.RefundQueue, metrics.OutcomeAck)
+	requeue := metrics.ConsumerMessages.WithLabelValues(messaging.RefundQueue, metrics.OutcomeRequeue)
+
+	msg := metrics.InstrumentMessage(messaging.RefundQueue, <-msgs)
+	require.NoError(t, msg.Nack(true))
+	assert.Equal(t, 1.0, testutil.ToFloat64(requeue))
+
+	msg = metrics.InstrumentMessage(messaging.RefundQueue, <-msgs)
+	require.NoError(t, msg.Ack())
+	assert.Equal(t, 1.0, testutil.ToFloat64(ack))
+	assert.Equal(t, 0, client.InFlight())
+}
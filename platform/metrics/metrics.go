@@ -0,0 +1,100 @@
+// Package metrics holds the Prometheus collectors of the service and the
+// handler that exposes them on /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cashflow"
+
+// Registry holds every collector of the service. It is separate from the
+// global default registry so tests and libraries cannot pollute it.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	PaymentsCreated = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payments_created_total",
+		Help:      "Payments created by currency and status.",
+	}, []string{"currency", "status"})
+
+	OutboxBacklog = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "backlog",
+		Help:      "Outbox events waiting to be published.",
+	})
+
+	OutboxPublishDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "publish_duration_seconds",
+		Help:      "Time taken to publish an outbox event, including the broker confirm.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"event_type"})
+
+	OutboxPublishFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "publish_failures_total",
+		Help:      "Outbox events that failed to publish.",
+	}, []string{"event_type"})
+
+	ConsumerProcessingDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "processing_duration_seconds",
+		Help:      "Time taken to process a consumed message.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"queue"})
+
+	ConsumerMessages = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "messages_total",
+		Help:      "Consumed messages by how they were settled: ack, nack or requeue.",
+	}, []string{"queue", "outcome"})
+
+	WebhookDeliveryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "delivery_duration_seconds",
+		Help:      "Time taken by merchant endpoints to answer a webhook delivery.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"event_type"})
+
+	WebhookDeliveries = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "deliveries_total",
+		Help:      "Webhook delivery attempts by outcome: succeeded, retry or failed.",
+	}, []string{"event_type", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the collectors in Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,64 @@
+// Package nonce remembers recently used nonces so that a signed request can
+// only be accepted once.
+package nonce
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval bounds how often expired nonces are dropped.
+const sweepInterval = time.Minute
+
+// Store is an in-memory set of nonces that forgets each one after its TTL.
+// It only covers the process it lives in: instances behind a load balancer
+// each keep their own set.
+type Store struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		nonces: make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+// Use records nonce for ttl and reports whether it was unused. A nonce seen
+// again before its TTL runs out is a replay.
+func (s *Store) Use(nonce string, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	if expiresAt, ok := s.nonces[nonce]; ok && now.Before(expiresAt) {
+		return false
+	}
+
+	s.nonces[nonce] = now.Add(ttl)
+	return true
+}
+
+// Len is the number of nonces currently remembered.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.nonces)
+}
+
+func (s *Store) sweep(now time.Time) {
+	for nonce, expiresAt := range s.nonces {
+		if !now.Before(expiresAt) {
+			delete(s.nonces, nonce)
+		}
+	}
+	s.lastSweep = now
+}
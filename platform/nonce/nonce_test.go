@@ -0,0 +1,32 @@
+package nonce
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseRejectsReplays(t *testing.T) {
+	s := NewStore()
+
+	assert.True(t, s.Use("merchant-1:abc", time.Minute))
+	assert.False(t, s.Use("merchant-1:abc", time.Minute))
+	assert.True(t, s.Use("merchant-2:abc", time.Minute))
+}
+
+func TestUseForgetsExpiredNonces(t *testing.T) {
+	now := time.Unix(1768550400, 0)
+	s := NewStore()
+	s.now = func() time.Time { return now }
+
+	assert.True(t, s.Use("abc", time.Minute))
+	assert.True(t, s.Use("def", 10*time.Minute))
+
+	now = now.Add(2 * time.Minute)
+	assert.True(t, s.Use("abc", time.Minute), "an expired nonce can be used again")
+
+	now = now.Add(2 * time.Minute)
+	s.Use("ghi", time.Minute)
+	assert.Equal(t, 2, s.Len(), "expired nonces are swept")
+}
@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "SUCCESS"
+	OutcomeFailed  Outcome = "FAILED"
+	OutcomePending Outcome = "PENDING"
+)
+
+// Result is the typed answer a PaymentProcessor gives for a single payment.
+// ReasonCode is only set when Outcome is OutcomeFailed or OutcomePending.
+type Result struct {
+	Outcome    Outcome
+	ReasonCode string
+}
+
+// Payment is what a processor is told about a payment it has to settle.
+type Payment struct {
+	ID        uuid.UUID
+	Reference uuid.UUID
+	Amount    decimal.Decimal
+	Currency  string
+}
+
+// Refund is what a processor is told about a refund it has to settle.
+type Refund struct {
+	ID        uuid.UUID
+	PaymentID uuid.UUID
+	Amount    decimal.Decimal
+	Currency  string
+}
+
+// PaymentProcessor settles a locked payment or refund against a payment
+// network. Returning an error means the processor could not be reached and
+// the work should be retried; a decline is a Result, not an error.
+type PaymentProcessor interface {
+	Process(ctx context.Context, payment Payment) (Result, error)
+	Refund(ctx context.Context, refund Refund) (Result, error)
+}
+
+func Success() Result {
+	return Result{Outcome: OutcomeSuccess}
+}
+
+func Failed(reasonCode string) Result {
+	return Result{Outcome: OutcomeFailed, ReasonCode: reasonCode}
+}
+
+func Pending(reasonCode string) Result {
+	return Result{Outcome: OutcomePending, ReasonCode: reasonCode}
+}
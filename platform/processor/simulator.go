@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ReasonInsufficientFunds = "insufficient_funds"
+	ReasonCardDeclined      = "card_declined"
+	ReasonDoNotHonor        = "do_not_honor"
+	ReasonAwaitingIssuer    = "awaiting_issuer"
+)
+
+var ErrProcessorUnavailable = errors.New("payment processor unavailable")
+
+// simulator mimics a card-network test mode: the outcome is decided by the
+// minor units (cents) of the amount so the same request always produces the
+// same result.
+//
+//	.51 -> FAILED  insufficient_funds
+//	.52 -> FAILED  card_declined
+//	.53 -> FAILED  do_not_honor
+//	.54 -> PENDING awaiting_issuer
+//	.55 -> error   processor unavailable
+//	any other amount -> SUCCESS
+//
+// Refunds follow the same table using the refund amount.
+type simulator struct{}
+
+func NewSimulator() PaymentProcessor {
+	return &simulator{}
+}
+
+func (s *simulator) Process(ctx context.Context, payment Payment) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	return outcomeFor(payment.Amount)
+}
+
+func (s *simulator) Refund(ctx context.Context, refund Refund) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	return outcomeFor(refund.Amount)
+}
+
+func outcomeFor(amount decimal.Decimal) (Result, error) {
+	cents := amount.Shift(2).Truncate(0).IntPart() % 100
+
+	switch cents {
+	case 51:
+		return Failed(ReasonInsufficientFunds), nil
+	case 52:
+		return Failed(ReasonCardDeclined), nil
+	case 53:
+		return Failed(ReasonDoNotHonor), nil
+	case 54:
+		return Pending(ReasonAwaitingIssuer), nil
+	case 55:
+		return Result{}, ErrProcessorUnavailable
+	default:
+		return Success(), nil
+	}
+}
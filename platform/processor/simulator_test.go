@@ -0,0 +1,62 @@
+package processor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kalom60/cashflow/platform/processor"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulatorOutcomes(t *testing.T) {
+	sim := processor.NewSimulator()
+
+	cases := []struct {
+		amount  string
+		outcome processor.Outcome
+		reason  string
+	}{
+		{"100.00", processor.OutcomeSuccess, ""},
+		{"100.51", processor.OutcomeFailed, processor.ReasonInsufficientFunds},
+		{"7.52", processor.OutcomeFailed, processor.ReasonCardDeclined},
+		{"1000000.53", processor.OutcomeFailed, processor.ReasonDoNotHonor},
+		{"0.54", processor.OutcomePending, processor.ReasonAwaitingIssuer},
+	}
+
+	for _, c := range cases {
+		res, err := sim.Process(context.Background(), processor.Payment{Amount: decimal.RequireFromString(c.amount)})
+		assert.NoError(t, err, c.amount)
+		assert.Equal(t, c.outcome, res.Outcome, c.amount)
+		assert.Equal(t, c.reason, res.ReasonCode, c.amount)
+	}
+}
+
+func TestSimulatorUnavailable(t *testing.T) {
+	_, err := processor.NewSimulator().Process(context.Background(), processor.Payment{Amount: decimal.RequireFromString("20.55")})
+	assert.ErrorIs(t, err, processor.ErrProcessorUnavailable)
+}
+
+func TestSimulatorIsDeterministic(t *testing.T) {
+	sim := processor.NewSimulator()
+	p := processor.Payment{Amount: decimal.RequireFromString("42.52")}
+
+	first, _ := sim.Process(context.Background(), p)
+	for i := 0; i < 10; i++ {
+		res, _ := sim.Process(context.Background(), p)
+		assert.Equal(t, first, res)
+	}
+}
+
+func TestSimulatorRefundOutcomes(t *testing.T) {
+	sim := processor.NewSimulator()
+
+	res, err := sim.Refund(context.Background(), processor.Refund{Amount: decimal.RequireFromString("10.00")})
+	assert.NoError(t, err)
+	assert.Equal(t, processor.OutcomeSuccess, res.Outcome)
+
+	res, err = sim.Refund(context.Background(), processor.Refund{Amount: decimal.RequireFromString("10.52")})
+	assert.NoError(t, err)
+	assert.Equal(t, processor.OutcomeFailed, res.Outcome)
+	assert.Equal(t, processor.ReasonCardDeclined, res.ReasonCode)
+}
@@ -0,0 +1,69 @@
+package signature_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalom60/cashflow/platform/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPayload() signature.Payload {
+	return signature.Payload{
+		Method:    "POST",
+		Path:      "/api/v1/payments",
+		Timestamp: time.Unix(1768550400, 0),
+		Nonce:     "3f2b8c1e-0d4a-4c55-9a57-7d7f3c2a9e10",
+		Body:      []byte(`{"amount":"10.00","currency":"ETB","reference":"order-1"}`),
+	}
+}
+
+func TestSignIsDeterministic(t *testing.T) {
+	p := testPayload()
+
+	sig := signature.Sign("secret", p)
+
+	assert.Equal(t, sig, signature.Sign("secret", p))
+	assert.Regexp(t, `^v1=[0-9a-f]{64}$`, sig)
+	assert.NotEqual(t, sig, signature.Sign("other-secret", p))
+}
+
+func TestVerify(t *testing.T) {
+	p := testPayload()
+	sig := signature.Sign("secret", p)
+
+	assert.True(t, signature.Verify([]string{"secret"}, sig, p))
+	assert.True(t, signature.Verify([]string{"new-secret", "secret"}, sig, p), "any of the secrets may match during a rotation")
+	assert.False(t, signature.Verify([]string{"other-secret"}, sig, p))
+	assert.False(t, signature.Verify(nil, sig, p))
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	sig := signature.Sign("secret", testPayload())
+
+	tests := map[string]func(p *signature.Payload){
+		"method":    func(p *signature.Payload) { p.Method = "PUT" },
+		"path":      func(p *signature.Payload) { p.Path = "/api/v1/payments?x=1" },
+		"timestamp": func(p *signature.Payload) { p.Timestamp = p.Timestamp.Add(time.Second) },
+		"nonce":     func(p *signature.Payload) { p.Nonce = "another-nonce" },
+		"body":      func(p *signature.Payload) { p.Body = []byte(`{"amount":"1000.00"}`) },
+	}
+
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := testPayload()
+			tamper(&p)
+
+			assert.False(t, signature.Verify([]string{"secret"}, sig, p))
+		})
+	}
+}
+
+func TestVerifyRejectsMalformedHeaders(t *testing.T) {
+	p := testPayload()
+	sig := signature.Sign("secret", p)
+
+	for _, header := range []string{"", sig[len("v1="):], "v2=" + sig[len("v1="):], "v1=not-hex"} {
+		assert.False(t, signature.Verify([]string{"secret"}, header, p), header)
+	}
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryLogger turns the query log entries of pgx into spans before passing
+// them on.
+type queryLogger struct {
+	next     pgx.Logger
+	minLevel pgx.LogLevel
+}
+
+// QueryLogger wraps next so every query logged by pgx also becomes a span.
+// pgx v4 has no tracer hook, so the span is reconstructed from the duration
+// pgx reports once the query has finished. Only queries that run under an
+// existing span are traced, so background polling stays out of the traces.
+// Entries are forwarded to next when they are at least as severe as level.
+//
+// The pgx connection must log at pgx.LogLevelInfo or lower, which is the
+// level queries are reported at.
+func QueryLogger(next pgx.Logger, level pgx.LogLevel) pgx.Logger {
+	return &queryLogger{next: next, minLevel: level}
+}
+
+func (l *queryLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]any) {
+	l.span(ctx, level, msg, data)
+
+	// A lower pgx.LogLevel is more severe
+	if level <= l.minLevel {
+		l.next.Log(ctx, level, msg, data)
+	}
+}
+
+func (l *queryLogger) span(ctx context.Context, level pgx.LogLevel, msg string, data map[string]any) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	sql, ok := data["sql"].(string)
+	if !ok {
+		return
+	}
+
+	end := time.Now()
+	start := end
+	if took, ok := data["time"].(time.Duration); ok {
+		start = end.Add(-took)
+	}
+
+	_, span := Tracer().Start(ctx, "db "+msg,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBQueryText(sql),
+		),
+	)
+
+	if rows, ok := data["rowCount"].(int); ok {
+		span.SetAttributes(attribute.Int("db.rows", rows))
+	}
+	if level <= pgx.LogLevelError {
+		span.SetStatus(codes.Error, fmt.Sprint(data["err"]))
+	}
+
+	span.End(trace.WithTimestamp(end))
+}
@@ -0,0 +1,96 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/kalom60/cashflow/platform/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return recorder
+}
+
+type recordingLogger struct {
+	levels []pgx.LogLevel
+}
+
+func (l *recordingLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]any) {
+	l.levels = append(l.levels, level)
+}
+
+func TestQueryLoggerCreatesChildSpan(t *testing.T) {
+	recorder := setupRecorder(t)
+	next := &recordingLogger{}
+	queryLogger := tracing.QueryLogger(next, pgx.LogLevelWarn)
+
+	// Without a parent span the query is not traced
+	queryLogger.Log(context.Background(), pgx.LogLevelInfo, "Query", map[string]any{"sql": "SELECT 1", "time": time.Millisecond})
+	assert.Empty(t, recorder.Ended())
+
+	ctx, parent := tracing.Tracer().Start(context.Background(), "request")
+	queryLogger.Log(ctx, pgx.LogLevelInfo, "Query", map[string]any{"sql": "SELECT 1", "time": 50 * time.Millisecond, "rowCount": 1})
+	queryLogger.Log(ctx, pgx.LogLevelError, "Exec", map[string]any{"sql": "DELETE FROM payments", "time": time.Millisecond, "err": "boom"})
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	query := spans[0]
+	assert.Equal(t, "db Query", query.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+	assert.Equal(t, trace.SpanKindClient, query.SpanKind())
+	assert.InDelta(t, 50*time.Millisecond, query.EndTime().Sub(query.StartTime()), float64(time.Millisecond))
+
+	assert.Equal(t, "db Exec", spans[1].Name())
+	assert.Equal(t, "boom", spans[1].Status().Description)
+
+	// Only the error reached the wrapped logger
+	assert.Equal(t, []pgx.LogLevel{pgx.LogLevelError}, next.levels)
+}
+
+func TestInjectExtract(t *testing.T) {
+	setupRecorder(t)
+
+	ctx, span := tracing.Tracer().Start(context.Background(), "request")
+	defer span.End()
+
+	headers := map[string]string{"correlation_id": "payment-1"}
+	tracing.Inject(ctx, headers)
+	assert.Contains(t, headers, "traceparent")
+
+	extracted := trace.SpanContextFromContext(tracing.Extract(context.Background(), headers))
+	assert.Equal(t, span.SpanContext().TraceID(), extracted.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), extracted.SpanID())
+}
+
+func TestStartConsumerLinksPublisher(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	_, publisher := tracing.Tracer().Start(context.Background(), "publish")
+	publisher.End()
+
+	_, consumer := tracing.StartConsumer(context.Background(), "process payments", publisher.SpanContext())
+	consumer.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.NotEqual(t, publisher.SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+	require.Len(t, spans[1].Links(), 1)
+	assert.Equal(t, publisher.SpanContext().SpanID(), spans[1].Links()[0].SpanContext.SpanID())
+}
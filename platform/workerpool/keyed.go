@@ -0,0 +1,60 @@
+package workerpool
+
+// keyQueue holds the tasks waiting behind the one currently running for a
+// key.
+type keyQueue struct {
+	tasks []Task
+}
+
+// SubmitWithKey queues task behind every task previously submitted with the
+// same key. Tasks for one key run one at a time and in submission order,
+// while different keys run concurrently. A key only holds state while it has
+// tasks queued or running.
+//
+// The worker that picks up a key runs its queue until it is empty, so a very
+// busy key keeps that worker to itself.
+func (p *WorkerPool) SubmitWithKey(key string, task Task) {
+	p.keysMu.Lock()
+	if q, ok := p.keys[key]; ok {
+		q.tasks = append(q.tasks, task)
+		p.keysMu.Unlock()
+		return
+	}
+	p.keys[key] = &keyQueue{}
+	p.keysMu.Unlock()
+
+	if !p.submit(func() { p.runKey(key, task) }) {
+		p.keysMu.Lock()
+		delete(p.keys, key)
+		p.keysMu.Unlock()
+	}
+}
+
+// ActiveKeys is the number of keys with tasks queued or running.
+func (p *WorkerPool) ActiveKeys() int {
+	p.keysMu.Lock()
+	defer p.keysMu.Unlock()
+
+	return len(p.keys)
+}
+
+// runKey runs task and then the tasks queued behind it, evicting the key once
+// its queue is empty.
+func (p *WorkerPool) runKey(key string, task Task) {
+	for {
+		p.call(task)
+
+		p.keysMu.Lock()
+		q := p.keys[key]
+		if len(q.tasks) == 0 {
+			delete(p.keys, key)
+			p.keysMu.Unlock()
+			return
+		}
+
+		task = q.tasks[0]
+		q.tasks[0] = nil
+		q.tasks = q.tasks[1:]
+		p.keysMu.Unlock()
+	}
+}
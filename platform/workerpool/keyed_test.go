@@ -0,0 +1,115 @@
+package workerpool_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kalom60/cashflow/platform/workerpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitWithKeyRunsInOrder(t *testing.T) {
+	pool := workerpool.New(8, 100, workerpool.Options{})
+	pool.Start()
+
+	var (
+		mu    sync.Mutex
+		order []int
+	)
+	for i := range 1000 {
+		pool.SubmitWithKey("payment-1", func() {
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		})
+	}
+
+	require.NoError(t, pool.StopAndWait(context.Background()))
+	require.Len(t, order, 1000)
+	for i, got := range order {
+		assert.Equal(t, i, got)
+	}
+}
+
+func TestSubmitWithKeyNeverOverlaps(t *testing.T) {
+	pool := workerpool.New(8, 100, workerpool.Options{})
+	pool.Start()
+
+	var running, overlaps atomic.Int32
+	for range 500 {
+		pool.SubmitWithKey("merchant-1", func() {
+			if running.Add(1) > 1 {
+				overlaps.Add(1)
+			}
+			running.Add(-1)
+		})
+	}
+
+	require.NoError(t, pool.StopAndWait(context.Background()))
+	assert.Zero(t, overlaps.Load())
+}
+
+func TestSubmitWithKeyHighCardinality(t *testing.T) {
+	const (
+		keys        = 20000
+		tasksPerKey = 5
+	)
+
+	pool := workerpool.New(16, 1000, workerpool.Options{})
+	pool.Start()
+
+	var (
+		mu   sync.Mutex
+		last = make(map[string]int, keys)
+		bad  atomic.Int32
+	)
+	for seq := range tasksPerKey {
+		for k := range keys {
+			key := fmt.Sprintf("payment-%d", k)
+			pool.SubmitWithKey(key, func() {
+				mu.Lock()
+				defer mu.Unlock()
+
+				prev, seen := last[key]
+				if (seen && prev != seq-1) || (!seen && seq != 0) {
+					bad.Add(1)
+				}
+				last[key] = seq
+			})
+		}
+	}
+
+	require.NoError(t, pool.StopAndWait(context.Background()))
+	assert.Zero(t, bad.Load(), "tasks ran out of order")
+	assert.Len(t, last, keys)
+	// Every key is evicted once its queue drains
+	assert.Zero(t, pool.ActiveKeys())
+}
+
+func TestSubmitWithKeyPanicKeepsQueue(t *testing.T) {
+	pool := workerpool.New(1, 10, workerpool.Options{
+		PanicHandler: func(any, []byte) {},
+	})
+	pool.Start()
+
+	var ran atomic.Int32
+	pool.SubmitWithKey("payment-1", func() { panic("boom") })
+	pool.SubmitWithKey("payment-1", func() { ran.Add(1) })
+
+	require.NoError(t, pool.StopAndWait(context.Background()))
+	assert.Equal(t, int32(1), ran.Load())
+	assert.Zero(t, pool.ActiveKeys())
+}
+
+func TestSubmitWithKeyAfterStop(t *testing.T) {
+	pool := workerpool.New(1, 10, workerpool.Options{})
+	pool.Start()
+	require.NoError(t, pool.StopAndWait(context.Background()))
+
+	pool.SubmitWithKey("payment-1", func() {})
+	assert.Zero(t, pool.ActiveKeys())
+}
@@ -1,17 +1,78 @@
 package workerpool
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrPoolFull is returned by TrySubmit when the task buffer is full.
+	ErrPoolFull = errors.New("worker pool is full")
+	// ErrPoolStopped is returned by TrySubmit once the pool is stopped.
+	ErrPoolStopped = errors.New("worker pool is stopped")
+)
 
 type Task func()
 
+// ContextTask is a task that receives the pool's per-task deadline.
+type ContextTask func(ctx context.Context)
+
+// PanicHandler is called with the recovered value and the stack trace when a
+// task panics. The worker keeps running.
+type PanicHandler func(recovered any, stack []byte)
+
+type Options struct {
+	// PanicHandler reports panicking tasks. Defaults to the standard logger.
+	PanicHandler PanicHandler
+	// TaskTimeout is the deadline applied to tasks submitted with
+	// SubmitContext. Zero means no deadline.
+	TaskTimeout time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.PanicHandler == nil {
+		o.PanicHandler = func(recovered any, stack []byte) {
+			log.Printf("worker pool task panicked: %v\n%s", recovered, stack)
+		}
+	}
+
+	return o
+}
+
 type WorkerPool struct {
-	tasks      chan Task
-	sems       sync.Map
-	maxWorkers int
-	once       sync.Once
+	tasks chan Task
+	opts  Options
+	once  sync.Once
+
+	// size is the number of workers the pool should have, workers the number
+	// running. Surplus workers retire after their current task.
+	sizeMu  sync.Mutex
+	size    atomic.Int32
+	workers atomic.Int32
+	resized chan struct{}
+	started bool
+	busy    atomic.Int32
+
+	// mu only guards stopped and registering a sender, never a send itself.
+	// quit is closed on Stop to release senders blocked on a full buffer,
+	// and tasks is closed once the last of them has returned.
+	mu      sync.RWMutex
+	stopped bool
+	quit    chan struct{}
+	senders sync.WaitGroup
+	wg      sync.WaitGroup
+
+	keysMu sync.Mutex
+	keys   map[string]*keyQueue
 }
 
-func New(maxWorkers int, taskBuffer int) *WorkerPool {
+func New(maxWorkers int, taskBuffer int, opts Options) *WorkerPool {
 	if taskBuffer <= 0 {
 		taskBuffer = 10000
 	}
@@ -19,52 +80,247 @@ func New(maxWorkers int, taskBuffer int) *WorkerPool {
 		maxWorkers = 100
 	}
 
-	return &WorkerPool{
-		tasks:      make(chan Task, taskBuffer),
-		maxWorkers: maxWorkers,
+	p := &WorkerPool{
+		tasks:   make(chan Task, taskBuffer),
+		opts:    opts.withDefaults(),
+		resized: make(chan struct{}),
+		quit:    make(chan struct{}),
+		keys:    make(map[string]*keyQueue),
 	}
+	p.size.Store(int32(maxWorkers))
+
+	return p
 }
 
 func (p *WorkerPool) Start() {
 	p.once.Do(func() {
-		for i := 0; i < p.maxWorkers; i++ {
-			go p.worker()
-		}
+		p.sizeMu.Lock()
+		defer p.sizeMu.Unlock()
+
+		p.started = true
+		p.spawn(int(p.size.Load()))
 	})
 }
 
-func (p *WorkerPool) SubmitWithKey(key string, maxConcurrentPerKey int, task Task) {
-	if maxConcurrentPerKey < 1 {
-		maxConcurrentPerKey = 1
+// Resize changes the number of workers. New workers start straight away,
+// surplus ones finish their current task and exit.
+func (p *WorkerPool) Resize(maxWorkers int) error {
+	if maxWorkers <= 0 {
+		return fmt.Errorf("invalid worker count %d", maxWorkers)
 	}
 
-	semI, _ := p.sems.LoadOrStore(key, make(chan struct{}, maxConcurrentPerKey))
-	sem := semI.(chan struct{})
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 
-	p.tasks <- func() {
-		sem <- struct{}{}
-		defer func() { <-sem }()
+	if p.stopped {
+		return ErrPoolStopped
+	}
 
-		defer func() {
-			if r := recover(); r != nil {
+	p.sizeMu.Lock()
+	defer p.sizeMu.Unlock()
 
-			}
-		}()
+	p.size.Store(int32(maxWorkers))
 
-		task()
+	// Before Start the new size is simply used when the workers are spawned
+	if p.started {
+		p.spawn(maxWorkers - int(p.workers.Load()))
 	}
+
+	// Wake idle workers so surplus ones notice they should retire
+	close(p.resized)
+	p.resized = make(chan struct{})
+
+	return nil
+}
+
+// Size is the number of workers the pool is scaled to.
+func (p *WorkerPool) Size() int {
+	return int(p.size.Load())
+}
+
+// QueueDepth is the number of tasks waiting for a worker.
+func (p *WorkerPool) QueueDepth() int {
+	return len(p.tasks)
 }
 
+// Busy is the number of workers currently running a task.
+func (p *WorkerPool) Busy() int {
+	return int(p.busy.Load())
+}
+
+// spawn must be called with p.sizeMu held.
+func (p *WorkerPool) spawn(n int) {
+	if n <= 0 {
+		return
+	}
+
+	p.workers.Add(int32(n))
+	p.wg.Add(n)
+	for i := 0; i < n; i++ {
+		go p.worker()
+	}
+}
+
+// Submit queues task, blocking while the buffer is full. Tasks submitted
+// after Stop are dropped.
 func (p *WorkerPool) Submit(task Task) {
-	p.tasks <- task
+	p.submit(task)
+}
+
+// SubmitContext queues task like Submit. The task runs with a context derived
+// from ctx that carries the pool's TaskTimeout, if one is set.
+func (p *WorkerPool) SubmitContext(ctx context.Context, task ContextTask) {
+	p.submit(p.withDeadline(ctx, task))
+}
+
+// TrySubmit queues task without blocking. It returns ErrPoolFull when the
+// buffer is full and ErrPoolStopped once the pool is stopped.
+func (p *WorkerPool) TrySubmit(task Task) error {
+	if !p.addSender() {
+		return ErrPoolStopped
+	}
+	defer p.senders.Done()
+
+	select {
+	case p.tasks <- task:
+		return nil
+	default:
+		return ErrPoolFull
+	}
+}
+
+// submit drops task once the pool is stopped instead of sending on the
+// closed channel. It reports whether task was queued.
+func (p *WorkerPool) submit(task Task) bool {
+	if !p.addSender() {
+		return false
+	}
+	defer p.senders.Done()
+
+	select {
+	case p.tasks <- task:
+		return true
+	case <-p.quit:
+		return false
+	}
+}
+
+// addSender registers a caller about to send on p.tasks, so that Stop does
+// not close the channel under it. It reports false once the pool is stopped.
+func (p *WorkerPool) addSender() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		return false
+	}
+
+	p.senders.Add(1)
+	return true
+}
+
+func (p *WorkerPool) withDeadline(ctx context.Context, task ContextTask) Task {
+	return func() {
+		if p.opts.TaskTimeout <= 0 {
+			task(ctx)
+			return
+		}
+
+		// The deadline starts when a worker picks the task up, not when it
+		// is queued
+		taskCtx, cancel := context.WithTimeout(ctx, p.opts.TaskTimeout)
+		defer cancel()
+
+		task(taskCtx)
+	}
 }
 
 func (p *WorkerPool) worker() {
-	for task := range p.tasks {
-		task()
+	defer p.wg.Done()
+
+	for {
+		resized, retire := p.checkSize()
+		if retire {
+			return
+		}
+
+		select {
+		case task, ok := <-p.tasks:
+			if !ok {
+				p.workers.Add(-1)
+				return
+			}
+			p.run(task)
+		case <-resized:
+		}
+	}
+}
+
+// checkSize reports whether the calling worker should exit because the pool
+// has been scaled down, and if so takes it out of the count. Otherwise it
+// returns the channel that is closed on the next Resize.
+func (p *WorkerPool) checkSize() (<-chan struct{}, bool) {
+	p.sizeMu.Lock()
+	defer p.sizeMu.Unlock()
+
+	if p.workers.Load() > p.size.Load() {
+		p.workers.Add(-1)
+		return nil, true
 	}
+
+	return p.resized, false
 }
 
+// run executes task on the calling worker.
+func (p *WorkerPool) run(task Task) {
+	p.busy.Add(1)
+	defer p.busy.Add(-1)
+
+	p.call(task)
+}
+
+// call executes task and reports a panic instead of letting it take down the
+// process.
+func (p *WorkerPool) call(task Task) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.opts.PanicHandler(r, debug.Stack())
+		}
+	}()
+
+	task()
+}
+
+// Stop stops accepting tasks. Tasks already queued still run.
 func (p *WorkerPool) Stop() {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	close(p.quit)
+	p.mu.Unlock()
+
+	// Senders blocked on a full buffer give up on quit
+	p.senders.Wait()
 	close(p.tasks)
 }
+
+// StopAndWait stops accepting tasks and waits until every queued task has
+// run, or until ctx is done.
+func (p *WorkerPool) StopAndWait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.Stop()
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,185 @@
+package workerpool_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kalom60/cashflow/platform/workerpool"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopAndWaitDrainsQueuedTasks(t *testing.T) {
+	pool := workerpool.New(2, 10, workerpool.Options{})
+	pool.Start()
+
+	var ran atomic.Int32
+	for range 10 {
+		pool.Submit(func() {
+			time.Sleep(10 * time.Millisecond)
+			ran.Add(1)
+		})
+	}
+
+	assert.NoError(t, pool.StopAndWait(context.Background()))
+	assert.Equal(t, int32(10), ran.Load())
+
+	// Submitting after stop must not panic
+	pool.Submit(func() { ran.Add(1) })
+	assert.Equal(t, int32(10), ran.Load())
+}
+
+func TestStopAndWaitDeadline(t *testing.T) {
+	pool := workerpool.New(1, 1, workerpool.Options{})
+	pool.Start()
+
+	release := make(chan struct{})
+	defer close(release)
+	pool.Submit(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, pool.StopAndWait(ctx), context.DeadlineExceeded)
+}
+
+func TestStopReleasesBlockedSubmit(t *testing.T) {
+	pool := workerpool.New(1, 1, workerpool.Options{})
+	pool.Start()
+
+	release := make(chan struct{})
+	defer close(release)
+	pool.Submit(func() { <-release })
+	pool.Submit(func() {})
+
+	// The buffer is full, so this one blocks until the pool is stopped
+	submitted := make(chan struct{})
+	go func() {
+		pool.Submit(func() {})
+		close(submitted)
+	}()
+
+	// A blocked Submit must not hold up TrySubmit or Stop
+	assert.Eventually(t, func() bool {
+		return pool.TrySubmit(func() {}) == workerpool.ErrPoolFull
+	}, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, pool.StopAndWait(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, pool.TrySubmit(func() {}), workerpool.ErrPoolStopped)
+
+	select {
+	case <-submitted:
+	case <-time.After(time.Second):
+		t.Fatal("Submit still blocked after Stop")
+	}
+}
+
+func TestPanicIsRecovered(t *testing.T) {
+	panics := make(chan any, 1)
+	pool := workerpool.New(1, 10, workerpool.Options{
+		PanicHandler: func(recovered any, stack []byte) {
+			assert.NotEmpty(t, stack)
+			panics <- recovered
+		},
+	})
+	pool.Start()
+
+	var ran atomic.Int32
+	pool.Submit(func() { panic("boom") })
+	pool.Submit(func() { ran.Add(1) })
+
+	assert.NoError(t, pool.StopAndWait(context.Background()))
+	assert.Equal(t, "boom", <-panics)
+	// The same worker carries on with the next task
+	assert.Equal(t, int32(1), ran.Load())
+}
+
+func TestTrySubmit(t *testing.T) {
+	pool := workerpool.New(1, 1, workerpool.Options{})
+
+	// Not started, so the first task fills the buffer
+	assert.NoError(t, pool.TrySubmit(func() {}))
+	assert.ErrorIs(t, pool.TrySubmit(func() {}), workerpool.ErrPoolFull)
+
+	pool.Start()
+	assert.NoError(t, pool.StopAndWait(context.Background()))
+	assert.ErrorIs(t, pool.TrySubmit(func() {}), workerpool.ErrPoolStopped)
+}
+
+func TestSubmitContextDeadline(t *testing.T) {
+	pool := workerpool.New(1, 1, workerpool.Options{TaskTimeout: 20 * time.Millisecond})
+	pool.Start()
+
+	errs := make(chan error, 1)
+	pool.SubmitContext(context.Background(), func(ctx context.Context) {
+		<-ctx.Done()
+		errs <- ctx.Err()
+	})
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("task deadline was not applied")
+	}
+	assert.NoError(t, pool.StopAndWait(context.Background()))
+}
+
+func TestResize(t *testing.T) {
+	pool := workerpool.New(1, 100, workerpool.Options{})
+	pool.Start()
+
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	task := func() {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		running.Add(-1)
+	}
+
+	assert.NoError(t, pool.Resize(4))
+	assert.Equal(t, 4, pool.Size())
+	for range 8 {
+		pool.Submit(task)
+	}
+
+	assert.Eventually(t, func() bool { return running.Load() == 4 }, time.Second, 5*time.Millisecond)
+	close(release)
+
+	assert.Error(t, pool.Resize(0))
+	assert.NoError(t, pool.Resize(2))
+	assert.NoError(t, pool.StopAndWait(context.Background()))
+	assert.Equal(t, int32(4), peak.Load())
+	assert.ErrorIs(t, pool.Resize(3), workerpool.ErrPoolStopped)
+}
+
+func TestResizeShrinksIdleWorkers(t *testing.T) {
+	pool := workerpool.New(4, 100, workerpool.Options{})
+	pool.Start()
+	assert.NoError(t, pool.Resize(1))
+
+	var running, peak atomic.Int32
+	for range 20 {
+		pool.Submit(func() {
+			n := running.Add(1)
+			if n > peak.Load() {
+				peak.Store(n)
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+		})
+	}
+
+	assert.NoError(t, pool.StopAndWait(context.Background()))
+	assert.Equal(t, int32(1), peak.Load())
+}
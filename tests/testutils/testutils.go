@@ -6,8 +6,10 @@ import (
 	"os"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/kalom60/cashflow/initiator"
+	"github.com/kalom60/cashflow/internal/constant/model/db"
 	"github.com/kalom60/cashflow/internal/constant/model/persistencedb"
 	"github.com/kalom60/cashflow/platform/logger"
 	_ "github.com/lib/pq"
@@ -68,10 +70,24 @@ func CleanTables(conn *pgxpool.Pool) {
 	_, err := conn.Exec(
 		ctx,
 		`TRUNCATE TABLE
-			payments, outbox_events
+			payments, outbox_events, refunds, idempotency_keys, payment_status_history,
+			webhook_deliveries, webhook_endpoints, signing_secrets, api_keys, merchants
 		RESTART IDENTITY CASCADE
 	`)
 	if err != nil {
 		log.Fatalf("failed to clean test tables: %v", err)
 	}
 }
+
+// CreateMerchant inserts a merchant for tests that need to own payments.
+func CreateMerchant(persistenceDB persistencedb.PersistenceDB) uuid.UUID {
+	merchant, err := persistenceDB.Queries.CreateMerchant(context.Background(), db.CreateMerchantParams{
+		Name:      "test merchant",
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Fatalf("failed to create test merchant: %v", err)
+	}
+
+	return merchant.ID
+}